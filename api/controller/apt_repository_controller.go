@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AptRepositoryController 以 APT 仓库的形式提供 linux 类型包中的 .deb 文件
+type AptRepositoryController struct {
+	AptRepositoryUsecase domain.AptRepositoryUsecase
+	ClientAccessUsecase  domain.ClientAccessUsecase
+	FileUsecase          domain.FileUsecase
+	Env                  *bootstrap.Env
+}
+
+// authorizeRepositoryClient 校验访问仓库的客户端接入凭证，失败时写入错误响应并返回 false
+// apt/dnf 通过 HTTP Basic 认证传递凭证：密码为 access token（用户名任意），也可以只填用户名；同时支持 x-access-token 请求头
+func authorizeRepositoryClient(c *gin.Context, clientAccessUsecase domain.ClientAccessUsecase, scope domain.RepositoryScope) bool {
+	accessToken := c.GetHeader(constants.AccessToken)
+	if accessToken == "" {
+		if username, password, ok := c.Request.BasicAuth(); ok {
+			accessToken = password
+			if accessToken == "" {
+				accessToken = username
+			}
+		}
+	}
+	if accessToken == "" {
+		c.Header("WWW-Authenticate", `Basic realm="pkms"`)
+		c.JSON(http.StatusUnauthorized, domain.RespError("access_token is required"))
+		return false
+	}
+
+	clientAccess, err := clientAccessUsecase.ValidateAccessToken(c, accessToken)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Basic realm="pkms"`)
+		c.JSON(http.StatusUnauthorized, domain.RespError("无效的访问令牌"))
+		return false
+	}
+	if !scope.Allows(clientAccess) {
+		c.JSON(http.StatusForbidden, domain.RespError("访问令牌无权访问该仓库"))
+		return false
+	}
+	return true
+}
+
+// repositoryScope 从路由参数解析仓库范围
+func repositoryScope(c *gin.Context) (domain.RepositoryScope, bool) {
+	scope := domain.RepositoryScope{Type: c.Param("scope"), ID: c.Param("id")}
+	if scope.Type != domain.RepositoryScopeProject && scope.Type != domain.RepositoryScopePackage {
+		return scope, false
+	}
+	return scope, scope.ID != ""
+}
+
+// PublicKey godoc
+// @Summary      Download repository signing key
+// @Description  Download the OpenPGP public key used to sign APT/YUM repository metadata (ASCII armored)
+// @Tags         Package Repository
+// @Produce      plain
+// @Success      200  {string}  string  "OpenPGP public key"
+// @Failure      404  {object}  domain.Response  "Repository signing is not configured"
+// @Router       /apt/gpg.key [get]
+func (ac *AptRepositoryController) PublicKey(c *gin.Context) {
+	key, err := ac.AptRepositoryUsecase.PublicKey()
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+	c.Data(http.StatusOK, "application/pgp-keys", key)
+}
+
+// Serve godoc
+// @Summary      APT repository
+// @Description  Serve a project (scope=projects) or package (scope=packages) as an APT repository generated from published releases of linux packages.
+// @Description  Index files: dists/{stable|testing}/InRelease, Release, Release.gpg, main/binary-{arch}/Packages[.gz]; package files: pool/{id}/{file}.
+// @Description  Authenticate with a client access token as the HTTP basic auth password.
+// @Tags         Package Repository
+// @Produce      plain
+// @Param        scope  path  string  true  "projects or packages"
+// @Param        id     path  string  true  "Project ID or package ID"
+// @Param        path   path  string  true  "File path within the repository"
+// @Success      200  {file}    file             "Repository file"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token does not belong to this repository"
+// @Failure      404  {object}  domain.Response  "File not found"
+// @Router       /apt/{scope}/{id}/{path} [get]
+func (ac *AptRepositoryController) Serve(c *gin.Context) {
+	scope, ok := repositoryScope(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, domain.RespError("仓库不存在"))
+		return
+	}
+	if !authorizeRepositoryClient(c, ac.ClientAccessUsecase, scope) {
+		return
+	}
+
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+	if strings.HasPrefix(filePath, "pool/") {
+		ac.servePoolFile(c, scope, filePath)
+		return
+	}
+
+	data, err := ac.AptRepositoryUsecase.GetIndexFile(c, scope, filePath)
+	if err != nil {
+		if errors.Is(err, domain.ErrRepositoryFileNotFound) {
+			c.JSON(http.StatusNotFound, domain.RespError("文件不存在"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	switch path.Base(filePath) {
+	case "Packages.gz":
+		contentType = "application/gzip"
+	case "Release.gpg":
+		contentType = "application/pgp-signature"
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// servePoolFile 下载 pool/{id}/{file} 对应的软件包文件，文件名部分只用于显示
+func (ac *AptRepositoryController) servePoolFile(c *gin.Context, scope domain.RepositoryScope, filePath string) {
+	parts := strings.Split(filePath, "/")
+	if len(parts) != 3 {
+		c.JSON(http.StatusNotFound, domain.RespError("文件不存在"))
+		return
+	}
+	lp, err := ac.AptRepositoryUsecase.GetPoolPackage(c, scope, parts[1])
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("文件不存在"))
+		return
+	}
+
+	serveDownload(c, ac.FileUsecase, &fileDownload{
+		Bucket:         ac.Env.S3Bucket,
+		ObjectName:     lp.FilePath,
+		FileName:       parts[2],
+		FileSize:       lp.FileSize,
+		Hash:           lp.SHA256,
+		ModTime:        lp.CreatedAt,
+		RedirectExpiry: downloadRedirectExpiry(ac.Env),
+	})
+}
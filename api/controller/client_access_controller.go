@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"encoding/base64"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/xid"
 	"io"
@@ -8,6 +10,7 @@ import (
 	"pkms/bootstrap"
 	"pkms/domain"
 	"pkms/internal/constants"
+	"pkms/pkg"
 	"strconv"
 	"time"
 )
@@ -18,9 +21,41 @@ type ClientAccessController struct {
 	UpgradeUsecase      domain.UpgradeUsecase
 	FileUsecase         domain.FileUsecase
 	ReleaseUsecase      domain.ReleaseUsecase
+	PatchUsecase        domain.PatchUsecase
+	SigningUsecase      domain.SigningUsecase      // 为空表示未启用签名
+	QuotaUsecase        domain.QuotaUsecase        // 为空表示不检查租户配额
+	LinuxPackageUsecase domain.LinuxPackageUsecase // 为空表示未启用 APT/YUM 仓库
 	Env                 *bootstrap.Env
 }
 
+// authorizeClient 校验请求头中的 access token，失败时直接写入错误响应并返回 false
+func (cac *ClientAccessController) authorizeClient(c *gin.Context) (*domain.ClientAccess, bool) {
+	return authorizeAccessToken(c, cac.ClientAccessUsecase, c.GetHeader(constants.AccessToken))
+}
+
+// authorizeAccessToken 校验 access token 有效、已启用且未过期，失败时直接写入错误响应并返回 false
+func authorizeAccessToken(c *gin.Context, clientAccessUsecase domain.ClientAccessUsecase, accessToken string) (*domain.ClientAccess, bool) {
+	if accessToken == "" {
+		c.JSON(http.StatusUnauthorized, domain.RespError("access_token is required"))
+		return nil, false
+	}
+
+	clientAccess, err := clientAccessUsecase.ValidateAccessToken(c, accessToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, domain.RespError("无效的访问令牌"))
+		return nil, false
+	}
+	if !clientAccess.IsActive {
+		c.JSON(http.StatusForbidden, domain.RespError("客户端接入凭证已被禁用"))
+		return nil, false
+	}
+	if clientAccess.ExpiresAt != nil && clientAccess.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusForbidden, domain.RespError("客户端接入凭证已过期"))
+		return nil, false
+	}
+	return clientAccess, true
+}
+
 // CheckUpdate godoc
 // @Summary      Check for updates
 // @Description  Check for application updates using client access token (no JWT required)
@@ -80,8 +115,15 @@ func (cac *ClientAccessController) CheckUpdate(c *gin.Context) {
 // @Produce      application/octet-stream
 // @Param        id            path   string  true   "Release ID"
 // @Param        access_token  query  string  true   "Client access token"
-// @Param        bucket        query  string  false  "S3 bucket name"
+// @Param        os            query  string  false  "Client operating system, selects the matching asset"
+// @Param        arch          query  string  false  "Client architecture, selects the matching asset"
+// @Param        kind          query  string  false  "Asset kind (binary/archive/installer/package)"
+// @Param        Range         header string  false  "Byte range for resuming, e.g. bytes=1024- (single range only)"
+// @Param        If-None-Match header string  false  "ETag from a previous response"
 // @Success      200  {file}    file    "File download successful"
+// @Success      206  {file}    file    "Partial content"
+// @Success      304  "Not modified"
+// @Failure      416  {object}  domain.Response  "Range not satisfiable or multiple ranges requested"
 // @Failure      400  {object}  domain.Response  "Invalid request parameters"
 // @Failure      401  {object}  domain.Response  "Invalid access token"
 // @Failure      403  {object}  domain.Response  "Access token disabled or expired"
@@ -124,40 +166,42 @@ func (cac *ClientAccessController) Download(c *gin.Context) {
 
 	// 通过release ID获取release信息，从中获取file_path
 	release, err := cac.ReleaseUsecase.GetReleaseByID(c, releaseID)
-	if err != nil {
+	if err != nil || release.IsDraft || release.PackageID != clientAccess.PackageID {
 		c.JSON(http.StatusNotFound, domain.RespError("找不到指定的版本"))
 		return
 	}
 
-	bucket := c.DefaultQuery("bucket", cac.Env.S3Bucket)
-
-	req := &domain.DownloadRequest{
-		Bucket:     bucket,
-		ObjectName: release.FilePath, // 使用release中的file_path
+	// 根据客户端平台选择构件，没有匹配的构件时使用版本主文件
+	download := &fileDownload{
+		Bucket:     cac.Env.S3Bucket,
+		ObjectName: release.FilePath, // 使用release（或构件）中的file_path
+		FileName:   release.FileName,
+		FileSize:   release.FileSize,
+		Hash:       firstNonEmpty(release.SHA256, release.FileHash),
+		ModTime:    release.CreatedAt,
+		// 开启直接下载时重定向到预签名地址
+		RedirectExpiry: downloadRedirectExpiry(cac.Env),
+		OnStart: func() {
+			// 增加下载计数，断点续传的后续请求不重复计数
+			if err := cac.ReleaseUsecase.IncrementDownloadCount(c, releaseID); err != nil {
+				// 记录错误但不阻止下载
+				pkg.Log.Error("Failed to increment download count:", err)
+			}
+		},
 	}
-
-	object, err := cac.FileUsecase.Download(c, req)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
-		return
+	if asset := release.MatchAsset(c.Query("os"), c.Query("arch"), c.Query("kind")); asset != nil {
+		download.ObjectName, download.FileName, download.FileSize = asset.FilePath, asset.FileName, asset.FileSize
+		download.Hash = firstNonEmpty(asset.SHA256, asset.FileHash)
+		download.ModTime = asset.CreatedAt
 	}
-	defer object.Close()
-
-	// 设置响应头，使用release中的文件名
-	c.Header("Content-Disposition", "attachment; filename=\""+release.FileName+"\"")
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", strconv.FormatInt(release.FileSize, 10))
 
-	// 流式传输文件内容
-	if _, err := io.Copy(c.Writer, object); err != nil {
-		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
-		return
-	}
+	// 流式传输文件内容，支持断点续传与条件请求
+	serveDownload(c, cac.FileUsecase, download)
 }
 
 // Release godoc
 // @Summary      Upload artifact for GoReleaser
-// @Description  Upload artifact files for GoReleaser publish process using client access token (no JWT required). Project and package are determined from the access token.
+// @Description  Upload artifact files for GoReleaser publish process using client access token (no JWT required). Project and package are determined from the access token. Uploading to an existing version attaches the file as an additional os/arch asset.
 // @Tags         Client Access
 // @Accept       multipart/form-data
 // @Produce      json
@@ -168,11 +212,15 @@ func (cac *ClientAccessController) Download(c *gin.Context) {
 // @Param        artifact       formData  string  false  "Artifact name"
 // @Param        os             formData  string  false  "Operating system"
 // @Param        arch           formData  string  false  "Architecture"
+// @Param        kind           formData  string  false  "Asset kind, inferred from the file name when empty"
 // @Param        changelog      formData  string  false  "Release changelog"
+// @Param        draft          formData  bool    false  "Create the release as a draft (only when the version does not exist yet)"
+// @Param        prerelease     formData  bool    false  "Mark the release as a prerelease (only when the version does not exist yet)"
 // @Success      201  {object}  domain.Response  "Upload successful"
 // @Failure      400  {object}  domain.Response  "Invalid request data"
 // @Failure      401  {object}  domain.Response  "Invalid access token"
-// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired, or tenant quota exceeded"
+// @Failure      413  {object}  domain.Response  "File exceeds the tenant's maximum file size"
 // @Failure      500  {object}  domain.Response  "Internal server error"
 // @Router       /client-access/release [post]
 func (cac *ClientAccessController) Release(c *gin.Context) {
@@ -203,41 +251,14 @@ func (cac *ClientAccessController) Release(c *gin.Context) {
 	}
 
 	// 获取GoReleaser相关参数
-	version := c.PostForm("version")
-	versionCode := c.PostForm("version_code")
-	artifact := c.PostForm("artifact")
-	osParam := c.PostForm("os")
-	arch := c.PostForm("arch")
-	changelog := c.PostForm("changelog")
+	params := parseArtifactParams(c.PostForm)
 
 	// 参数验证
-	if version == "" {
+	if params.version == "" {
 		c.JSON(http.StatusBadRequest, domain.RespError("version is required"))
 		return
 	}
 
-	// 从clientAccess中获取project_id和package_id
-	projectID := clientAccess.ProjectID
-	packageID := clientAccess.PackageID
-
-	// 检查版本是否已经存在,如果不同的包下面的releases 有相同的versionCode和versionName是允许的
-	existingReleases, err := cac.ReleaseUsecase.GetReleasesByPackage(c, packageID)
-	if err == nil {
-		for _, existingRelease := range existingReleases {
-			// 检查 package_id、version_code 和 version_name 是否都相同
-			packageIDMatch := existingRelease.PackageID == packageID
-			versionCodeMatch := (versionCode == "" && existingRelease.VersionCode == "") ||
-				(versionCode != "" && existingRelease.VersionCode == versionCode)
-			versionNameMatch := (version == "" && existingRelease.VersionName == "") ||
-				(version != "" && existingRelease.VersionName == version)
-
-			if packageIDMatch && versionCodeMatch && versionNameMatch {
-				c.JSON(http.StatusBadRequest, domain.RespError("版本已存在，相同的版本号不能重复上传"))
-				return
-			}
-		}
-	}
-
 	// 获取上传的文件 (所有验证通过后再处理文件)
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
@@ -246,17 +267,77 @@ func (cac *ClientAccessController) Release(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// 生成 release ID (在文件上传前生成，确保目录结构一致)
+	cac.publishArtifact(c, clientAccess, params, file, header.Filename, header.Size)
+}
+
+// artifactParams GoReleaser 上传构件时携带的版本与平台信息
+type artifactParams struct {
+	version      string
+	versionCode  string
+	artifact     string
+	os           string
+	arch         string
+	kind         string
+	changelog    string
+	isDraft      bool
+	isPrerelease bool
+}
+
+// parseArtifactParams 从表单（或上传会话的元数据）中读取构件参数
+func parseArtifactParams(get func(string) string) *artifactParams {
+	params := &artifactParams{
+		version:     get("version"),
+		versionCode: get("version_code"),
+		artifact:    get("artifact"),
+		os:          pkg.NormalizeOS(get("os")),
+		arch:        pkg.NormalizeArch(get("arch")),
+		kind:        get("kind"),
+		changelog:   get("changelog"),
+	}
+	params.isDraft, _ = strconv.ParseBool(get("draft"))
+	params.isPrerelease, _ = strconv.ParseBool(get("prerelease"))
+	return params
+}
+
+// publishArtifact 将上传的文件写入存储，并创建版本或追加为已有版本的构件，成功时返回版本 ID 和 true
+func (cac *ClientAccessController) publishArtifact(c *gin.Context, clientAccess *domain.ClientAccess, params *artifactParams, file io.Reader, fileName string, fileSize int64) (string, bool) {
+	// 从clientAccess中获取project_id和package_id
+	projectID := clientAccess.ProjectID
+	packageID := clientAccess.PackageID
+	version, versionCode := params.version, params.versionCode
+
+	// 查找版本是否已经存在，已存在时将本次上传的文件作为该版本的构件追加
+	existingRelease := cac.findRelease(c, packageID, version, versionCode)
+
+	kind := params.kind
+	if kind == "" {
+		kind = pkg.GetArtifactKind(fileName)
+	}
+
+	// 生成 release ID (在文件上传前生成，确保目录结构一致)；追加构件时沿用已有版本的 ID
 	releaseID := xid.New().String()
+	if existingRelease != nil {
+		releaseID = existingRelease.ID
+		for _, asset := range existingRelease.Assets {
+			if asset.OS == params.os && asset.Arch == params.arch && asset.Kind == kind {
+				c.JSON(http.StatusBadRequest, domain.RespError("该版本下相同平台的构件已存在，不能重复上传"))
+				return "", false
+			}
+		}
+	}
+	if !checkUploadQuota(c, cac.QuotaUsecase, packageID, fileSize, existingRelease == nil) {
+		return "", false
+	}
+
 	// 构建文件路径，支持GoReleaser的文件组织方式
 	hierarchicalPrefix := projectID + "/" + packageID + "/" + releaseID
 	// 准备上传请求
 	uploadReq := &domain.UploadRequest{
 		Bucket:      cac.Env.S3Bucket,
-		ObjectName:  header.Filename,
+		ObjectName:  fileName,
 		Prefix:      hierarchicalPrefix,
 		Reader:      file,
-		Size:        header.Size,
+		Size:        fileSize,
 		ContentType: "application/octet-stream",
 	}
 
@@ -264,51 +345,329 @@ func (cac *ClientAccessController) Release(c *gin.Context) {
 	uploadResp, err := cac.FileUsecase.Upload(c, uploadReq)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.RespError("文件上传失败: "+err.Error()))
-		return
+		return "", false
 	}
 
-	// 创建Release记录
-	release := &domain.Release{
-		ID:            releaseID, // 使用预先生成的 ID
-		PackageID:     packageID,
-		VersionCode:   versionCode,
-		VersionName:   version,
-		TagName:       version, // GoReleaser通常使用tag作为版本
-		ChangeLog:     changelog,
-		FilePath:      uploadResp.ObjectName,
-		FileName:      header.Filename,
-		FileSize:      header.Size,
-		FileHash:      uploadResp.ETag,
-		DownloadCount: 0,
-		CreatedBy:     clientAccess.CreatedBy, // 使用客户端接入凭证的创建者
-		CreatedAt:     time.Now(),
-	}
-
-	// 保存Release到数据库
-	if err := cac.ReleaseUsecase.CreateRelease(c, release); err != nil {
-		// 如果数据库保存失败，尝试删除已上传的文件
-		_ = cac.FileUsecase.Delete(c, cac.Env.S3Bucket, uploadResp.ObjectName)
-		c.JSON(http.StatusInternalServerError, domain.RespError("创建发布记录失败: "+err.Error()))
-		return
+	asset := &domain.ReleaseAsset{
+		ReleaseID: releaseID,
+		OS:        params.os,
+		Arch:      params.arch,
+		Kind:      kind,
+		Artifact:  params.artifact,
+		FilePath:  uploadResp.ObjectName,
+		FileName:  fileName,
+		FileSize:  fileSize,
+		FileHash:  uploadResp.ETag,
+		SHA256:    uploadResp.SHA256,
+		SHA512:    uploadResp.SHA512,
+	}
+	signature := signArtifact(c, cac.SigningUsecase, clientAccess.TenantID, uploadResp.SHA512)
+	if signature != nil {
+		asset.Signature = signature.Signature
+		asset.SignatureKeyID = signature.KeyID
+	}
+
+	createdBy := clientAccess.CreatedBy // 使用客户端接入凭证的创建者
+	if existingRelease == nil {
+		// 创建Release记录，首个上传的文件作为版本的主文件
+		release := &domain.Release{
+			ID:            releaseID, // 使用预先生成的 ID
+			PackageID:     packageID,
+			VersionCode:   versionCode,
+			VersionName:   version,
+			TagName:       version, // GoReleaser通常使用tag作为版本
+			ChangeLog:     params.changelog,
+			FilePath:      uploadResp.ObjectName,
+			FileName:      fileName,
+			FileSize:      fileSize,
+			FileHash:      uploadResp.ETag,
+			SHA256:        uploadResp.SHA256,
+			SHA512:        uploadResp.SHA512,
+			DownloadCount: 0,
+			IsDraft:       params.isDraft,
+			IsPrerelease:  params.isPrerelease,
+			CreatedBy:     createdBy,
+			CreatedAt:     time.Now(),
+		}
+		if signature != nil {
+			release.Signature = signature.Signature
+			release.SignatureKeyID = signature.KeyID
+		}
+
+		// 保存Release到数据库
+		err := cac.ReleaseUsecase.CreateRelease(c, release)
+		if errors.Is(err, domain.ErrReleaseExists) {
+			// 并发上传同一新版本时只有一个请求能创建版本，其余请求把文件追加为该版本的构件
+			if existingRelease = cac.findRelease(c, packageID, version, versionCode); existingRelease != nil {
+				releaseID = existingRelease.ID
+				asset.ReleaseID = releaseID
+				err = nil
+			}
+		}
+		if err != nil {
+			// 如果数据库保存失败，尝试删除已上传的文件
+			_ = cac.FileUsecase.Delete(c, cac.Env.S3Bucket, uploadResp.ObjectName)
+			c.JSON(http.StatusInternalServerError, domain.RespError("创建发布记录失败: "+err.Error()))
+			return "", false
+		}
+	}
+
+	// 记录构件
+	if err := cac.ReleaseUsecase.AddAsset(c, asset); err != nil {
+		if existingRelease == nil {
+			// 新建的版本没有构件不可用，连同已上传的文件一起回滚
+			if delErr := cac.ReleaseUsecase.DeleteRelease(c, releaseID); delErr != nil {
+				pkg.Log.Errorf("回滚发布记录 %s 失败: %v", releaseID, delErr)
+			}
+		} else {
+			_ = cac.FileUsecase.Delete(c, cac.Env.S3Bucket, uploadResp.ObjectName)
+		}
+		c.JSON(http.StatusInternalServerError, domain.RespError("创建构件记录失败: "+err.Error()))
+		return "", false
 	}
+	indexLinuxPackages(c, cac.LinuxPackageUsecase, releaseID)
 
 	// 构建响应数据
 	response := map[string]interface{}{
-		"release_id":   release.ID,
+		"release_id":   releaseID,
+		"asset_id":     asset.ID,
 		"file_path":    uploadResp.ObjectName,
-		"file_size":    header.Size,
-		"filename":     header.Filename,
+		"file_size":    fileSize,
+		"filename":     fileName,
 		"project_id":   projectID,
 		"version":      version,
 		"version_code": versionCode,
 		"package_id":   packageID,
-		"artifact":     artifact,
-		"os":           osParam,
-		"arch":         arch,
-		"changelog":    changelog,
+		"artifact":     params.artifact,
+		"os":           params.os,
+		"arch":         params.arch,
+		"kind":         kind,
+		"changelog":    params.changelog,
+		"sha256":       uploadResp.SHA256,
 		"upload_time":  time.Now(),
-		"created_by":   release.CreatedBy,
+		"created_by":   createdBy,
 	}
 
 	c.JSON(http.StatusCreated, domain.RespSuccess(response))
+	return releaseID, true
+}
+
+// findRelease 查找软件包下 version_code 和 version_name 都相同的版本，不存在时返回 nil
+func (cac *ClientAccessController) findRelease(c *gin.Context, packageID, version, versionCode string) *domain.Release {
+	releases, err := cac.ReleaseUsecase.GetReleasesByPackage(c, packageID)
+	if err != nil {
+		return nil
+	}
+	for _, r := range releases {
+		// 检查 package_id、version_code 和 version_name 是否都相同
+		packageIDMatch := r.PackageID == packageID
+		versionCodeMatch := (versionCode == "" && r.VersionCode == "") ||
+			(versionCode != "" && r.VersionCode == versionCode)
+		versionNameMatch := (version == "" && r.VersionName == "") ||
+			(version != "" && r.VersionName == version)
+
+		if packageIDMatch && versionCodeMatch && versionNameMatch {
+			return r
+		}
+	}
+	return nil
+}
+
+// AuthorizeUpload 客户端分片上传的会话归属：接入凭证所属的租户与包
+func (cac *ClientAccessController) AuthorizeUpload(c *gin.Context) (*domain.UploadSession, bool) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return nil, false
+	}
+	return &domain.UploadSession{
+		TenantID:       clientAccess.TenantID,
+		PackageID:      clientAccess.PackageID,
+		ClientAccessID: clientAccess.ID,
+		CreatedBy:      clientAccess.CreatedBy,
+	}, true
+}
+
+// FinalizeUpload 分片上传完成后发布构件，参数与 Release 接口的表单字段一致
+func (cac *ClientAccessController) FinalizeUpload(c *gin.Context, session *domain.UploadSession, file io.Reader, param func(string) string) (string, bool) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return "", false
+	}
+
+	params := parseArtifactParams(param)
+	if params.version == "" {
+		c.JSON(http.StatusBadRequest, domain.RespError("version is required"))
+		return "", false
+	}
+	return cac.publishArtifact(c, clientAccess, params, file, session.FileName, session.Size)
+}
+
+// DownloadSignature godoc
+// @Summary      Download detached signature
+// @Description  Download the raw 64-byte Ed25519ph signature over the SHA-512 digest of the release file (or the asset selected by os/arch/kind)
+// @Tags         Client Access
+// @Produce      application/octet-stream
+// @Param        x-access-token  header  string  true   "Client access token"
+// @Param        id              path    string  true   "Release ID"
+// @Param        os              query   string  false  "Operating system of the asset"
+// @Param        arch            query   string  false  "Architecture of the asset"
+// @Param        kind            query   string  false  "Asset kind"
+// @Success      200  {file}    file    "Detached signature"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "Release or signature not found"
+// @Router       /client-access/signature/{id} [get]
+func (cac *ClientAccessController) DownloadSignature(c *gin.Context) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return
+	}
+
+	release, err := cac.ReleaseUsecase.GetReleaseByID(c, c.Param("id"))
+	if err != nil || release.IsDraft || release.PackageID != clientAccess.PackageID {
+		c.JSON(http.StatusNotFound, domain.RespError("找不到指定的版本"))
+		return
+	}
+
+	fileName, signature, keyID := release.FileName, release.Signature, release.SignatureKeyID
+	if asset := release.MatchAsset(c.Query("os"), c.Query("arch"), c.Query("kind")); asset != nil {
+		fileName, signature, keyID = asset.FileName, asset.Signature, asset.SignatureKeyID
+	}
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if signature == "" || err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("该文件没有签名"))
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+fileName+".sig")
+	c.Header("X-Signature-Key-Id", keyID)
+	c.Data(http.StatusOK, "application/octet-stream", raw)
+}
+
+// DownloadPublicKeys godoc
+// @Summary      Download signing public keys
+// @Description  Download the PEM encoded public keys of the tenant owning the access token, including retired keys
+// @Tags         Client Access
+// @Produce      plain
+// @Param        x-access-token  header  string  true  "Client access token"
+// @Success      200  {string}  string  "PEM encoded public keys"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "Signing disabled"
+// @Router       /client-access/signing-keys [get]
+func (cac *ClientAccessController) DownloadPublicKeys(c *gin.Context) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return
+	}
+	if cac.SigningUsecase == nil {
+		c.JSON(http.StatusNotFound, domain.RespError("未启用发布签名"))
+		return
+	}
+	writePublicKeys(c, cac.SigningUsecase, clientAccess.TenantID)
+}
+
+// DownloadChecksums godoc
+// @Summary      Download release checksums
+// @Description  Download a SHA256SUMS/SHA512SUMS style checksum file for a release of the package bound to the access token
+// @Tags         Client Access
+// @Produce      plain
+// @Param        x-access-token  header  string  true   "Client access token"
+// @Param        id              path    string  true   "Release ID"
+// @Param        algorithm       query   string  false  "Digest algorithm: sha256 (default) or sha512"
+// @Success      200  {string}  string  "Checksum file"
+// @Failure      400  {object}  domain.Response  "Unsupported algorithm"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "Release not found"
+// @Router       /client-access/checksums/{id} [get]
+func (cac *ClientAccessController) DownloadChecksums(c *gin.Context) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return
+	}
+
+	release, err := cac.ReleaseUsecase.GetReleaseByID(c, c.Param("id"))
+	if err != nil || release.IsDraft || release.PackageID != clientAccess.PackageID {
+		c.JSON(http.StatusNotFound, domain.RespError("找不到指定的版本"))
+		return
+	}
+	writeChecksumFile(c, release)
+}
+
+// DownloadPatch godoc
+// @Summary      Download delta patch
+// @Description  Download a binary delta patch advertised by the update check (patch_url). Apply it to the client's current file to get the new release file.
+// @Tags         Client Access
+// @Produce      application/octet-stream
+// @Param        x-access-token  header  string  true  "Client access token"
+// @Param        id              path    string  true  "Patch ID"
+// @Param        Range           header  string  false "Byte range for resuming, e.g. bytes=1024-"
+// @Success      200  {file}    file    "Patch download successful"
+// @Success      206  {file}    file    "Partial content"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "Patch not found"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /client-access/patch/{id} [get]
+func (cac *ClientAccessController) DownloadPatch(c *gin.Context) {
+	clientAccess, ok := cac.authorizeClient(c)
+	if !ok {
+		return
+	}
+
+	patch, err := cac.PatchUsecase.GetPatchByID(c, c.Param("id"))
+	if err != nil || patch.Status != domain.PatchStatusReady || patch.PackageID != clientAccess.PackageID {
+		c.JSON(http.StatusNotFound, domain.RespError("找不到指定的补丁"))
+		return
+	}
+
+	// 补丁文件生成后不再变化，以补丁 ID 作为 ETag
+	serveDownload(c, cac.FileUsecase, &fileDownload{
+		Bucket:         cac.Env.S3Bucket,
+		ObjectName:     patch.FilePath,
+		FileName:       patch.ID + ".patch",
+		FileSize:       patch.FileSize,
+		Hash:           patch.ID,
+		ModTime:        patch.UpdatedAt,
+		RedirectExpiry: downloadRedirectExpiry(cac.Env),
+		Headers: map[string]string{
+			"X-Patch-Format":      patch.Format,
+			"X-Patch-Target-Hash": patch.TargetHash,
+		},
+	})
+}
+
+// ReportUpgrade godoc
+// @Summary      Report upgrade result
+// @Description  Report the download/install/start outcome of an update. Each client keeps one result per release and stage; failures count towards automatic rollout pausing.
+// @Tags         Client Access
+// @Accept       json
+// @Produce      json
+// @Param        x-access-token  header  string                       true  "Client access token"
+// @Param        request         body    domain.ReportUpgradeRequest  true  "Upgrade result"
+// @Success      200  {object}  domain.Response{data=domain.UpgradeReport}  "Report saved"
+// @Failure      400  {object}  domain.Response  "Invalid request data"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Router       /client-access/report [post]
+func (cac *ClientAccessController) ReportUpgrade(c *gin.Context) {
+	accessToken := c.GetHeader(constants.AccessToken)
+	if accessToken == "" {
+		c.JSON(http.StatusUnauthorized, domain.RespError("access_token is required"))
+		return
+	}
+
+	var request domain.ReportUpgradeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	report, err := cac.UpgradeUsecase.ReportUpgradeResult(c, &request, c.ClientIP(), accessToken)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(report))
 }
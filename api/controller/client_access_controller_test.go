@@ -0,0 +1,109 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+)
+
+const testFileContent = "0123456789abcdef"
+
+type tokenClientAccessUsecase struct {
+	domain.ClientAccessUsecase
+	access *domain.ClientAccess
+}
+
+func (u *tokenClientAccessUsecase) ValidateAccessToken(_ context.Context, token string) (*domain.ClientAccess, error) {
+	if token != "token" {
+		return nil, errors.New("invalid token")
+	}
+	return u.access, nil
+}
+
+// countingReleaseUsecase 记录下载计数的版本业务逻辑
+type countingReleaseUsecase struct {
+	domain.ReleaseUsecase
+	releases  map[string]*domain.Release
+	downloads map[string]int
+}
+
+func (u *countingReleaseUsecase) GetReleaseByID(_ context.Context, id string) (*domain.Release, error) {
+	if release, ok := u.releases[id]; ok {
+		return release, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (u *countingReleaseUsecase) IncrementDownloadCount(_ context.Context, releaseID string) error {
+	u.downloads[releaseID]++
+	return nil
+}
+
+type memoryFileUsecase struct {
+	domain.FileUsecase
+}
+
+func (u *memoryFileUsecase) DownloadRange(_ context.Context, _ *domain.DownloadRequest, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader([]byte(testFileContent[offset : offset+length]))), nil
+}
+
+func newClientDownloadRouter(releases *countingReleaseUsecase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cac := &controller.ClientAccessController{
+		ClientAccessUsecase: &tokenClientAccessUsecase{access: &domain.ClientAccess{PackageID: "pkg", IsActive: true}},
+		ReleaseUsecase:      releases,
+		FileUsecase:         &memoryFileUsecase{},
+		Env:                 &bootstrap.Env{S3Bucket: "pkms"},
+	}
+	router := gin.New()
+	router.GET("/client-access/download/:id", cac.Download)
+	router.HEAD("/client-access/download/:id", cac.Download)
+	return router
+}
+
+func clientDownload(router *gin.Engine, method, id string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/client-access/download/"+id, nil)
+	req.Header.Set(constants.AccessToken, "token")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestClientDownloadCountsOnce(t *testing.T) {
+	releases := &countingReleaseUsecase{
+		releases: map[string]*domain.Release{
+			"r1": {ID: "r1", PackageID: "pkg", FilePath: "p/r1/app.bin", FileName: "app.bin", FileSize: int64(len(testFileContent))},
+		},
+		downloads: make(map[string]int),
+	}
+	router := newClientDownloadRouter(releases)
+
+	w := clientDownload(router, http.MethodGet, "r1", nil)
+	if w.Code != http.StatusOK || w.Body.String() != testFileContent {
+		t.Fatalf("unexpected response: %d %q", w.Code, w.Body.String())
+	}
+	// 断点续传与 HEAD 请求不重复计数
+	if w := clientDownload(router, http.MethodGet, "r1", map[string]string{"Range": "bytes=8-"}); w.Code != http.StatusPartialContent {
+		t.Fatalf("unexpected range response: %d", w.Code)
+	}
+	if w := clientDownload(router, http.MethodHead, "r1", nil); w.Code != http.StatusOK {
+		t.Fatalf("unexpected head response: %d", w.Code)
+	}
+	if got := releases.downloads["r1"]; got != 1 {
+		t.Fatalf("download count = %d, want 1", got)
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 type DashboardController struct {
 	DashboardUsecase domain.DashboardUsecase
+	QuotaUsecase     domain.QuotaUsecase
 	Env              *bootstrap.Env
 }
 
@@ -65,3 +66,24 @@ func (dc *DashboardController) GetRecentActivities(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, domain.RespSuccess(activities))
 }
+
+// GetUsage godoc
+// @Summary      Get storage usage
+// @Description  Retrieve storage usage and quota of the current tenant, broken down by project and package
+// @Tags         Dashboard
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header    string  true  "Tenant ID"
+// @Success      200  {object}  domain.Response{data=domain.TenantUsage}  "Usage retrieved successfully"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /dashboard/usage [get]
+func (dc *DashboardController) GetUsage(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+	usage, err := dc.QuotaUsecase.GetUsage(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(usage))
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceController 设备登记相关接口
+type DeviceController struct {
+	DeviceUsecase domain.DeviceUsecase
+	Env           *bootstrap.Env
+}
+
+// GetDevices 获取设备列表
+// @Summary      Get devices
+// @Description  List devices registered by update checks, ordered by last seen time
+// @Tags         Devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        package_id   query  string  false  "Package ID"
+// @Param        version      query  string  false  "Current version"
+// @Param        os           query  string  false  "Operating system"
+// @Param        channel      query  string  false  "Release channel"
+// @Param        active_days  query  int     false  "Only devices seen within the last N days"
+// @Param        page         query  int     false  "Page number (default: 1)"
+// @Param        page_size    query  int     false  "Page size (default: 20)"
+// @Success      200          {object} domain.Response  "Successfully retrieved devices"
+// @Failure      500          {object} domain.Response  "Internal server error"
+// @Router       /devices [get]
+func (dc *DeviceController) GetDevices(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+
+	// 解析分页参数
+	var params domain.QueryParams
+	if p := c.Query("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil {
+			params.Page = v
+		}
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil {
+			params.PageSize = v
+		}
+	}
+
+	// 验证和设置默认参数
+	domain.ValidateQueryParams(&params)
+
+	// 构建过滤条件
+	filters := make(map[string]interface{})
+	if packageID := c.Query("package_id"); packageID != "" {
+		filters["package_id"] = packageID
+	}
+	if version := c.Query("version"); version != "" {
+		filters["version"] = version
+	}
+	if osName := c.Query("os"); osName != "" {
+		filters["os"] = osName
+	}
+	if channel := c.Query("channel"); channel != "" {
+		filters["channel"] = channel
+	}
+	if activeSince := parseActiveSince(c); activeSince != nil {
+		filters["active_since"] = *activeSince
+	}
+
+	result, err := dc.DeviceUsecase.GetDevicesPaged(c, tenantID, filters, params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(result))
+}
+
+// GetVersionDistribution 获取设备版本分布
+// @Summary      Get device version distribution
+// @Description  Count devices of a package grouped by their current version
+// @Tags         Devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        package_id   query  string  true   "Package ID"
+// @Param        active_days  query  int     false  "Only devices seen within the last N days"
+// @Success      200          {object} domain.Response  "Successfully retrieved version distribution"
+// @Failure      400          {object} domain.Response  "Bad request - missing package_id"
+// @Failure      500          {object} domain.Response  "Internal server error"
+// @Router       /devices/versions [get]
+func (dc *DeviceController) GetVersionDistribution(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+
+	packageID := c.Query("package_id")
+	if packageID == "" {
+		c.JSON(http.StatusBadRequest, domain.RespError("package_id 不能为空"))
+		return
+	}
+
+	counts, err := dc.DeviceUsecase.GetVersionDistribution(c, tenantID, packageID, parseActiveSince(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(counts))
+}
+
+// GetDevice 获取设备详情
+// @Summary      Get device
+// @Description  Get a registered device by ID
+// @Tags         Devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Device ID"
+// @Success      200  {object} domain.Response  "Successfully retrieved device"
+// @Failure      404  {object} domain.Response  "Device not found"
+// @Router       /devices/{id} [get]
+func (dc *DeviceController) GetDevice(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+	id := c.Param("id")
+
+	device, err := dc.DeviceUsecase.GetDeviceByID(c, id)
+	if err != nil || device.TenantID != tenantID {
+		c.JSON(http.StatusNotFound, domain.RespError("设备不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(device))
+}
+
+// DeleteDevice 删除设备记录
+// @Summary      Delete device
+// @Description  Remove a registered device; it is registered again on its next update check
+// @Tags         Devices
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Device ID"
+// @Success      200  {object} domain.Response  "Successfully deleted device"
+// @Failure      404  {object} domain.Response  "Device not found"
+// @Failure      500  {object} domain.Response  "Internal server error"
+// @Router       /devices/{id} [delete]
+func (dc *DeviceController) DeleteDevice(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+	id := c.Param("id")
+
+	device, err := dc.DeviceUsecase.GetDeviceByID(c, id)
+	if err != nil || device.TenantID != tenantID {
+		c.JSON(http.StatusNotFound, domain.RespError("设备不存在"))
+		return
+	}
+
+	if err := dc.DeviceUsecase.DeleteDevice(c, id); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess("设备删除成功"))
+}
+
+// parseActiveSince 将 active_days 查询参数转换为活跃起始时间
+func parseActiveSince(c *gin.Context) *time.Time {
+	days, err := strconv.Atoi(c.Query("active_days"))
+	if err != nil || days <= 0 {
+		return nil
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	return &since
+}
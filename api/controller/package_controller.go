@@ -129,6 +129,29 @@ func (pc *PackageController) GetPackage(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.RespSuccess(pkg))
 }
 
+// GetLatestRelease 获取包的最新稳定版本
+// @Summary      Get latest stable release of package
+// @Description  Get the release marked as latest, or the highest published non-prerelease version
+// @Tags         Packages
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true  "Tenant ID"
+// @Param        id           path    string  true  "Package ID"
+// @Success      200  {object}  domain.Response{data=domain.Release}  "Successfully retrieved latest release"
+// @Failure      401  {object}  domain.Response  "Unauthorized"
+// @Failure      403  {object}  domain.Response  "Forbidden"
+// @Failure      404  {object}  domain.Response  "No stable release found"
+// @Router       /packages/{id}/releases/latest [get]
+func (pc *PackageController) GetLatestRelease(c *gin.Context) {
+	release, err := pc.PackageUsecase.GetLatestRelease(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(release))
+}
+
 // DeletePackage 删除包
 // @Summary      Delete package by ID
 // @Description  Delete a specific package by its ID
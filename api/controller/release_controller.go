@@ -1,12 +1,17 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"pkms/internal/constants"
+	"pkms/internal/versioning"
 	"pkms/pkg"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"pkms/bootstrap"
 	"pkms/domain"
@@ -16,70 +21,19 @@ import (
 )
 
 type ReleaseController struct {
-	ReleaseUsecase domain.ReleaseUsecase
-	PackageUsecase domain.PackageUsecase
-	FileUsecase    domain.FileUsecase
-	ShareUsecase   domain.ShareUsecase
-	Env            *bootstrap.Env
+	ReleaseUsecase      domain.ReleaseUsecase
+	PackageUsecase      domain.PackageUsecase
+	FileUsecase         domain.FileUsecase
+	ShareUsecase        domain.ShareUsecase
+	SigningUsecase      domain.SigningUsecase      // 为空表示未启用签名
+	QuotaUsecase        domain.QuotaUsecase        // 为空表示不检查租户配额
+	LinuxPackageUsecase domain.LinuxPackageUsecase // 为空表示未启用 APT/YUM 仓库
+	Env                 *bootstrap.Env
 }
 
-// compareVersions 比较两个版本号，返回 1 表示 v1 > v2，-1 表示 v1 < v2，0 表示 v1 = v2
-func compareVersions(v1, v2 string) int {
-	// 如果两个版本号相同，返回0
-	if v1 == v2 {
-		return 0
-	}
-
-	// 分割版本号
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	// 获取最大长度
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
-	}
-
-	// 逐个比较版本号的每一部分
-	for i := 0; i < maxLen; i++ {
-		var n1, n2 int
-		var err1, err2 error
-
-		// 获取第i部分的数值，如果不存在则为0
-		if i < len(parts1) {
-			n1, err1 = strconv.Atoi(parts1[i])
-		}
-		if i < len(parts2) {
-			n2, err2 = strconv.Atoi(parts2[i])
-		}
-
-		// 如果解析失败，使用字符串比较
-		if err1 != nil || err2 != nil {
-			part1 := ""
-			part2 := ""
-			if i < len(parts1) {
-				part1 = parts1[i]
-			}
-			if i < len(parts2) {
-				part2 = parts2[i]
-			}
-			if part1 > part2 {
-				return 1
-			} else if part1 < part2 {
-				return -1
-			}
-			continue
-		}
-
-		// 数值比较
-		if n1 > n2 {
-			return 1
-		} else if n1 < n2 {
-			return -1
-		}
-	}
-
-	return 0
+// compareVersions 按包的版本方案比较两个版本号，返回 1 表示 v1 > v2，-1 表示 v1 < v2，0 表示 v1 = v2
+func compareVersions(scheme, v1, v2 string) int {
+	return versioning.Compare(scheme, v1, v2)
 }
 
 // GetReleases 获取包的所有发布版本
@@ -129,24 +83,17 @@ func (rc *ReleaseController) GetReleases(c *gin.Context) {
 		return
 	}
 
-	// 按版本号排序，优先使用 version_code，其次使用 version_name，版本号大的排在前面
-	sort.Slice(releases, func(i, j int) bool {
-		// 优先比较 version_code
-		if releases[i].VersionCode != "" && releases[j].VersionCode != "" {
-			return compareVersions(releases[i].VersionCode, releases[j].VersionCode) > 0
-		}
-		// 如果 version_code 为空，使用 version_name
-		if releases[i].VersionName != "" && releases[j].VersionName != "" {
-			return compareVersions(releases[i].VersionName, releases[j].VersionName) > 0
-		}
-		// 如果一个有 version_code 另一个没有，有的排在前面
-		if releases[i].VersionCode != "" && releases[j].VersionCode == "" {
-			return true
-		}
-		if releases[i].VersionCode == "" && releases[j].VersionCode != "" {
-			return false
+	// 使用包配置的版本方案排序
+	scheme := versioning.SchemeAuto
+	if packageInfo, err := rc.PackageUsecase.GetPackageByID(c, packageID); err == nil && packageInfo.VersionScheme != "" {
+		scheme = packageInfo.VersionScheme
+	}
+
+	// 按版本号（见 Release.Version）排序，版本号大的排在前面；相同或都没有版本号时按创建时间倒序
+	sort.SliceStable(releases, func(i, j int) bool {
+		if c := compareVersions(scheme, releases[i].Version(), releases[j].Version()); c != 0 {
+			return c > 0
 		}
-		// 都没有版本号时，按创建时间倒序排列（新的在前面）
 		return releases[i].CreatedAt.After(releases[j].CreatedAt)
 	})
 
@@ -206,13 +153,15 @@ func (rc *ReleaseController) GetRelease(c *gin.Context) {
 // @Param        changelog     formData  string  false  "Changelog"
 // @Param        tag_name      formData  string  false  "Tag name"
 // @Param        is_latest     formData  bool    false  "Is latest version"
+// @Param        is_prerelease formData  bool    false  "Is prerelease"
+// @Param        is_draft      formData  bool    false  "Create as draft (invisible until published)"
 // @Success      201           {object} domain.Response  "Successfully uploaded release"
 // @Failure      400           {object} domain.Response  "Bad request - missing required fields or file upload failed"
+// @Failure      403           {object} domain.Response  "Tenant storage quota or release limit exceeded"
+// @Failure      413           {object} domain.Response  "File exceeds the tenant's maximum file size"
 // @Failure      500           {object} domain.Response  "Internal server error"
 // @Router       /releases/upload [post]
 func (rc *ReleaseController) UploadRelease(c *gin.Context) {
-	userID := c.GetString(constants.UserID)
-
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
@@ -222,35 +171,84 @@ func (rc *ReleaseController) UploadRelease(c *gin.Context) {
 	defer file.Close()
 
 	// 构建上传请求
+	req := parseReleaseUploadRequest(c.PostForm)
+	req.File = file
+	req.FileName = header.Filename
+	req.FileSize = header.Size
+	req.FileHeader = header.Header.Get("Content-Type")
+
+	rc.createReleaseFromUpload(c, req, c.PostForm("version_name"))
+}
+
+// parseReleaseUploadRequest 从表单（或上传会话的元数据）中读取版本信息
+func parseReleaseUploadRequest(get func(string) string) *domain.ReleaseUploadRequest {
 	req := &domain.ReleaseUploadRequest{
-		PackageID:  c.PostForm("package_id"),
-		Name:       c.PostForm("name"),
-		Version:    c.PostForm("version_code"),
-		Type:       c.PostForm("type"),
-		Changelog:  c.PostForm("changelog"),
-		TagName:    c.PostForm("tag_name"),
-		File:       file,
-		FileName:   header.Filename,
-		FileSize:   header.Size,
-		FileHeader: header.Header.Get("Content-Type"),
+		PackageID: get("package_id"),
+		Name:      get("name"),
+		Version:   get("version_code"),
+		Type:      get("type"),
+		Changelog: get("changelog"),
+		TagName:   get("tag_name"),
 	}
 
+	// 解析可选的布尔字段
+	req.IsLatest, _ = strconv.ParseBool(get("is_latest"))
+	req.IsPrerelease, _ = strconv.ParseBool(get("is_prerelease"))
+	req.IsDraft, _ = strconv.ParseBool(get("is_draft"))
+	return req
+}
+
+// AuthorizeUpload 管理端分片上传的会话归属：当前租户与用户
+func (rc *ReleaseController) AuthorizeUpload(c *gin.Context) (*domain.UploadSession, bool) {
+	tenantID := c.GetHeader(constants.TenantID)
+	if tenantID == "" {
+		c.JSON(http.StatusBadRequest, domain.RespError("Tenant ID is required"))
+		return nil, false
+	}
+	return &domain.UploadSession{
+		TenantID:  tenantID,
+		PackageID: c.Query("package_id"),
+		CreatedBy: c.GetString(constants.UserID),
+	}, true
+}
+
+// FinalizeUpload 分片上传完成后创建发布版本，版本信息来自会话元数据（可由请求覆盖）
+func (rc *ReleaseController) FinalizeUpload(c *gin.Context, session *domain.UploadSession, file io.Reader, param func(string) string) (string, bool) {
+	req := parseReleaseUploadRequest(param)
+	if req.PackageID == "" {
+		req.PackageID = session.PackageID
+	}
+	req.File = file
+	req.FileName = session.FileName
+	req.FileSize = session.Size
+	req.FileHeader = session.ContentType
+
+	return rc.createReleaseFromUpload(c, req, param("version_name"))
+}
+
+// createReleaseFromUpload 将上传的文件写入存储并创建发布版本（表单上传与分片上传共用），成功时返回版本 ID 和 true
+func (rc *ReleaseController) createReleaseFromUpload(c *gin.Context, req *domain.ReleaseUploadRequest, versionName string) (string, bool) {
+	userID := c.GetString(constants.UserID)
+
 	// 验证必需字段
 	if req.PackageID == "" || req.Version == "" {
 		c.JSON(http.StatusBadRequest, domain.RespError("Missing required fields: package_id, version_code"))
-		return
+		return "", false
+	}
+	if req.IsLatest && (req.IsPrerelease || versioning.IsPrerelease(req.Version)) {
+		c.JSON(http.StatusBadRequest, domain.RespError("Prerelease cannot be marked as latest"))
+		return "", false
 	}
 
 	// 获取包信息以获取 project_id
 	packageInfo, err := rc.PackageUsecase.GetPackageByID(c, req.PackageID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, domain.RespError("Package not found: "+err.Error()))
-		return
+		return "", false
 	}
 
-	// 解析可选的布尔字段
-	if isLatest := c.PostForm("is_latest"); isLatest == "true" {
-		req.IsLatest = true
+	if !checkUploadQuota(c, rc.QuotaUsecase, req.PackageID, req.FileSize, true) {
+		return "", false
 	}
 
 	// 生成 release ID (在文件上传前生成，确保目录结构一致)
@@ -263,41 +261,82 @@ func (rc *ReleaseController) UploadRelease(c *gin.Context) {
 	// 上传文件到存储
 	uploadRequest := &domain.UploadRequest{
 		Bucket:      rc.Env.S3Bucket,
-		ObjectName:  header.Filename,
+		ObjectName:  req.FileName,
 		Prefix:      hierarchicalPrefix,
-		Reader:      file,
-		Size:        header.Size,
-		ContentType: header.Header.Get("Content-Type"),
+		Reader:      req.File,
+		Size:        req.FileSize,
+		ContentType: req.FileHeader,
 	}
 	uploadResp, err := rc.FileUsecase.Upload(c, uploadRequest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.RespError("File upload failed: "+err.Error()))
-		return
+		return "", false
 	}
-	pkg.Log.Printf("文件上传成功: %s -> %s", header.Filename, uploadResp.ObjectName)
+	pkg.Log.Printf("文件上传成功: %s -> %s", req.FileName, uploadResp.ObjectName)
 
 	// 创建发布版本，使用预先生成的 release ID 确保与文件路径一致
 	release := &domain.Release{
-		ID:          releaseID, // 使用预先生成的 ID
-		PackageID:   req.PackageID,
-		VersionCode: req.Version,
-		TagName:     req.TagName,
-		VersionName: c.PostForm("version_name"),
-		ChangeLog:   req.Changelog,
-		FileName:    req.FileName,
-		FileSize:    req.FileSize,
-		FilePath:    uploadResp.ObjectName,
-		FileHash:    uploadResp.ETag,
-		CreatedBy:   userID,
+		ID:           releaseID, // 使用预先生成的 ID
+		PackageID:    req.PackageID,
+		VersionCode:  req.Version,
+		TagName:      req.TagName,
+		VersionName:  versionName,
+		ChangeLog:    req.Changelog,
+		FileName:     req.FileName,
+		FileSize:     req.FileSize,
+		FilePath:     uploadResp.ObjectName,
+		FileHash:     uploadResp.ETag,
+		SHA256:       uploadResp.SHA256,
+		SHA512:       uploadResp.SHA512,
+		IsDraft:      req.IsDraft,
+		IsPrerelease: req.IsPrerelease,
+		IsLatest:     req.IsLatest,
+		CreatedBy:    userID,
+	}
+	if signature := signArtifact(c, rc.SigningUsecase, c.GetHeader(constants.TenantID), uploadResp.SHA512); signature != nil {
+		release.Signature = signature.Signature
+		release.SignatureKeyID = signature.KeyID
 	}
 
 	err = rc.ReleaseUsecase.CreateRelease(c, release)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, domain.RespError("Create release failed: "+err.Error()))
-		return
+		return "", false
 	}
+	indexLinuxPackages(c, rc.LinuxPackageUsecase, release.ID)
 
 	c.JSON(http.StatusCreated, domain.RespSuccess(release))
+	return release.ID, true
+}
+
+// checkUploadQuota 检查包所属租户的配额，超出时写入 413（单个文件过大）或 403（存储总量或版本数已满）响应
+func checkUploadQuota(c *gin.Context, quotaUsecase domain.QuotaUsecase, packageID string, fileSize int64, newRelease bool) bool {
+	if quotaUsecase == nil {
+		return true
+	}
+	err := quotaUsecase.CheckUpload(c, packageID, fileSize, newRelease)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrFileTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, domain.RespError(err.Error()))
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		c.JSON(http.StatusForbidden, domain.RespError(err.Error()))
+	default:
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+	}
+	return false
+}
+
+// indexLinuxPackages 版本文件写入后提取其中 .deb/.rpm 文件的元数据
+// 无法解析的文件会被记录并排除在 APT/YUM 仓库之外，只记录日志，不影响上传
+func indexLinuxPackages(c context.Context, linuxPackageUsecase domain.LinuxPackageUsecase, releaseID string) {
+	if linuxPackageUsecase == nil {
+		return
+	}
+	if err := linuxPackageUsecase.IndexRelease(c, releaseID); err != nil {
+		pkg.Log.Warnf("版本 %s 中的软件包文件无法解析，不会出现在仓库索引中: %v", releaseID, err)
+	}
 }
 
 // DeleteRelease 删除发布版本
@@ -323,14 +362,19 @@ func (rc *ReleaseController) DeleteRelease(c *gin.Context) {
 
 // DownloadRelease 下载发布版本文件
 // @Summary      Download release file
-// @Description  Download the file associated with a specific release
+// @Description  Download the file associated with a specific release. Supports Range/If-Range for resuming and ETag/Last-Modified conditional requests.
 // @Tags         Releases
 // @Accept       json
 // @Produce      application/octet-stream
 // @Security     BearerAuth
-// @Param        id   path     string  true  "Release ID"
+// @Param        id             path     string  true   "Release ID"
+// @Param        Range          header   string  false  "Byte range, e.g. bytes=1024- (single range only)"
+// @Param        If-None-Match  header   string  false  "ETag from a previous response"
 // @Success      200  {file}   file    "Successfully downloaded release file"
+// @Success      206  {file}   file    "Partial content"
+// @Success      304  "Not modified"
 // @Failure      404  {object} domain.Response  "Release not found"
+// @Failure      416  {object} domain.Response  "Range not satisfiable or multiple ranges requested"
 // @Failure      500  {object} domain.Response  "Download failed"
 // @Router       /releases/{id}/download [get]
 func (rc *ReleaseController) DownloadRelease(c *gin.Context) {
@@ -342,35 +386,172 @@ func (rc *ReleaseController) DownloadRelease(c *gin.Context) {
 		return
 	}
 
-	// 增加下载计数
-	err = rc.ReleaseUsecase.IncrementDownloadCount(c, releaseID)
+	serveDownload(c, rc.FileUsecase, &fileDownload{
+		Bucket:     rc.Env.S3Bucket,
+		ObjectName: release.FilePath,
+		FileName:   release.FileName,
+		FileSize:   release.FileSize,
+		Hash:       firstNonEmpty(release.SHA256, release.FileHash),
+		ModTime:    release.CreatedAt,
+		// 开启直接下载时重定向到预签名地址
+		RedirectExpiry: downloadRedirectExpiry(rc.Env),
+		OnStart: func() {
+			// 增加下载计数，断点续传的后续请求不重复计数
+			if err := rc.ReleaseUsecase.IncrementDownloadCount(c, releaseID); err != nil {
+				// 记录错误但不阻止下载
+				pkg.Log.Error("Failed to increment download count:", err)
+			}
+		},
+	})
+}
+
+// fileDownload 一次文件下载：存储位置、响应文件名以及用于条件请求的元数据
+type fileDownload struct {
+	Bucket     string
+	ObjectName string
+	FileName   string
+	FileSize   int64
+	Hash       string            // 生成 ETag 的文件摘要，为空时使用大小与修改时间生成弱 ETag
+	ModTime    time.Time         // Last-Modified
+	Headers    map[string]string // 额外的响应头
+	OnStart    func()            // 开始传输文件起始部分时调用（304 与从中间续传的请求不会调用）
+	// RedirectExpiry 大于 0 时 GET 请求重定向到有时效的直接下载地址，不再由服务端转发文件
+	RedirectExpiry time.Duration
+}
+
+// downloadRedirectExpiry 开启直接下载时返回地址有效期，否则返回 0
+func downloadRedirectExpiry(env *bootstrap.Env) time.Duration {
+	if !env.DownloadRedirect || env.DownloadURLExpirySeconds <= 0 {
+		return 0
+	}
+	return time.Duration(env.DownloadURLExpirySeconds) * time.Second
+}
+
+// serveDownload 流式输出文件，支持 Range/If-Range 断点续传以及 If-None-Match/If-Modified-Since 条件请求
+// 只支持单一区间，多区间与超出文件范围的区间返回 416
+func serveDownload(c *gin.Context, fileUsecase domain.FileUsecase, d *fileDownload) {
+	etag := "\"" + d.Hash + "\""
+	if d.Hash == "" {
+		etag = "W/\"" + strconv.FormatInt(d.FileSize, 36) + "-" + strconv.FormatInt(d.ModTime.Unix(), 36) + "\""
+	}
+	c.Header("ETag", etag)
+	if !d.ModTime.IsZero() {
+		c.Header("Last-Modified", d.ModTime.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("Content-Disposition", "attachment; filename=\""+d.FileName+"\"")
+	for k, v := range d.Headers {
+		c.Header(k, v)
+	}
+
+	// 条件请求：If-None-Match 优先于 If-Modified-Since
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if pkg.ETagMatches(inm, etag, true) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if pkg.NotModifiedSince(c.GetHeader("If-Modified-Since"), d.ModTime) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// If-Range 不匹配时（文件已变化）忽略 Range，返回完整文件
+	rangeHeader := c.GetHeader("Range")
+	if !pkg.IfRangeMatches(c.GetHeader("If-Range"), etag, d.ModTime) {
+		rangeHeader = ""
+	}
+	byteRange, err := pkg.ParseRange(rangeHeader, d.FileSize)
 	if err != nil {
-		// 记录错误但不阻止下载
-		pkg.Log.Error("Failed to increment download count:", err)
+		c.Header("Content-Range", "bytes */"+strconv.FormatInt(d.FileSize, 10))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, domain.RespError(err.Error()))
+		return
 	}
 
-	// 构建下载请求
-	downloadRequest := &domain.DownloadRequest{
-		Bucket:     rc.Env.S3Bucket,
-		ObjectName: release.FilePath,
+	status, offset, length := http.StatusOK, int64(0), d.FileSize
+	if byteRange != nil {
+		status, offset, length = http.StatusPartialContent, byteRange.Start, byteRange.Length
+		c.Header("Content-Range", byteRange.ContentRange(d.FileSize))
 	}
 
-	reader, err := rc.FileUsecase.Download(c, downloadRequest)
+	request := &domain.DownloadRequest{
+		Bucket:     d.Bucket,
+		ObjectName: d.ObjectName,
+	}
+
+	// 直接下载：鉴权与计数在这里完成，文件由对象存储（或 /files 签名地址）提供，Range 由客户端随重定向重新发送
+	if d.RedirectExpiry > 0 && c.Request.Method == http.MethodGet {
+		location, err := fileUsecase.PresignDownload(c, request, &domain.PresignOptions{
+			FileName: d.FileName,
+			FileSize: d.FileSize,
+			Hash:     d.Hash,
+			Expiry:   d.RedirectExpiry,
+		})
+		if err == nil {
+			if offset == 0 && d.OnStart != nil {
+				d.OnStart()
+			}
+			c.Header("Content-Range", "")
+			c.Header("Cache-Control", "no-store")
+			c.Redirect(http.StatusFound, location)
+			return
+		}
+		pkg.Log.Errorf("生成直接下载地址失败，改为服务端转发: %v", err)
+	}
+
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Length", strconv.FormatInt(length, 10))
+		c.Status(status)
+		return
+	}
+
+	reader, err := fileUsecase.DownloadRange(c, request, offset, length)
 	if err != nil {
+		c.Header("Content-Range", "")
 		c.JSON(http.StatusInternalServerError, domain.RespError("Download failed: "+err.Error()))
 		return
 	}
 	defer reader.Close()
 
-	// 设置响应头
-	c.Header("Content-Disposition", "attachment; filename="+release.FileName)
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", strconv.FormatInt(release.FileSize, 10))
+	if offset == 0 && d.OnStart != nil {
+		d.OnStart()
+	}
 
 	// 流式传输文件
-	c.DataFromReader(http.StatusOK, release.FileSize, "application/octet-stream", reader, map[string]string{
-		"Content-Disposition": "attachment; filename=" + release.FileName,
-	})
+	c.DataFromReader(status, length, "application/octet-stream", reader, nil)
+}
+
+// DownloadChecksums 下载版本的校验和文件
+// @Summary      Download release checksums
+// @Description  Download a SHA256SUMS/SHA512SUMS style checksum file covering the release file and all of its assets
+// @Tags         Releases
+// @Produce      plain
+// @Security     BearerAuth
+// @Param        id         path     string  true   "Release ID"
+// @Param        algorithm  query    string  false  "Digest algorithm: sha256 (default) or sha512"
+// @Success      200  {string}  string  "Checksum file"
+// @Failure      400  {object}  domain.Response  "Unsupported algorithm"
+// @Failure      404  {object}  domain.Response  "Release not found"
+// @Router       /releases/{id}/checksums [get]
+func (rc *ReleaseController) DownloadChecksums(c *gin.Context) {
+	release, err := rc.ReleaseUsecase.GetReleaseByID(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("Release not found"))
+		return
+	}
+	writeChecksumFile(c, release)
+}
+
+// writeChecksumFile 按 algorithm 查询参数输出版本的校验和文件
+func writeChecksumFile(c *gin.Context, release *domain.Release) {
+	algorithm := strings.ToLower(c.DefaultQuery("algorithm", domain.ChecksumSHA256))
+	data, err := release.ChecksumFile(algorithm)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+domain.ChecksumFileName(algorithm))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", data)
 }
 
 // GetLatestRelease 获取包的最新发布版本
@@ -396,6 +577,46 @@ func (rc *ReleaseController) GetLatestRelease(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.RespSuccess(release))
 }
 
+// PublishRelease 发布草稿版本
+// @Summary      Publish draft release
+// @Description  Publish a draft release so that it becomes visible to share, download and upgrade paths
+// @Tags         Releases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Release ID"
+// @Success      200  {object} domain.Response  "Successfully published release"
+// @Failure      400  {object} domain.Response  "Release is not a draft"
+// @Router       /releases/{id}/publish [post]
+func (rc *ReleaseController) PublishRelease(c *gin.Context) {
+	release, err := rc.ReleaseUsecase.PublishRelease(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(release))
+}
+
+// SetLatestRelease 将发布版本标记为包的最新版本
+// @Summary      Mark release as latest
+// @Description  Mark a published stable release as the latest release of its package
+// @Tags         Releases
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Release ID"
+// @Success      200  {object} domain.Response  "Successfully marked release as latest"
+// @Failure      400  {object} domain.Response  "Release is a draft or prerelease"
+// @Router       /releases/{id}/latest [post]
+func (rc *ReleaseController) SetLatestRelease(c *gin.Context) {
+	release, err := rc.ReleaseUsecase.SetLatestRelease(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(release))
+}
+
 // CreateShareLink 创建发布版本分享链接
 // @Summary      Create share link
 // @Description  Create a shareable link for a specific release
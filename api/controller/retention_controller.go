@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"net/http"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RetentionController 版本保留策略管理
+type RetentionController struct {
+	RetentionUsecase domain.RetentionUsecase
+	Env              *bootstrap.Env
+}
+
+// GetPolicies 获取保留策略
+// @Summary      List retention policies
+// @Description  List the tenant's release retention policies, optionally filtered by project
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true   "Tenant ID"
+// @Param        project_id   query   string  false  "Project ID"
+// @Success      200  {object}  domain.Response{data=[]domain.RetentionPolicy}  "Successfully retrieved retention policies"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /retention-policies [get]
+func (rc *RetentionController) GetPolicies(c *gin.Context) {
+	policies, err := rc.RetentionUsecase.ListPolicies(c, c.GetHeader(constants.TenantID), c.Query("project_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(policies))
+}
+
+// CreatePolicy 创建保留策略
+// @Summary      Create retention policy
+// @Description  Create a retention policy for a project (all its packages) or a single package; a package policy takes precedence over its project's policy. The latest release, releases that are or were upgrade targets and releases with active shares are never deleted.
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string                               true  "Tenant ID"
+// @Param        request      body    domain.CreateRetentionPolicyRequest  true  "Retention policy"
+// @Success      201  {object}  domain.Response{data=domain.RetentionPolicy}  "Successfully created retention policy"
+// @Failure      400  {object}  domain.Response  "Invalid request or policy already exists"
+// @Router       /retention-policies [post]
+func (rc *RetentionController) CreatePolicy(c *gin.Context) {
+	var request domain.CreateRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	policy, err := rc.RetentionUsecase.CreatePolicy(c, c.GetHeader(constants.TenantID), c.GetString(constants.UserID), &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, domain.RespSuccess(policy))
+}
+
+// UpdatePolicy 更新保留策略
+// @Summary      Update retention policy
+// @Description  Update the rules of a retention policy or enable/disable it
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string                               true  "Tenant ID"
+// @Param        id           path    string                               true  "Policy ID"
+// @Param        request      body    domain.UpdateRetentionPolicyRequest  true  "Fields to update"
+// @Success      200  {object}  domain.Response{data=domain.RetentionPolicy}  "Successfully updated retention policy"
+// @Failure      400  {object}  domain.Response  "Invalid request or policy not found"
+// @Router       /retention-policies/{id} [put]
+func (rc *RetentionController) UpdatePolicy(c *gin.Context) {
+	var request domain.UpdateRetentionPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	policy, err := rc.RetentionUsecase.UpdatePolicy(c, c.GetHeader(constants.TenantID), c.Param("id"), &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(policy))
+}
+
+// DeletePolicy 删除保留策略
+// @Summary      Delete retention policy
+// @Description  Delete a retention policy; releases are no longer pruned by it
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true  "Tenant ID"
+// @Param        id           path    string  true  "Policy ID"
+// @Success      200  {object}  domain.Response  "Successfully deleted retention policy"
+// @Failure      404  {object}  domain.Response  "Policy not found"
+// @Router       /retention-policies/{id} [delete]
+func (rc *RetentionController) DeletePolicy(c *gin.Context) {
+	if err := rc.RetentionUsecase.DeletePolicy(c, c.GetHeader(constants.TenantID), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess("Retention policy deleted successfully"))
+}
+
+// DryRun 预览保留策略
+// @Summary      Retention dry run
+// @Description  List the releases the enabled retention policies would delete on the next scheduled run, without deleting anything
+// @Tags         Retention
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true   "Tenant ID"
+// @Param        project_id   query   string  false  "Only evaluate the policies of this project"
+// @Success      200  {object}  domain.Response{data=domain.RetentionResult}  "Releases that would be deleted"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /retention-policies/dry-run [get]
+func (rc *RetentionController) DryRun(c *gin.Context) {
+	result, err := rc.RetentionUsecase.Evaluate(c, c.GetHeader(constants.TenantID), c.Query("project_id"), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(result))
+}
@@ -6,7 +6,6 @@ import (
 	"pkms/domain"
 	"pkms/internal/constants"
 	"pkms/pkg"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,13 +19,18 @@ type ShareController struct {
 
 // DownloadSharedRelease 通过分享码下载发布版本文件
 // @Summary      Download shared release
-// @Description  Download a release file using a share code without authentication
+// @Description  Download a release file using a share code without authentication. Supports Range/If-Range for resuming and ETag/Last-Modified conditional requests.
 // @Tags         share(public)
 // @Accept       json
 // @Produce      application/octet-stream
-// @Param        code  path  string  true  "Share code"
+// @Param        code           path    string  true   "Share code"
+// @Param        Range          header  string  false  "Byte range, e.g. bytes=1024- (single range only)"
+// @Param        If-None-Match  header  string  false  "ETag from a previous response"
 // @Success      200   {file} file   "Successfully downloaded shared release file"
+// @Success      206   {file} file   "Partial content"
+// @Success      304   "Not modified"
 // @Failure      404   {object} domain.Response  "Share not found or expired"
+// @Failure      416   {object} domain.Response  "Range not satisfiable or multiple ranges requested"
 // @Failure      500   {object} domain.Response  "Download failed"
 // @Router       /share/{code} [get]
 func (sc *ShareController) DownloadSharedRelease(c *gin.Context) {
@@ -41,39 +45,27 @@ func (sc *ShareController) DownloadSharedRelease(c *gin.Context) {
 
 	// Get release information
 	release, err := sc.ReleaseUsecase.GetReleaseByID(c, share.ReleaseID)
-	if err != nil {
+	if err != nil || release.IsDraft {
 		c.JSON(http.StatusNotFound, domain.RespError("Release not found"))
 		return
 	}
 
-	// Increment download count
-	err = sc.ReleaseUsecase.IncrementDownloadCount(c, share.ReleaseID)
-	if err != nil {
-		// Log error but don't block download
-		pkg.Log.Error("Failed to increment download count:", err)
-	}
-
-	// Build download request
-	downloadRequest := &domain.DownloadRequest{
+	serveDownload(c, sc.FileUsecase, &fileDownload{
 		Bucket:     sc.Env.S3Bucket,
 		ObjectName: release.FilePath,
-	}
-
-	reader, err := sc.FileUsecase.Download(c, downloadRequest)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.RespError("Download failed: "+err.Error()))
-		return
-	}
-	defer reader.Close()
-
-	// Set response headers
-	c.Header("Content-Disposition", "attachment; filename="+release.FileName)
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", strconv.FormatInt(release.FileSize, 10))
-
-	// Stream file
-	c.DataFromReader(http.StatusOK, release.FileSize, "application/octet-stream", reader, map[string]string{
-		"Content-Disposition": "attachment; filename=" + release.FileName,
+		FileName:   release.FileName,
+		FileSize:   release.FileSize,
+		Hash:       firstNonEmpty(release.SHA256, release.FileHash),
+		ModTime:    release.CreatedAt,
+		// Redirect to a short-lived direct URL when enabled
+		RedirectExpiry: downloadRedirectExpiry(sc.Env),
+		OnStart: func() {
+			// Increment download count, resumed requests are not counted again
+			if err := sc.ReleaseUsecase.IncrementDownloadCount(c, share.ReleaseID); err != nil {
+				// Log error but don't block download
+				pkg.Log.Error("Failed to increment download count:", err)
+			}
+		},
 	})
 }
 
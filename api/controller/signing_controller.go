@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+	"pkms/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SigningController 租户签名密钥管理接口
+type SigningController struct {
+	SigningUsecase domain.SigningUsecase
+	Env            *bootstrap.Env
+}
+
+// GetSigningKeys 获取签名密钥列表
+// @Summary      Get signing keys
+// @Description  List the tenant's signing keys, including retired keys that are still valid for verifying older signatures
+// @Tags         Signing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true  "Tenant ID"
+// @Success      200  {object}  domain.Response{data=[]domain.SigningKey}  "Successfully retrieved signing keys"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /signing-keys [get]
+func (sc *SigningController) GetSigningKeys(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+
+	keys, err := sc.SigningUsecase.ListKeys(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(keys))
+}
+
+// RotateSigningKey 轮换签名密钥
+// @Summary      Rotate signing key
+// @Description  Generate a new active signing key; the previous key is retired but stays downloadable for verification
+// @Tags         Signing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true  "Tenant ID"
+// @Success      200  {object}  domain.Response{data=domain.SigningKey}  "Successfully rotated signing key"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /signing-keys/rotate [post]
+func (sc *SigningController) RotateSigningKey(c *gin.Context) {
+	tenantID := c.GetHeader(constants.TenantID)
+
+	key, err := sc.SigningUsecase.RotateKey(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(key))
+}
+
+// DownloadPublicKeys 下载租户公钥（PEM）
+// @Summary      Download public keys
+// @Description  Download the tenant's public keys as PEM blocks; each block carries its Key-Id and Status headers
+// @Tags         Signing
+// @Produce      plain
+// @Security     BearerAuth
+// @Param        x-tenant-id  header  string  true  "Tenant ID"
+// @Success      200  {string}  string  "PEM encoded public keys"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /signing-keys/public.pem [get]
+func (sc *SigningController) DownloadPublicKeys(c *gin.Context) {
+	writePublicKeys(c, sc.SigningUsecase, c.GetHeader(constants.TenantID))
+}
+
+// writePublicKeys 输出租户的 PEM 公钥集合
+func writePublicKeys(c *gin.Context, signingUsecase domain.SigningUsecase, tenantID string) {
+	data, err := signingUsecase.PublicKeysPEM(c, tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=pkms-signing-keys.pem")
+	c.Data(http.StatusOK, "application/x-pem-file", data)
+}
+
+// signArtifact 对上传的构件签名，未启用签名或没有 SHA-512 摘要时返回 nil
+// 签名失败只记录日志，不影响上传
+func signArtifact(c context.Context, signingUsecase domain.SigningUsecase, tenantID, sha512Hex string) *domain.ArtifactSignature {
+	if signingUsecase == nil || sha512Hex == "" {
+		return nil
+	}
+	signature, err := signingUsecase.SignArtifact(c, tenantID, sha512Hex)
+	if err != nil {
+		pkg.Log.Errorf("构件签名失败 (tenant %s): %v", tenantID, err)
+		return nil
+	}
+	return signature
+}
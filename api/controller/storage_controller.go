@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StorageController 存储后端迁移与巡检管理接口（仅系统管理员）
+type StorageController struct {
+	StorageMigrationUsecase domain.StorageMigrationUsecase
+	StorageScanUsecase      domain.StorageScanUsecase
+	Env                     *bootstrap.Env
+}
+
+// StartMigration 发起存储迁移
+// @Summary      Start storage migration
+// @Description  Copy every object referenced by releases, assets and patches from the configured storage to the target storage in the background, verifying size and SHA-256. An unfinished migration with the same source and target is resumed. Switch STORAGE_TYPE after it completes.
+// @Tags         Storage
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.StartStorageMigrationRequest  true  "Target storage"
+// @Success      202      {object}  domain.Response{data=domain.StorageMigration}  "Migration started"
+// @Failure      400      {object}  domain.Response  "Invalid target or a migration is already running"
+// @Router       /storage/migrations [post]
+func (sc *StorageController) StartMigration(c *gin.Context) {
+	var request domain.StartStorageMigrationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	migration, err := sc.StorageMigrationUsecase.Prepare(c, request.Target, c.GetString(constants.UserID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	// 迁移耗时较长，在后台执行，通过 GET /storage/migrations/:id 查询进度
+	go func(migration domain.StorageMigration) {
+		_ = sc.StorageMigrationUsecase.Run(context.Background(), &migration)
+	}(*migration)
+
+	c.JSON(http.StatusAccepted, domain.RespSuccess(migration))
+}
+
+// GetMigrations 获取存储迁移记录
+// @Summary      List storage migrations
+// @Description  List the most recent storage migrations with their progress
+// @Tags         Storage
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  domain.Response{data=[]domain.StorageMigration}  "Storage migrations"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /storage/migrations [get]
+func (sc *StorageController) GetMigrations(c *gin.Context) {
+	migrations, err := sc.StorageMigrationUsecase.ListMigrations(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(migrations))
+}
+
+// GetMigration 获取存储迁移进度
+// @Summary      Get storage migration
+// @Description  Get progress, counters and failed objects of a storage migration
+// @Tags         Storage
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Migration ID"
+// @Success      200  {object}  domain.Response{data=domain.StorageMigration}  "Storage migration"
+// @Failure      404  {object}  domain.Response  "Migration not found"
+// @Router       /storage/migrations/{id} [get]
+func (sc *StorageController) GetMigration(c *gin.Context) {
+	migration, err := sc.StorageMigrationUsecase.GetMigration(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("Migration not found"))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(migration))
+}
+
+// StartScan 发起存储巡检
+// @Summary      Start storage scan
+// @Description  Reconcile stored objects with release, asset and patch records in the background. Orphaned objects are reported (and deleted when requested and older than STORAGE_ORPHAN_GRACE_HOURS); releases whose object is missing or whose size/SHA-256 no longer matches are flagged.
+// @Tags         Storage
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.StartStorageScanRequest  false  "Scan options"
+// @Success      202      {object}  domain.Response{data=domain.StorageScan}  "Scan started"
+// @Failure      400      {object}  domain.Response  "A scan is already running"
+// @Router       /storage/scans [post]
+func (sc *StorageController) StartScan(c *gin.Context) {
+	var request domain.StartStorageScanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+			return
+		}
+	}
+
+	scan, err := sc.StorageScanUsecase.StartScan(c, &request, domain.StorageScanTriggerManual, c.GetString(constants.UserID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusAccepted, domain.RespSuccess(scan))
+}
+
+// GetScans 获取存储巡检记录
+// @Summary      List storage scans
+// @Description  List the most recent storage scans with their counters (issue details omitted)
+// @Tags         Storage
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  domain.Response{data=[]domain.StorageScan}  "Storage scans"
+// @Failure      500  {object}  domain.Response  "Internal server error"
+// @Router       /storage/scans [get]
+func (sc *StorageController) GetScans(c *gin.Context) {
+	scans, err := sc.StorageScanUsecase.ListScans(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(scans))
+}
+
+// GetScan 获取存储巡检结果
+// @Summary      Get storage scan
+// @Description  Get counters and issues (orphaned, missing and corrupt objects) of a storage scan
+// @Tags         Storage
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Scan ID"
+// @Success      200  {object}  domain.Response{data=domain.StorageScan}  "Storage scan"
+// @Failure      404  {object}  domain.Response  "Scan not found"
+// @Router       /storage/scans/{id} [get]
+func (sc *StorageController) GetScan(c *gin.Context) {
+	scan, err := sc.StorageScanUsecase.GetScan(c, c.Param("id"))
+	if err != nil || scan == nil {
+		c.JSON(http.StatusNotFound, domain.RespError("Scan not found"))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(scan))
+}
@@ -12,6 +12,7 @@ import (
 
 type TenantController struct {
 	TenantUsecase domain.TenantUseCase
+	QuotaUsecase  domain.QuotaUsecase
 	CasbinManager *casbin.CasbinManager
 	Env           *bootstrap.Env
 }
@@ -148,6 +149,92 @@ func (tc *TenantController) DeleteTenant(c *gin.Context) {
 	c.JSON(http.StatusOK, domain.RespSuccess("Tenant deleted successfully"))
 }
 
+// GetTenantQuota 获取租户配额
+// @Summary      Get tenant quota
+// @Description  Get storage quota, maximum file size and maximum releases per package of a tenant (0 means unlimited)
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Tenant ID"
+// @Success      200  {object} domain.Response{data=domain.TenantQuota}  "Successfully retrieved tenant quota"
+// @Failure      404  {object} domain.Response  "Tenant not found"
+// @Router       /tenants/{id}/quota [get]
+func (tc *TenantController) GetTenantQuota(c *gin.Context) {
+	quota, err := tc.QuotaUsecase.GetQuota(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("Tenant not found"))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(quota))
+}
+
+// UpdateTenantQuota 设置租户配额
+// @Summary      Update tenant quota
+// @Description  Set storage quota, maximum file size and maximum releases per package of a tenant (0 means unlimited). Enforced on release uploads and GoReleaser publishing.
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id     path     string              true  "Tenant ID"
+// @Param        quota  body     domain.TenantQuota  true  "Tenant quota"
+// @Success      200    {object} domain.Response{data=domain.TenantQuota}  "Successfully updated tenant quota"
+// @Failure      400    {object} domain.Response  "Bad request - invalid parameters"
+// @Failure      500    {object} domain.Response  "Internal server error"
+// @Router       /tenants/{id}/quota [put]
+func (tc *TenantController) UpdateTenantQuota(c *gin.Context) {
+	var quota domain.TenantQuota
+	if err := c.ShouldBindJSON(&quota); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	if err := tc.QuotaUsecase.UpdateQuota(c, c.Param("id"), &quota); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(quota))
+}
+
+// GetTenantUsage 获取租户存储用量
+// @Summary      Get tenant usage
+// @Description  Get storage usage of a tenant broken down by project and package, together with its quota
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Tenant ID"
+// @Success      200  {object} domain.Response{data=domain.TenantUsage}  "Successfully retrieved tenant usage"
+// @Failure      404  {object} domain.Response  "Tenant not found"
+// @Router       /tenants/{id}/usage [get]
+func (tc *TenantController) GetTenantUsage(c *gin.Context) {
+	usage, err := tc.QuotaUsecase.GetUsage(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("Tenant not found"))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(usage))
+}
+
+// GetTenantsUsage 获取所有租户的存储用量
+// @Summary      List tenant usage
+// @Description  List storage usage of all tenants, ordered by quota utilisation. Tenants at or above QUOTA_WARNING_PERCENT are flagged with near_limit.
+// @Tags         Tenants
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object} domain.Response{data=[]domain.TenantUsage}  "Successfully retrieved tenant usage"
+// @Failure      500  {object} domain.Response  "Internal server error"
+// @Router       /tenants/usage [get]
+func (tc *TenantController) GetTenantsUsage(c *gin.Context) {
+	usages, err := tc.QuotaUsecase.ListUsage(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(usages))
+}
+
 // GetTenantUsers 获取租户用户
 // @Summary      Get tenant users
 // @Description  Get all users belonging to a specific tenant
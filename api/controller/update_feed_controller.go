@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+// squirrelReleasesFile Squirrel.Windows 请求的版本列表文件名
+const squirrelReleasesFile = "RELEASES"
+
+// UpdateFeedController 为 desktop 类型包提供 electron-updater 与 Squirrel.Windows 的更新源
+type UpdateFeedController struct {
+	UpdateFeedUsecase   domain.UpdateFeedUsecase
+	ClientAccessUsecase domain.ClientAccessUsecase
+	FileUsecase         domain.FileUsecase
+	Env                 *bootstrap.Env
+}
+
+// ElectronFeed godoc
+// @Summary      electron-updater feed
+// @Description  Generate the electron-updater update info (latest.yml, latest-mac.yml, latest-linux.yml, latest-linux-arm64.yml, or {channel}*.yml) from the active upgrade target of the desktop package bound to the access token.
+// @Description  Configure electron-updater with the generic provider url /client-access/electron and send the token with autoUpdater.requestHeaders. The "latest" channel maps to the channel bound to the token, or stable.
+// @Tags         Client Access
+// @Produce      plain
+// @Param        x-access-token  header  string  true  "Client access token"
+// @Param        name            path    string  true  "Feed file name, e.g. latest.yml"
+// @Success      200  {string}  string  "Update info (YAML)"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "No active upgrade target or no file for the platform"
+// @Router       /client-access/electron/{name} [get]
+func (fc *UpdateFeedController) ElectronFeed(c *gin.Context) {
+	clientAccess, ok := authorizeAccessToken(c, fc.ClientAccessUsecase, c.GetHeader(constants.AccessToken))
+	if !ok {
+		return
+	}
+
+	data, err := fc.UpdateFeedUsecase.ElectronFeed(c, clientAccess, c.Param("name"))
+	if err != nil {
+		fc.writeError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/yaml; charset=utf-8", data)
+}
+
+// ElectronFile godoc
+// @Summary      Download electron-updater file
+// @Description  Download a file referenced by an electron-updater feed
+// @Tags         Client Access
+// @Produce      application/octet-stream
+// @Param        x-access-token  header  string  true  "Client access token"
+// @Param        name            path    string  true  "Release ID"
+// @Param        file            path    string  true  "File name"
+// @Success      200  {file}    file             "File download successful"
+// @Success      206  {file}    file             "Partial content"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "File not found"
+// @Router       /client-access/electron/{name}/{file} [get]
+func (fc *UpdateFeedController) ElectronFile(c *gin.Context) {
+	clientAccess, ok := authorizeAccessToken(c, fc.ClientAccessUsecase, c.GetHeader(constants.AccessToken))
+	if !ok {
+		return
+	}
+
+	file, err := fc.UpdateFeedUsecase.GetElectronFile(c, clientAccess, c.Param("name"), c.Param("file"))
+	if err != nil {
+		fc.writeError(c, err)
+		return
+	}
+	fc.serveFile(c, file)
+}
+
+// Squirrel godoc
+// @Summary      Squirrel.Windows feed
+// @Description  Serve the Squirrel.Windows RELEASES file generated from the .nupkg files of the active upgrade target, and the packages it references.
+// @Description  Squirrel cannot send request headers, so the access token is part of the update URL: /client-access/squirrel/{token}. The channel bound to the token is used, or stable.
+// @Tags         Client Access
+// @Produce      plain
+// @Param        token  path  string  true  "Client access token"
+// @Param        file   path  string  true  "RELEASES or a .nupkg file name"
+// @Success      200  {file}    file             "RELEASES file or package"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token disabled or expired"
+// @Failure      404  {object}  domain.Response  "No active upgrade target or file not found"
+// @Router       /client-access/squirrel/{token}/{file} [get]
+func (fc *UpdateFeedController) Squirrel(c *gin.Context) {
+	clientAccess, ok := authorizeAccessToken(c, fc.ClientAccessUsecase, c.Param("token"))
+	if !ok {
+		return
+	}
+
+	if c.Param("file") == squirrelReleasesFile {
+		data, err := fc.UpdateFeedUsecase.SquirrelReleases(c, clientAccess)
+		if err != nil {
+			fc.writeError(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", data)
+		return
+	}
+
+	file, err := fc.UpdateFeedUsecase.GetSquirrelFile(c, clientAccess, c.Param("file"))
+	if err != nil {
+		fc.writeError(c, err)
+		return
+	}
+	fc.serveFile(c, file)
+}
+
+func (fc *UpdateFeedController) serveFile(c *gin.Context, file *domain.ReleaseAsset) {
+	serveDownload(c, fc.FileUsecase, &fileDownload{
+		Bucket:         fc.Env.S3Bucket,
+		ObjectName:     file.FilePath,
+		FileName:       file.FileName,
+		FileSize:       file.FileSize,
+		Hash:           firstNonEmpty(file.SHA256, file.FileHash),
+		ModTime:        file.CreatedAt,
+		RedirectExpiry: downloadRedirectExpiry(fc.Env),
+	})
+}
+
+func (fc *UpdateFeedController) writeError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrUpdateFeedNotFound) {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+}
@@ -57,6 +57,7 @@ func (uc *UpgradeController) CreateUpgradeTarget(c *gin.Context) {
 // @Security     BearerAuth
 // @Param        project_id  query  string  false  "Project ID"
 // @Param        package_id  query  string  false  "Package ID"
+// @Param        channel     query  string  false  "Release channel"
 // @Param        is_active   query  bool    false  "Is active"
 // @Param        page        query  int     false  "Page number (default: 1)"
 // @Param        page_size    query  int     false  "Page size (default: 20)"
@@ -90,6 +91,9 @@ func (uc *UpgradeController) GetUpgradeTargets(c *gin.Context) {
 	if packageID := c.Query("package_id"); packageID != "" {
 		filters["package_id"] = packageID
 	}
+	if channel := c.Query("channel"); channel != "" {
+		filters["channel"] = channel
+	}
 	if isActiveStr := c.Query("is_active"); isActiveStr != "" {
 		if isActive, err := strconv.ParseBool(isActiveStr); err == nil {
 			filters["is_active"] = isActive
@@ -203,3 +207,117 @@ func (uc *UpgradeController) GetProjectUpgradeTargets(c *gin.Context) {
 
 	c.JSON(http.StatusOK, domain.RespSuccess(targets))
 }
+
+// PauseRollout 暂停灰度发布
+// @Summary      Pause rollout
+// @Description  Pause the staged rollout of an upgrade target, clients stop receiving the update
+// @Tags         Upgrades
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Upgrade target ID"
+// @Success      200  {object} domain.Response  "Successfully paused rollout"
+// @Failure      400  {object} domain.Response  "Bad request - rollout cannot be paused"
+// @Router       /upgrades/{id}/rollout/pause [post]
+func (uc *UpgradeController) PauseRollout(c *gin.Context) {
+	target, err := uc.UpgradeUsecase.PauseRollout(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(target))
+}
+
+// ResumeRollout 恢复灰度发布
+// @Summary      Resume rollout
+// @Description  Resume a paused staged rollout of an upgrade target
+// @Tags         Upgrades
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Upgrade target ID"
+// @Success      200  {object} domain.Response  "Successfully resumed rollout"
+// @Failure      400  {object} domain.Response  "Bad request - rollout is not paused"
+// @Router       /upgrades/{id}/rollout/resume [post]
+func (uc *UpgradeController) ResumeRollout(c *gin.Context) {
+	target, err := uc.UpgradeUsecase.ResumeRollout(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(target))
+}
+
+// CompleteRollout 全量发布
+// @Summary      Complete rollout
+// @Description  Roll out an upgrade target to 100% of clients
+// @Tags         Upgrades
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Upgrade target ID"
+// @Success      200  {object} domain.Response  "Successfully completed rollout"
+// @Failure      400  {object} domain.Response  "Bad request - upgrade target not found"
+// @Router       /upgrades/{id}/rollout/complete [post]
+func (uc *UpgradeController) CompleteRollout(c *gin.Context) {
+	target, err := uc.UpgradeUsecase.CompleteRollout(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(target))
+}
+
+// PromoteUpgradeTarget 渠道晋级
+// @Summary      Promote upgrade target
+// @Description  Promote the release of an upgrade target to another channel (e.g. beta -> stable), moving that channel's active target
+// @Tags         Upgrades
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path     string                              true  "Upgrade target ID"
+// @Param        data  body     domain.PromoteUpgradeTargetRequest  true  "Destination channel"
+// @Success      200   {object} domain.Response  "Successfully promoted upgrade target"
+// @Failure      400   {object} domain.Response  "Bad request - invalid parameters"
+// @Router       /upgrades/{id}/promote [post]
+func (uc *UpgradeController) PromoteUpgradeTarget(c *gin.Context) {
+	userID := c.GetString(constants.UserID)
+
+	var request domain.PromoteUpgradeTargetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	target, err := uc.UpgradeUsecase.PromoteUpgradeTarget(c, c.Param("id"), &request, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(target))
+}
+
+// GetUpgradeTargetStats 获取升级目标的上报统计
+// @Summary      Get upgrade target stats
+// @Description  Get download/install/start success and failure counts reported by clients for an upgrade target
+// @Tags         Upgrades
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path     string  true  "Upgrade target ID"
+// @Success      200  {object} domain.Response{data=domain.UpgradeTargetStats}  "Successfully retrieved stats"
+// @Failure      404  {object} domain.Response  "Upgrade target not found"
+// @Router       /upgrades/{id}/stats [get]
+func (uc *UpgradeController) GetUpgradeTargetStats(c *gin.Context) {
+	stats, err := uc.UpgradeUsecase.GetUpgradeTargetStats(c, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.RespSuccess(stats))
+}
@@ -0,0 +1,408 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/pkg"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tus 协议相关的请求/响应头
+const (
+	headerTusResumable   = "Tus-Resumable"
+	headerTusVersion     = "Tus-Version"
+	headerTusExtension   = "Tus-Extension"
+	headerTusMaxSize     = "Tus-Max-Size"
+	headerUploadOffset   = "Upload-Offset"
+	headerUploadLength   = "Upload-Length"
+	headerUploadMetadata = "Upload-Metadata"
+	headerUploadExpires  = "Upload-Expires"
+	tusChunkContentType  = "application/offset+octet-stream"
+)
+
+// UploadController 可续传的分片上传接口，兼容 tus 1.0.0（creation/termination/expiration 扩展）
+// 管理端与客户端接入（GoReleaser）共用同一套协议实现，差别在于鉴权与完成上传后的处理
+type UploadController struct {
+	UploadSessionUsecase domain.UploadSessionUsecase
+	QuotaUsecase         domain.QuotaUsecase // 为空表示不检查租户配额
+	Env                  *bootstrap.Env
+	// BasePath 会话地址前缀，用于生成 Location
+	BasePath string
+	// Authorize 校验请求并返回会话归属（TenantID/CreatedBy/PackageID/ClientAccessID），失败时写入错误响应
+	Authorize func(c *gin.Context) (*domain.UploadSession, bool)
+	// Finalize 使用接收完整的文件创建版本并写入响应，成功时返回版本 ID 和 true
+	Finalize func(c *gin.Context, session *domain.UploadSession, file io.Reader, param func(string) string) (string, bool)
+}
+
+// Options tus 能力发现
+// @Summary      Upload capabilities
+// @Description  tus OPTIONS request: returns supported protocol version, extensions and maximum upload size
+// @Tags         Uploads
+// @Success      204
+// @Router       /uploads [options]
+func (uc *UploadController) Options(c *gin.Context) {
+	c.Header(headerTusResumable, domain.TusVersion)
+	c.Header(headerTusVersion, domain.TusVersion)
+	c.Header(headerTusExtension, "creation,termination,expiration")
+	if uc.Env.UploadMaxSizeMB > 0 {
+		c.Header(headerTusMaxSize, strconv.FormatInt(uc.Env.UploadMaxSizeMB<<20, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateUpload 创建上传会话
+// @Summary      Create upload session
+// @Description  Create a resumable upload session. tus clients send Upload-Length and Upload-Metadata (filename plus release fields such as package_id/version_code); other clients may post JSON instead.
+// @Tags         Uploads
+// @Accept       json
+// @Produce      json
+// @Param        Upload-Length    header  int     false  "Total file size (tus)"
+// @Param        Upload-Metadata  header  string  false  "Comma separated key/base64 value pairs (tus)"
+// @Param        request          body    domain.CreateUploadSessionRequest  false  "Session request (non-tus clients)"
+// @Success      201  {object}  domain.Response{data=domain.UploadSession}  "Upload session created, Location header points to the session"
+// @Failure      400  {object}  domain.Response  "Invalid request"
+// @Failure      403  {object}  domain.Response  "Tenant storage quota exceeded"
+// @Failure      413  {object}  domain.Response  "File too large"
+// @Router       /uploads [post]
+func (uc *UploadController) CreateUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	session, ok := uc.Authorize(c)
+	if !ok {
+		return
+	}
+
+	if lengthHeader := c.GetHeader(headerUploadLength); lengthHeader != "" {
+		size, err := strconv.ParseInt(lengthHeader, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domain.RespError("Invalid Upload-Length"))
+			return
+		}
+		metadata, err := parseTusMetadata(c.GetHeader(headerUploadMetadata))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domain.RespError("Invalid Upload-Metadata: "+err.Error()))
+			return
+		}
+		session.Size = size
+		session.Metadata = metadata
+		session.FileName = firstNonEmpty(metadata["filename"], metadata["name"])
+		session.ContentType = firstNonEmpty(metadata["filetype"], metadata["type"])
+	} else {
+		var request domain.CreateUploadSessionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, domain.RespError("Upload-Length header or JSON body is required: "+err.Error()))
+			return
+		}
+		session.Size = request.Size
+		session.FileName = request.FileName
+		session.ContentType = request.ContentType
+		session.Metadata = request.Metadata
+	}
+
+	// 创建会话时就按声明的大小检查配额，避免传完才发现超出；版本数上限要到完成时才能确定是否新建版本，完成时会再检查一次
+	if packageID := firstNonEmpty(session.PackageID, session.Metadata["package_id"]); packageID != "" {
+		if !checkUploadQuota(c, uc.QuotaUsecase, packageID, session.Size, false) {
+			return
+		}
+	}
+
+	if err := uc.UploadSessionUsecase.CreateSession(c, session); err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	c.Header(headerTusResumable, domain.TusVersion)
+	c.Header("Location", uc.BasePath+"/"+session.ID)
+	c.Header(headerUploadOffset, "0")
+	c.Header(headerUploadExpires, session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.JSON(http.StatusCreated, domain.RespSuccess(session))
+}
+
+// HeadUpload 查询上传偏移
+// @Summary      Get upload offset
+// @Description  tus HEAD request: returns Upload-Offset and Upload-Length headers so the client can resume
+// @Tags         Uploads
+// @Param        id   path  string  true  "Upload session ID"
+// @Success      200
+// @Failure      404  "Upload session not found"
+// @Failure      410  "Upload session expired"
+// @Router       /uploads/{id} [head]
+func (uc *UploadController) HeadUpload(c *gin.Context) {
+	session, ok := uc.loadSession(c)
+	if !ok {
+		return
+	}
+	c.Header(headerTusResumable, domain.TusVersion)
+	c.Header(headerUploadOffset, strconv.FormatInt(session.Offset, 10))
+	c.Header(headerUploadLength, strconv.FormatInt(session.Size, 10))
+	c.Header(headerUploadExpires, session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// GetUpload 查询上传进度
+// @Summary      Get upload progress
+// @Description  Get the upload session including received bytes and status
+// @Tags         Uploads
+// @Produce      json
+// @Param        id   path  string  true  "Upload session ID"
+// @Success      200  {object}  domain.Response{data=domain.UploadSession}  "Upload session"
+// @Failure      404  {object}  domain.Response  "Upload session not found"
+// @Failure      410  {object}  domain.Response  "Upload session expired"
+// @Router       /uploads/{id} [get]
+func (uc *UploadController) GetUpload(c *gin.Context) {
+	session, ok := uc.loadSession(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, domain.RespSuccess(session))
+}
+
+// PatchUpload 上传分片
+// @Summary      Upload chunk
+// @Description  Append a chunk at Upload-Offset (tus PATCH with Content-Type application/offset+octet-stream). PUT with an offset query parameter is accepted for non-tus clients.
+// @Tags         Uploads
+// @Accept       application/offset+octet-stream
+// @Param        id             path    string  true   "Upload session ID"
+// @Param        Upload-Offset  header  int     false  "Offset of this chunk"
+// @Param        offset         query   int     false  "Offset of this chunk (when the header is absent)"
+// @Success      204  "Chunk stored, Upload-Offset header contains the new offset"
+// @Failure      409  "Offset does not match the received bytes"
+// @Failure      410  "Upload session expired"
+// @Failure      415  "Invalid content type"
+// @Router       /uploads/{id} [patch]
+func (uc *UploadController) PatchUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+	if c.Request.Method == http.MethodPatch && c.ContentType() != tusChunkContentType {
+		c.JSON(http.StatusUnsupportedMediaType, domain.RespError("Content-Type must be "+tusChunkContentType))
+		return
+	}
+	session, ok := uc.loadSession(c)
+	if !ok {
+		return
+	}
+
+	offset, err := strconv.ParseInt(firstNonEmpty(c.GetHeader(headerUploadOffset), c.Query("offset")), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.RespError("Invalid Upload-Offset"))
+		return
+	}
+
+	session, err = uc.UploadSessionUsecase.WriteChunk(c, session.ID, offset, c.Request.Body)
+	if session != nil {
+		c.Header(headerUploadOffset, strconv.FormatInt(session.Offset, 10))
+	}
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	c.Header(headerTusResumable, domain.TusVersion)
+	c.Header(headerUploadExpires, session.ExpiresAt.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteUpload 终止上传
+// @Summary      Terminate upload
+// @Description  tus termination: delete the session and its staged data
+// @Tags         Uploads
+// @Param        id   path  string  true  "Upload session ID"
+// @Success      204
+// @Failure      404  {object}  domain.Response  "Upload session not found"
+// @Router       /uploads/{id} [delete]
+func (uc *UploadController) DeleteUpload(c *gin.Context) {
+	session, ok := uc.loadSession(c)
+	if !ok {
+		return
+	}
+	if err := uc.UploadSessionUsecase.DeleteSession(c, session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+	c.Header(headerTusResumable, domain.TusVersion)
+	c.Status(http.StatusNoContent)
+}
+
+// FinalizeUpload 完成上传并创建版本
+// @Summary      Finalize upload
+// @Description  Store the fully received file and create the release. Release fields (JSON body or form) override the metadata given when the session was created.
+// @Description  Only one request can finalize a session; retrying after success returns the finalized session (with release_id) instead of creating another release.
+// @Tags         Uploads
+// @Accept       json
+// @Produce      json
+// @Param        id   path  string  true  "Upload session ID"
+// @Success      200  {object}  domain.Response{data=domain.UploadSession}  "Session was already finalized"
+// @Success      201  {object}  domain.Response  "Release created"
+// @Failure      400  {object}  domain.Response  "Upload incomplete or invalid release fields"
+// @Failure      404  {object}  domain.Response  "Upload session not found"
+// @Failure      409  {object}  domain.Response  "Another request is finalizing the session"
+// @Router       /uploads/{id}/finalize [post]
+func (uc *UploadController) FinalizeUpload(c *gin.Context) {
+	session, ok := uc.loadOwnedSession(c)
+	if !ok {
+		return
+	}
+
+	session, err := uc.UploadSessionUsecase.BeginFinalize(c, session.ID)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	if session.Status == domain.UploadSessionStatusFinalized {
+		c.JSON(http.StatusOK, domain.RespSuccess(session))
+		return
+	}
+
+	file, err := uc.UploadSessionUsecase.OpenUpload(c, session.ID)
+	if err != nil {
+		uc.abortFinalize(c, session.ID)
+		writeUploadError(c, err)
+		return
+	}
+	defer file.Close()
+
+	releaseID, ok := uc.Finalize(c, session, file, finalizeParams(c, session))
+	if !ok {
+		uc.abortFinalize(c, session.ID)
+		return
+	}
+	// 失败时会话停留在 finalizing，客户端重试得到 409 而不会重复创建版本
+	if err := uc.UploadSessionUsecase.MarkFinalized(c, session.ID, releaseID); err != nil {
+		pkg.Log.Errorf("标记上传会话完成失败 %s: %v", session.ID, err)
+	}
+}
+
+func (uc *UploadController) abortFinalize(c *gin.Context, id string) {
+	if err := uc.UploadSessionUsecase.AbortFinalize(c, id); err != nil {
+		pkg.Log.Errorf("恢复上传会话状态失败 %s: %v", id, err)
+	}
+}
+
+// loadSession 读取未完成的会话并校验归属，失败时写入错误响应
+func (uc *UploadController) loadSession(c *gin.Context) (*domain.UploadSession, bool) {
+	session, ok := uc.loadOwnedSession(c)
+	if ok && session.Status == domain.UploadSessionStatusFinalized {
+		c.JSON(http.StatusNotFound, domain.RespError("Upload session not found"))
+		return nil, false
+	}
+	return session, ok
+}
+
+// loadOwnedSession 读取会话（含已完成的）并校验归属，失败时写入错误响应
+func (uc *UploadController) loadOwnedSession(c *gin.Context) (*domain.UploadSession, bool) {
+	owner, ok := uc.Authorize(c)
+	if !ok {
+		return nil, false
+	}
+
+	session, err := uc.UploadSessionUsecase.GetSession(c, c.Param("id"))
+	if err != nil {
+		writeUploadError(c, err)
+		return nil, false
+	}
+	if session.TenantID != owner.TenantID || session.ClientAccessID != owner.ClientAccessID {
+		c.JSON(http.StatusNotFound, domain.RespError("Upload session not found"))
+		return nil, false
+	}
+	return session, true
+}
+
+// checkTusVersion 请求声明了 tus 版本时必须是支持的版本
+func checkTusVersion(c *gin.Context) bool {
+	if v := c.GetHeader(headerTusResumable); v != "" && v != domain.TusVersion {
+		c.Header(headerTusVersion, domain.TusVersion)
+		c.JSON(http.StatusPreconditionFailed, domain.RespError("Unsupported tus version: "+v))
+		return false
+	}
+	return true
+}
+
+// writeUploadError 按 tus 约定的状态码返回错误
+func writeUploadError(c *gin.Context, err error) {
+	c.Header(headerTusResumable, domain.TusVersion)
+	switch {
+	case errors.Is(err, domain.ErrUploadOffsetMismatch), errors.Is(err, domain.ErrUploadFinalizing):
+		c.JSON(http.StatusConflict, domain.RespError(err.Error()))
+	case errors.Is(err, domain.ErrUploadSessionExpired):
+		c.JSON(http.StatusGone, domain.RespError(err.Error()))
+	case errors.Is(err, domain.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, domain.RespError(err.Error()))
+	case errors.Is(err, domain.ErrUploadSessionClosed), errors.Is(err, domain.ErrUploadIncomplete):
+		c.JSON(http.StatusBadRequest, domain.RespError(err.Error()))
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, domain.RespError("Upload session not found"))
+	default:
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+	}
+}
+
+// parseTusMetadata 解析 Upload-Metadata：逗号分隔的 "key base64(value)"，value 可省略
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		switch len(parts) {
+		case 1:
+			metadata[parts[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, err
+			}
+			metadata[parts[0]] = string(value)
+		default:
+			return nil, errors.New("malformed pair: " + pair)
+		}
+	}
+	return metadata, nil
+}
+
+// finalizeParams 完成上传时的参数读取顺序：JSON 请求体 > 表单/查询参数 > 会话元数据
+func finalizeParams(c *gin.Context, session *domain.UploadSession) func(string) string {
+	body := make(map[string]interface{})
+	if c.ContentType() == gin.MIMEJSON {
+		_ = c.ShouldBindJSON(&body)
+	}
+	return func(key string) string {
+		if v, ok := body[key]; ok && v != nil {
+			switch value := v.(type) {
+			case string:
+				return value
+			case bool:
+				return strconv.FormatBool(value)
+			case float64:
+				return strconv.FormatFloat(value, 'f', -1, 64)
+			}
+		}
+		if v := c.PostForm(key); v != "" {
+			return v
+		}
+		if v := c.Query(key); v != "" {
+			return v
+		}
+		return session.Metadata[key]
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// YumRepositoryController 以 YUM/DNF 仓库的形式提供 linux 类型包中的 .rpm 文件
+type YumRepositoryController struct {
+	YumRepositoryUsecase domain.YumRepositoryUsecase
+	ClientAccessUsecase  domain.ClientAccessUsecase
+	FileUsecase          domain.FileUsecase
+	Env                  *bootstrap.Env
+}
+
+// PublicKey godoc
+// @Summary      Download repository signing key
+// @Description  Download the OpenPGP public key used to sign APT/YUM repository metadata (ASCII armored)
+// @Tags         Package Repository
+// @Produce      plain
+// @Success      200  {string}  string  "OpenPGP public key"
+// @Failure      404  {object}  domain.Response  "Repository signing is not configured"
+// @Router       /yum/gpg.key [get]
+func (yc *YumRepositoryController) PublicKey(c *gin.Context) {
+	key, err := yc.YumRepositoryUsecase.PublicKey()
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError(err.Error()))
+		return
+	}
+	c.Data(http.StatusOK, "application/pgp-keys", key)
+}
+
+// Serve godoc
+// @Summary      YUM/DNF repository
+// @Description  Serve a project (scope=projects) or package (scope=packages) as a YUM/DNF repository generated from published releases of linux packages.
+// @Description  Use {stable|testing} as baseurl. Metadata: {channel}/repodata/repomd.xml, repomd.xml.asc and the files it references; package files: {channel}/packages/{id}/{file}.
+// @Description  Authenticate with a client access token as the HTTP basic auth password.
+// @Tags         Package Repository
+// @Produce      xml
+// @Param        scope  path  string  true  "projects or packages"
+// @Param        id     path  string  true  "Project ID or package ID"
+// @Param        path   path  string  true  "File path within the repository"
+// @Success      200  {file}    file             "Repository file"
+// @Failure      401  {object}  domain.Response  "Invalid access token"
+// @Failure      403  {object}  domain.Response  "Access token does not belong to this repository"
+// @Failure      404  {object}  domain.Response  "File not found"
+// @Router       /yum/{scope}/{id}/{path} [get]
+func (yc *YumRepositoryController) Serve(c *gin.Context) {
+	scope, ok := repositoryScope(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, domain.RespError("仓库不存在"))
+		return
+	}
+	if !authorizeRepositoryClient(c, yc.ClientAccessUsecase, scope) {
+		return
+	}
+
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+	parts := strings.Split(filePath, "/")
+	if len(parts) == 4 && parts[1] == "packages" {
+		yc.servePackageFile(c, scope, parts[2], parts[3])
+		return
+	}
+
+	data, err := yc.YumRepositoryUsecase.GetMetadataFile(c, scope, filePath)
+	if err != nil {
+		if errors.Is(err, domain.ErrRepositoryFileNotFound) {
+			c.JSON(http.StatusNotFound, domain.RespError("文件不存在"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, domain.RespError(err.Error()))
+		return
+	}
+
+	contentType := "application/xml"
+	switch {
+	case strings.HasSuffix(filePath, ".gz"):
+		contentType = "application/gzip"
+	case path.Base(filePath) == "repomd.xml.asc":
+		contentType = "application/pgp-signature"
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// servePackageFile 下载 {channel}/packages/{id}/{file} 对应的软件包文件，文件名部分只用于显示
+func (yc *YumRepositoryController) servePackageFile(c *gin.Context, scope domain.RepositoryScope, id, fileName string) {
+	lp, err := yc.YumRepositoryUsecase.GetPackage(c, scope, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, domain.RespError("文件不存在"))
+		return
+	}
+
+	serveDownload(c, yc.FileUsecase, &fileDownload{
+		Bucket:         yc.Env.S3Bucket,
+		ObjectName:     lp.FilePath,
+		FileName:       fileName,
+		FileSize:       lp.FileSize,
+		Hash:           lp.SHA256,
+		ModTime:        lp.CreatedAt,
+		RedirectExpiry: downloadRedirectExpiry(yc.Env),
+	})
+}
@@ -0,0 +1,81 @@
+package route
+
+import (
+	"sync"
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/internal/pgpsign"
+	"pkms/pkg"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	repositorySignerOnce sync.Once
+	repositorySignerKey  *pgpsign.Signer
+)
+
+// repositorySigner 加载（首次启动时生成）APT/YUM 仓库共用的 OpenPGP 签名密钥，未配置或加载失败时返回 nil
+func repositorySigner(env *bootstrap.Env) *pgpsign.Signer {
+	repositorySignerOnce.Do(func() {
+		if env.RepoSigningKeyFile == "" {
+			return
+		}
+		signer, err := pgpsign.LoadOrCreate(env.RepoSigningKeyFile, env.RepoSigningKeyPassphrase, "pkms repository signing key")
+		if err != nil {
+			pkg.Log.Errorf("加载仓库签名密钥失败，仓库索引将不签名: %v", err)
+			return
+		}
+		pkg.Log.Printf("仓库签名密钥: %s", signer.Fingerprint())
+		repositorySignerKey = signer
+	})
+	return repositorySignerKey
+}
+
+// newLinuxPackageUsecase 在版本文件写入时为已启用的 APT/YUM 仓库提取软件包元数据，都未启用时返回 nil
+func newLinuxPackageUsecase(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository) domain.LinuxPackageUsecase {
+	var formats []string
+	if env.AptRepositoryEnabled {
+		formats = append(formats, domain.LinuxPackageFormatDeb)
+	}
+	if env.YumRepositoryEnabled {
+		formats = append(formats, domain.LinuxPackageFormatRPM)
+	}
+	if len(formats) == 0 {
+		return nil
+	}
+	return usecase.NewLinuxPackageUsecase(repository.NewPackageRepository(db), repository.NewReleaseRepository(db),
+		repository.NewLinuxPackageRepository(db), fileStorage, env.S3Bucket, formats, timeout)
+}
+
+// NewAptRouter APT 仓库路由（无需JWT认证，使用客户端接入凭证的 HTTP Basic 认证）
+func NewAptRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
+	packageRepo := repository.NewPackageRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	clientAccessRepo := repository.NewClientAccessRepository(db)
+
+	ac := &controller.AptRepositoryController{
+		AptRepositoryUsecase: usecase.NewAptRepositoryUsecase(
+			packageRepo,
+			repository.NewReleaseRepository(db),
+			repository.NewLinuxPackageRepository(db),
+			fileStorage,
+			repositorySigner(env),
+			env.S3Bucket,
+			timeout,
+		),
+		ClientAccessUsecase: usecase.NewClientAccessUsecase(clientAccessRepo, projectRepo, packageRepo, timeout),
+		FileUsecase:         usecase.NewFileUsecase(fileStorage, timeout),
+		Env:                 env,
+	}
+
+	group.GET("/gpg.key", ac.PublicKey)       // GET /apt/gpg.key
+	group.GET("/:scope/:id/*path", ac.Serve)  // GET /apt/{projects|packages}/{id}/dists/... 或 pool/...
+	group.HEAD("/:scope/:id/*path", ac.Serve) // HEAD /apt/{projects|packages}/{id}/...
+}
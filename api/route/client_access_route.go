@@ -22,7 +22,20 @@ func NewPublicClientAccessRouter(env *bootstrap.Env, timeout time.Duration, db *
 	releaseRepo := repository.NewReleaseRepository(db)
 
 	clientAccessUsecase := usecase.NewClientAccessUsecase(clientAccessRepo, projectRepo, packageRepo, timeout)
-	upgradeUsecase := usecase.NewUpgradeUsecase(upgradeRepo, projectRepo, packageRepo, releaseRepo, timeout, usecase.WithClientAccessRepository(clientAccessRepo))
+	patchUsecase := usecase.NewPatchUsecase(releaseRepo, fileStorage, env, timeout)
+	upgradeOptions := []usecase.UpgradeUsecaseOption{
+		usecase.WithClientAccessRepository(clientAccessRepo),
+		usecase.WithPatchUsecase(patchUsecase),
+		usecase.WithUpgradeReportRepository(repository.NewUpgradeReportRepository(db)),
+		usecase.WithDeviceRepository(repository.NewDeviceRepository(db)),
+		usecase.WithAutoPause(env.RolloutFailureThreshold, env.RolloutFailureMinReports),
+	}
+	var signingUsecase domain.SigningUsecase
+	if env.SigningEnabled {
+		signingUsecase = usecase.NewSigningUsecase(repository.NewSigningKeyRepository(db), env.SigningKeySecret, timeout)
+		upgradeOptions = append(upgradeOptions, usecase.WithSigningUsecase(signingUsecase))
+	}
+	upgradeUsecase := usecase.NewUpgradeUsecase(upgradeRepo, projectRepo, packageRepo, releaseRepo, timeout, upgradeOptions...)
 	fileUsecase := usecase.NewFileUsecase(fileStorage, timeout)
 	releaseUsecase := usecase.NewReleaseUsecase(releaseRepo, packageRepo, fileStorage, env, timeout)
 
@@ -31,11 +44,38 @@ func NewPublicClientAccessRouter(env *bootstrap.Env, timeout time.Duration, db *
 		UpgradeUsecase:      upgradeUsecase,
 		FileUsecase:         fileUsecase,
 		ReleaseUsecase:      releaseUsecase,
+		PatchUsecase:        patchUsecase,
+		SigningUsecase:      signingUsecase,
+		QuotaUsecase:        usecase.NewQuotaUsecase(repository.NewQuotaRepository(db), env.QuotaWarningPercent, timeout),
+		LinuxPackageUsecase: newLinuxPackageUsecase(env, timeout, db, fileStorage),
 		Env:                 env,
 	}
 
 	// Public client operations (无需JWT认证，使用access_token验证)
-	group.POST("/check", cac.CheckUpdate)    // POST /client-access/check
-	group.GET("/download/:id", cac.Download) // GET /client-access/download/:id?access_token=xxx
-	group.POST("/release", cac.Release)      // POST /client-access/upload (GoReleaser upload)
+	group.POST("/check", cac.CheckUpdate)              // POST /client-access/check
+	group.GET("/download/:id", cac.Download)           // GET /client-access/download/:id?access_token=xxx
+	group.HEAD("/download/:id", cac.Download)          // HEAD /client-access/download/:id
+	group.GET("/patch/:id", cac.DownloadPatch)         // GET /client-access/patch/:id
+	group.HEAD("/patch/:id", cac.DownloadPatch)        // HEAD /client-access/patch/:id
+	group.GET("/checksums/:id", cac.DownloadChecksums) // GET /client-access/checksums/:id?algorithm=sha256
+	group.GET("/signature/:id", cac.DownloadSignature) // GET /client-access/signature/:id?os=&arch=&kind=
+	group.GET("/signing-keys", cac.DownloadPublicKeys) // GET /client-access/signing-keys
+	group.POST("/report", cac.ReportUpgrade)           // POST /client-access/report
+	group.POST("/release", cac.Release)                // POST /client-access/upload (GoReleaser upload)
+
+	// 桌面应用自动更新源（desktop 类型包）：electron-updater 使用请求头中的 access token，Squirrel.Windows 无法设置请求头，token 位于路径中
+	feeds := &controller.UpdateFeedController{
+		UpdateFeedUsecase:   usecase.NewUpdateFeedUsecase(upgradeRepo, packageRepo, releaseRepo, fileStorage, env.S3Bucket, timeout),
+		ClientAccessUsecase: clientAccessUsecase,
+		FileUsecase:         fileUsecase,
+		Env:                 env,
+	}
+	group.GET("/electron/:name", feeds.ElectronFeed)        // GET /client-access/electron/latest.yml
+	group.GET("/electron/:name/:file", feeds.ElectronFile)  // GET /client-access/electron/{release_id}/{file}
+	group.HEAD("/electron/:name/:file", feeds.ElectronFile) // HEAD /client-access/electron/{release_id}/{file}
+	group.GET("/squirrel/:token/:file", feeds.Squirrel)     // GET /client-access/squirrel/{token}/RELEASES 或 .nupkg
+	group.HEAD("/squirrel/:token/:file", feeds.Squirrel)    // HEAD /client-access/squirrel/{token}/{file}
+
+	// Resumable chunked uploads (tus 1.0.0)，完成后的参数与 /release 一致
+	registerUploadRoutes(env, timeout, db, group.Group("/uploads"), cac.QuotaUsecase, cac.AuthorizeUpload, cac.FinalizeUpload) // /client-access/uploads
 }
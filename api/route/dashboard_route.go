@@ -18,10 +18,12 @@ func NewDashboardRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Clien
 
 	dc := &controller.DashboardController{
 		DashboardUsecase: usecase.NewDashboardUsecase(dashboardRepo, timeout),
+		QuotaUsecase:     usecase.NewQuotaUsecase(repository.NewQuotaRepository(db), env.QuotaWarningPercent, timeout),
 		Env:              env,
 	}
 
 	// Dashboard operations
 	group.GET("/stats", dc.GetStats)                 // GET /api/v1/dashboard/stats
 	group.GET("/activities", dc.GetRecentActivities) // GET /api/v1/dashboard/activities
+	group.GET("/usage", dc.GetUsage)                 // GET /api/v1/dashboard/usage
 }
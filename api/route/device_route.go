@@ -0,0 +1,26 @@
+package route
+
+import (
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/ent"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewDeviceRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, group *gin.RouterGroup) {
+	dr := repository.NewDeviceRepository(db)
+	dc := &controller.DeviceController{
+		DeviceUsecase: usecase.NewDeviceUsecase(dr, timeout),
+		Env:           env,
+	}
+
+	group.GET("/", dc.GetDevices)                     // GET /api/v1/devices
+	group.GET("/versions", dc.GetVersionDistribution) // GET /api/v1/devices/versions?package_id=xxx
+	group.GET("/:id", dc.GetDevice)                   // GET /api/v1/devices/:id
+	group.DELETE("/:id", dc.DeleteDevice)             // DELETE /api/v1/devices/:id
+}
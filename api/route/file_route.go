@@ -0,0 +1,23 @@
+package route
+
+import (
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewFileRouter 本地磁盘存储的签名直接下载路由（无需认证，使用签名校验）
+func NewFileRouter(env *bootstrap.Env, timeout time.Duration, fileStorage domain.FileRepository, group *gin.RouterGroup) {
+	fc := &controller.FileController{
+		FileUsecase: usecase.NewFileUsecase(fileStorage, timeout),
+		Env:         env,
+	}
+
+	group.GET("/*filepath", fc.DownloadSigned)  // GET /files/{bucket}/{object}?expires=&signature=
+	group.HEAD("/*filepath", fc.DownloadSigned) // HEAD /files/{bucket}/{object}?expires=&signature=
+}
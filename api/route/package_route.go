@@ -30,4 +30,5 @@ func NewPackageRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 
 	// Package specific operations
 	group.GET("/project/:projectId", pc.GetProjectPackages) // GET /api/v1/packages/project/:projectId
+	group.GET("/:id/releases/latest", pc.GetLatestRelease)  // GET /api/v1/packages/:id/releases/latest
 }
@@ -19,11 +19,16 @@ func NewReleaseRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 	shareRepo := repository.NewShareRepository(db)
 
 	rc := &controller.ReleaseController{
-		ReleaseUsecase: usecase.NewReleaseUsecase(releaseRepo, packageRepo, fileStorage, env, timeout),
-		PackageUsecase: usecase.NewPackageUsecase(packageRepo, releaseRepo, timeout), // 添加 PackageUsecase
-		FileUsecase:    usecase.NewFileUsecase(fileStorage, timeout),
-		ShareUsecase:   usecase.NewShareUsecase(shareRepo, releaseRepo, timeout),
-		Env:            env,
+		ReleaseUsecase:      usecase.NewReleaseUsecase(releaseRepo, packageRepo, fileStorage, env, timeout),
+		PackageUsecase:      usecase.NewPackageUsecase(packageRepo, releaseRepo, timeout), // 添加 PackageUsecase
+		FileUsecase:         usecase.NewFileUsecase(fileStorage, timeout),
+		ShareUsecase:        usecase.NewShareUsecase(shareRepo, releaseRepo, timeout),
+		QuotaUsecase:        usecase.NewQuotaUsecase(repository.NewQuotaRepository(db), env.QuotaWarningPercent, timeout),
+		LinuxPackageUsecase: newLinuxPackageUsecase(env, timeout, db, fileStorage),
+		Env:                 env,
+	}
+	if env.SigningEnabled {
+		rc.SigningUsecase = usecase.NewSigningUsecase(repository.NewSigningKeyRepository(db), env.SigningKeySecret, timeout)
 	}
 
 	// Release CRUD operations
@@ -34,7 +39,14 @@ func NewReleaseRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 
 	// Release specific operations
 	group.GET("/:id/download", rc.DownloadRelease)                // GET /api/v1/releases/:id/download
+	group.HEAD("/:id/download", rc.DownloadRelease)               // HEAD /api/v1/releases/:id/download
+	group.GET("/:id/checksums", rc.DownloadChecksums)             // GET /api/v1/releases/:id/checksums?algorithm=sha256
 	group.GET("/package/:package_id/latest", rc.GetLatestRelease) // GET /api/v1/releases/package/:package_id/latest
+	group.POST("/:id/publish", rc.PublishRelease)                 // POST /api/v1/releases/:id/publish
+	group.POST("/:id/latest", rc.SetLatestRelease)                // POST /api/v1/releases/:id/latest
+
+	// Resumable chunked uploads (tus 1.0.0)
+	registerUploadRoutes(env, timeout, db, group.Group("/uploads"), rc.QuotaUsecase, rc.AuthorizeUpload, rc.FinalizeUpload) // /api/v1/releases/uploads
 
 	// Release sharing operations
 	group.POST("/:id/share", rc.CreateShareLink) // POST /api/v1/releases/:id/share
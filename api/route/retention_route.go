@@ -0,0 +1,41 @@
+package route
+
+import (
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewRetentionRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
+	releaseRepo := repository.NewReleaseRepository(db)
+	packageRepo := repository.NewPackageRepository(db)
+
+	retentionUsecase := usecase.NewRetentionUsecase(
+		repository.NewRetentionPolicyRepository(db),
+		repository.NewProjectRepository(db),
+		packageRepo,
+		usecase.NewReleaseUsecase(releaseRepo, packageRepo, fileStorage, env, timeout),
+		timeout,
+	)
+	if env.RetentionEnabled && env.RetentionIntervalHours > 0 {
+		usecase.StartRetentionScheduler(retentionUsecase, time.Duration(env.RetentionIntervalHours)*time.Hour)
+	}
+
+	rc := &controller.RetentionController{
+		RetentionUsecase: retentionUsecase,
+		Env:              env,
+	}
+
+	group.GET("/", rc.GetPolicies)        // GET /api/v1/retention-policies?project_id=
+	group.POST("/", rc.CreatePolicy)      // POST /api/v1/retention-policies
+	group.PUT("/:id", rc.UpdatePolicy)    // PUT /api/v1/retention-policies/:id
+	group.DELETE("/:id", rc.DeletePolicy) // DELETE /api/v1/retention-policies/:id
+	group.GET("/dry-run", rc.DryRun)      // GET /api/v1/retention-policies/dry-run?project_id=
+}
@@ -7,6 +7,7 @@ import (
 	"pkms/bootstrap"
 	"pkms/domain"
 	"pkms/frontend"
+	"pkms/pkg"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -48,6 +49,24 @@ func Setup(app *bootstrap.Application, timeout time.Duration, gin *gin.Engine) {
 	publicClientAccessRouter := gin.Group("/client-access")
 	NewPublicClientAccessRouter(env, timeout, db, fileStorage, publicClientAccessRouter)
 
+	// APT 仓库路由，使用客户端接入凭证（HTTP Basic 认证）
+	if env.AptRepositoryEnabled {
+		aptRouter := gin.Group("/apt")
+		NewAptRouter(env, timeout, db, fileStorage, aptRouter)
+	}
+
+	// YUM/DNF 仓库路由，使用客户端接入凭证（HTTP Basic 认证）
+	if env.YumRepositoryEnabled {
+		yumRouter := gin.Group("/yum")
+		NewYumRouter(env, timeout, db, fileStorage, yumRouter)
+	}
+
+	// 签名直接下载路由，只在本地磁盘存储开启 DOWNLOAD_REDIRECT 且配置了签名密钥时注册
+	if env.DownloadRedirect && env.CurrentStorageType() == domain.StorageTypeDisk && env.DownloadURLSecret != "" {
+		fileRouter := gin.Group(pkg.SignedDownloadPrefix)
+		NewFileRouter(env, timeout, fileStorage, fileRouter)
+	}
+
 	// 系统版本号接口
 	publicRouter.GET("/version", controller.NewSystemController(app).GetVersion)
 
@@ -97,13 +116,35 @@ func Setup(app *bootstrap.Application, timeout time.Duration, gin *gin.Engine) {
 	// 跟新和升级路由
 	upgradeRouter := protectedRouter.Group("/upgrades")
 	upgradeRouter.Use(casbinMiddleware.RequireAnyRole([]string{domain.SystemRoleAdmin, domain.TenantRoleOwner, domain.TenantRoleUser, domain.TenantRoleViewer}))
-	NewUpgradeRouter(env, timeout, db, upgradeRouter)
+	NewUpgradeRouter(env, timeout, db, fileStorage, upgradeRouter)
+
+	// 设备登记路由
+	deviceRouter := protectedRouter.Group("/devices")
+	deviceRouter.Use(casbinMiddleware.RequireAnyRole([]string{domain.SystemRoleAdmin, domain.TenantRoleOwner, domain.TenantRoleUser, domain.TenantRoleViewer}))
+	NewDeviceRouter(env, timeout, db, deviceRouter)
+
+	// 签名密钥管理路由，只有管理员和租户所有者可以访问
+	if env.SigningEnabled {
+		signingRouter := protectedRouter.Group("/signing-keys")
+		signingRouter.Use(casbinMiddleware.RequireAnyRole([]string{domain.SystemRoleAdmin, domain.TenantRoleOwner}))
+		NewSigningRouter(env, timeout, db, signingRouter)
+	}
+
+	// 版本保留策略路由，只有管理员和租户所有者可以访问
+	retentionRouter := protectedRouter.Group("/retention-policies")
+	retentionRouter.Use(casbinMiddleware.RequireAnyRole([]string{domain.SystemRoleAdmin, domain.TenantRoleOwner}))
+	NewRetentionRouter(env, timeout, db, fileStorage, retentionRouter)
 
 	// 用户管理路由，只有管理员可以访问
 	userRouter := protectedRouter.Group("/user")
 	userRouter.Use(casbinMiddleware.RequireRole(domain.SystemRoleAdmin))
 	NewUserRouter(app, timeout, db, userRouter)
 
+	// 存储迁移路由，只有管理员可以访问
+	storageRouter := protectedRouter.Group("/storage")
+	storageRouter.Use(casbinMiddleware.RequireRole(domain.SystemRoleAdmin))
+	NewStorageRouter(env, timeout, db, fileStorage, storageRouter)
+
 	// 系统用户管理路由，只有管理员可以访问
 	tenantRouter := protectedRouter.Group("/tenants")
 	tenantRouter.Use(casbinMiddleware.RequireRole(domain.SystemRoleAdmin))
@@ -23,7 +23,8 @@ func NewShareRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, f
 		FileUsecase:    usecase.NewFileUsecase(fileStorage, timeout),
 		Env:            env,
 	}
-	group.GET("/:code", sc.DownloadSharedRelease) // GET /share/:code - 直接下载分享的文件
+	group.GET("/:code", sc.DownloadSharedRelease)  // GET /share/:code - 直接下载分享的文件
+	group.HEAD("/:code", sc.DownloadSharedRelease) // HEAD /share/:code - 获取文件大小与 ETag
 }
 
 func NewShareManagementRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
@@ -0,0 +1,24 @@
+package route
+
+import (
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/ent"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewSigningRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, group *gin.RouterGroup) {
+	sc := &controller.SigningController{
+		SigningUsecase: usecase.NewSigningUsecase(repository.NewSigningKeyRepository(db), env.SigningKeySecret, timeout),
+		Env:            env,
+	}
+
+	group.GET("/", sc.GetSigningKeys)               // GET /api/v1/signing-keys
+	group.POST("/rotate", sc.RotateSigningKey)      // POST /api/v1/signing-keys/rotate
+	group.GET("/public.pem", sc.DownloadPublicKeys) // GET /api/v1/signing-keys/public.pem
+}
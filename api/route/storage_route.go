@@ -0,0 +1,55 @@
+package route
+
+import (
+	"context"
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/pkg"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NewStorageRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
+	migrationRepo := repository.NewStorageMigrationRepository(db)
+
+	// 上次进程退出时仍在运行的任务已中断，可重新发起以继续
+	if err := migrationRepo.MarkRunningInterrupted(context.Background()); err != nil {
+		pkg.Log.Errorf("标记中断的存储迁移任务失败: %v", err)
+	}
+
+	scanRepo := repository.NewStorageScanRepository(db)
+	if err := scanRepo.MarkRunningFailed(context.Background()); err != nil {
+		pkg.Log.Errorf("标记中断的存储巡检任务失败: %v", err)
+	}
+	storageScanUsecase := usecase.NewStorageScanUsecase(scanRepo, repository.NewReleaseRepository(db), fileStorage, env.S3Bucket,
+		time.Duration(env.StorageOrphanGraceHours)*time.Hour, timeout)
+	if env.StorageScanEnabled && env.StorageScanIntervalHours > 0 {
+		usecase.StartStorageScanScheduler(storageScanUsecase, time.Duration(env.StorageScanIntervalHours)*time.Hour, domain.StartStorageScanRequest{
+			DeleteOrphans: env.StorageScanDeleteOrphans,
+			VerifyHashes:  env.StorageScanVerifyHashes,
+		})
+	}
+
+	sc := &controller.StorageController{
+		StorageMigrationUsecase: usecase.NewStorageMigrationUsecase(migrationRepo, repository.NewReleaseRepository(db), fileStorage, env.CurrentStorageType(), env.S3Bucket,
+			func(storageType string) (domain.FileRepository, error) {
+				return bootstrap.NewFileStorage(env, storageType)
+			}, timeout),
+		StorageScanUsecase: storageScanUsecase,
+		Env:                env,
+	}
+
+	group.POST("/migrations", sc.StartMigration)  // POST /api/v1/storage/migrations
+	group.GET("/migrations", sc.GetMigrations)    // GET /api/v1/storage/migrations
+	group.GET("/migrations/:id", sc.GetMigration) // GET /api/v1/storage/migrations/:id
+
+	group.POST("/scans", sc.StartScan)  // POST /api/v1/storage/scans
+	group.GET("/scans", sc.GetScans)    // GET /api/v1/storage/scans
+	group.GET("/scans/:id", sc.GetScan) // GET /api/v1/storage/scans/:id
+}
@@ -17,6 +17,7 @@ func NewTenantRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 	tr := repository.NewTenantRepository(db)
 	tc := &controller.TenantController{
 		TenantUsecase: usecase.NewTenantUsecase(tr, casbinManager, timeout),
+		QuotaUsecase:  usecase.NewQuotaUsecase(repository.NewQuotaRepository(db), env.QuotaWarningPercent, timeout),
 		CasbinManager: casbinManager,
 		Env:           env,
 	}
@@ -28,6 +29,12 @@ func NewTenantRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 	group.PUT("/:id", tc.UpdateTenant)    // PUT /api/v1/tenants/:id
 	group.DELETE("/:id", tc.DeleteTenant) // DELETE /api/v1/tenants/:id
 
+	// 租户配额与存储用量
+	group.GET("/usage", tc.GetTenantsUsage)       // GET /api/v1/tenants/usage
+	group.GET("/:id/quota", tc.GetTenantQuota)    // GET /api/v1/tenants/:id/quota
+	group.PUT("/:id/quota", tc.UpdateTenantQuota) // PUT /api/v1/tenants/:id/quota
+	group.GET("/:id/usage", tc.GetTenantUsage)    // GET /api/v1/tenants/:id/usage
+
 	// Tenant user management
 	group.GET("/:id/users", tc.GetTenantUsers)                  // GET /api/v1/tenants/:id/users
 	group.POST("/:id/users", tc.AddUserToTenant)                // POST /api/v1/tenants/:id/users
@@ -5,6 +5,7 @@ import (
 
 	"pkms/api/controller"
 	"pkms/bootstrap"
+	"pkms/domain"
 	"pkms/ent"
 	"pkms/repository"
 	"pkms/usecase"
@@ -12,15 +13,18 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewUpgradeRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, group *gin.RouterGroup) {
+func NewUpgradeRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
 	ur := repository.NewUpgradeRepository(db)
 	pr := repository.NewProjectRepository(db)
 	pkgRepo := repository.NewPackageRepository(db)
 	releaseRepo := repository.NewReleaseRepository(db)
 
 	uc := &controller.UpgradeController{
-		UpgradeUsecase: usecase.NewUpgradeUsecase(ur, pr, pkgRepo, releaseRepo, timeout),
-		Env:            env,
+		UpgradeUsecase: usecase.NewUpgradeUsecase(ur, pr, pkgRepo, releaseRepo, timeout,
+			usecase.WithPatchUsecase(usecase.NewPatchUsecase(releaseRepo, fileStorage, env, timeout)),
+			usecase.WithUpgradeReportRepository(repository.NewUpgradeReportRepository(db)),
+		),
+		Env: env,
 	}
 
 	// Upgrade target CRUD operations
@@ -30,6 +34,17 @@ func NewUpgradeRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client,
 	group.PUT("/:id", uc.UpdateUpgradeTarget)    // PUT /api/v1/upgrades/:id
 	group.DELETE("/:id", uc.DeleteUpgradeTarget) // DELETE /api/v1/upgrades/:id
 
+	// Staged rollout controls
+	group.POST("/:id/rollout/pause", uc.PauseRollout)       // POST /api/v1/upgrades/:id/rollout/pause
+	group.POST("/:id/rollout/resume", uc.ResumeRollout)     // POST /api/v1/upgrades/:id/rollout/resume
+	group.POST("/:id/rollout/complete", uc.CompleteRollout) // POST /api/v1/upgrades/:id/rollout/complete
+
+	// Client reported install results
+	group.GET("/:id/stats", uc.GetUpgradeTargetStats) // GET /api/v1/upgrades/:id/stats
+
+	// Channel promotion
+	group.POST("/:id/promote", uc.PromoteUpgradeTarget) // POST /api/v1/upgrades/:id/promote
+
 	// Project specific operations
 	group.GET("/projects/:projectId", uc.GetProjectUpgradeTargets) // GET /api/v1/upgrades/projects/:projectId
 }
@@ -0,0 +1,48 @@
+package route
+
+import (
+	"io"
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerUploadRoutes 在 group 下注册 tus 兼容的分片上传接口，管理端与客户端接入共用
+func registerUploadRoutes(env *bootstrap.Env, timeout time.Duration, db *ent.Client, group *gin.RouterGroup,
+	quotaUsecase domain.QuotaUsecase,
+	authorize func(c *gin.Context) (*domain.UploadSession, bool),
+	finalize func(c *gin.Context, session *domain.UploadSession, file io.Reader, param func(string) string) (string, bool)) {
+	uploadSessionUsecase := usecase.NewUploadSessionUsecase(
+		repository.NewUploadSessionRepository(db),
+		env.UploadSessionDir,
+		env.UploadMaxSizeMB<<20,
+		time.Duration(env.UploadSessionExpiryHours)*time.Hour,
+		timeout,
+	)
+
+	uc := &controller.UploadController{
+		UploadSessionUsecase: uploadSessionUsecase,
+		QuotaUsecase:         quotaUsecase,
+		Env:                  env,
+		BasePath:             group.BasePath(),
+		Authorize:            authorize,
+		Finalize:             finalize,
+	}
+
+	group.OPTIONS("", uc.Options)                  // OPTIONS {base}
+	group.POST("", uc.CreateUpload)                // POST {base}
+	group.OPTIONS("/:id", uc.Options)              // OPTIONS {base}/:id
+	group.HEAD("/:id", uc.HeadUpload)              // HEAD {base}/:id
+	group.GET("/:id", uc.GetUpload)                // GET {base}/:id
+	group.PATCH("/:id", uc.PatchUpload)            // PATCH {base}/:id (tus)
+	group.PUT("/:id", uc.PatchUpload)              // PUT {base}/:id?offset=
+	group.DELETE("/:id", uc.DeleteUpload)          // DELETE {base}/:id
+	group.POST("/:id/finalize", uc.FinalizeUpload) // POST {base}/:id/finalize
+}
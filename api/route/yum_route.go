@@ -0,0 +1,40 @@
+package route
+
+import (
+	"time"
+
+	"pkms/api/controller"
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/repository"
+	"pkms/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewYumRouter YUM/DNF 仓库路由（无需JWT认证，使用客户端接入凭证的 HTTP Basic 认证）
+func NewYumRouter(env *bootstrap.Env, timeout time.Duration, db *ent.Client, fileStorage domain.FileRepository, group *gin.RouterGroup) {
+	packageRepo := repository.NewPackageRepository(db)
+	projectRepo := repository.NewProjectRepository(db)
+	clientAccessRepo := repository.NewClientAccessRepository(db)
+
+	yc := &controller.YumRepositoryController{
+		YumRepositoryUsecase: usecase.NewYumRepositoryUsecase(
+			packageRepo,
+			repository.NewReleaseRepository(db),
+			repository.NewLinuxPackageRepository(db),
+			fileStorage,
+			repositorySigner(env),
+			env.S3Bucket,
+			timeout,
+		),
+		ClientAccessUsecase: usecase.NewClientAccessUsecase(clientAccessRepo, projectRepo, packageRepo, timeout),
+		FileUsecase:         usecase.NewFileUsecase(fileStorage, timeout),
+		Env:                 env,
+	}
+
+	group.GET("/gpg.key", yc.PublicKey)       // GET /yum/gpg.key
+	group.GET("/:scope/:id/*path", yc.Serve)  // GET /yum/{projects|packages}/{id}/{channel}/repodata/... 或 packages/...
+	group.HEAD("/:scope/:id/*path", yc.Serve) // HEAD /yum/{projects|packages}/{id}/...
+}
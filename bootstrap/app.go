@@ -4,6 +4,7 @@ import (
 	"pkms/domain"
 	"pkms/ent"
 	"pkms/internal/casbin"
+	"pkms/repository"
 
 	"github.com/minio/minio-go/v7"
 )
@@ -26,7 +27,8 @@ func App() *Application {
 	// 初始化文件存储
 	storageConfig := InitStorage(app.Env)
 	app.MinioClient = storageConfig.MinioClient
-	app.FileStorage = storageConfig.FileStorage
+	// 内容寻址包装始终生效：即使关闭 STORAGE_DEDUP，已有的内容寻址对象也必须按引用计数删除
+	app.FileStorage = repository.NewDedupFileRepository(storageConfig.FileStorage, repository.NewBlobRepository(app.DB), app.Env.StorageDedup)
 
 	return app
 }
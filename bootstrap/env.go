@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"log"
 	"os"
+	"pkms/domain"
 	"pkms/pkg"
 
 	"github.com/spf13/viper"
@@ -35,6 +36,65 @@ type Env struct {
 	S3SecretKey string `mapstructure:"S3_SECRET_KEY"`
 	S3Bucket    string `mapstructure:"S3_BUCKET"`
 	S3Token     string `mapstructure:"S3_TOKEN"`
+
+	S3UseSSL             bool   `mapstructure:"S3_USE_SSL"`              // 使用 HTTPS 连接
+	S3CAFile             string `mapstructure:"S3_CA_FILE"`              // 自定义 CA 证书（PEM），用于自签名证书
+	S3InsecureSkipVerify bool   `mapstructure:"S3_INSECURE_SKIP_VERIFY"` // 跳过证书校验，仅用于测试环境
+	S3Region             string `mapstructure:"S3_REGION"`               // 区域，AWS S3 需要设置
+	S3BucketLookup       string `mapstructure:"S3_BUCKET_LOOKUP"`        // 存储桶寻址方式 auto/path/dns
+	S3SSE                string `mapstructure:"S3_SSE"`                  // 服务端加密：空、AES256（SSE-S3）或 aws:kms（SSE-KMS）
+	S3SSEKMSKeyID        string `mapstructure:"S3_SSE_KMS_KEY_ID"`       // SSE-KMS 使用的密钥 ID，为空时使用默认密钥
+	S3Prefix             string `mapstructure:"S3_PREFIX"`               // 存储桶内的路径前缀，多个实例共用一个存储桶时使用
+
+	// 差分补丁配置
+	DeltaPatchEnabled          bool  `mapstructure:"DELTA_PATCH_ENABLED"`           // 是否在创建升级目标后生成差分补丁
+	DeltaPatchPreviousReleases int   `mapstructure:"DELTA_PATCH_PREVIOUS_RELEASES"` // 为最近多少个旧版本生成补丁
+	DeltaPatchMaxSizeMB        int64 `mapstructure:"DELTA_PATCH_MAX_SIZE_MB"`       // 参与差分的单个文件大小上限（MB），生成时内存占用约为文件大小的 10 倍
+	DeltaPatchPendingTimeout   int   `mapstructure:"DELTA_PATCH_PENDING_TIMEOUT"`   // 补丁处于生成中超过该时间（分钟）视为中断，重新生成
+
+	// 灰度自动暂停配置
+	RolloutFailureThreshold  float64 `mapstructure:"ROLLOUT_FAILURE_THRESHOLD"`   // 安装/启动失败率超过该值时自动暂停，<=0 表示关闭
+	RolloutFailureMinReports int     `mapstructure:"ROLLOUT_FAILURE_MIN_REPORTS"` // 触发自动暂停所需的最少上报数
+
+	// 发布签名配置
+	SigningEnabled   bool   `mapstructure:"SIGNING_ENABLED"`    // 是否对构件和检查更新响应签名
+	SigningKeySecret string `mapstructure:"SIGNING_KEY_SECRET"` // 加密存储租户私钥的密钥
+
+	// 部署的服务实例数，分片上传的暂存文件和写入锁只在本实例内有效，目前只支持单实例部署
+	Replicas int `mapstructure:"REPLICAS"`
+
+	// 分片上传配置
+	UploadSessionDir         string `mapstructure:"UPLOAD_SESSION_DIR"`          // 分片暂存目录
+	UploadSessionExpiryHours int    `mapstructure:"UPLOAD_SESSION_EXPIRY_HOURS"` // 会话无写入后多久过期
+	UploadMaxSizeMB          int64  `mapstructure:"UPLOAD_MAX_SIZE_MB"`          // 单个文件大小上限（MB），<=0 表示不限制
+
+	// 直接下载配置
+	DownloadRedirect         bool   `mapstructure:"DOWNLOAD_REDIRECT"`           // 下载接口是否重定向到有时效的直接下载地址
+	DownloadURLExpirySeconds int    `mapstructure:"DOWNLOAD_URL_EXPIRY_SECONDS"` // 直接下载地址的有效期（秒）
+	DownloadURLSecret        string `mapstructure:"DOWNLOAD_URL_SECRET"`         // 本地磁盘签名下载地址的 HMAC 密钥
+
+	// 存储巡检配置
+	StorageScanEnabled       bool `mapstructure:"STORAGE_SCAN_ENABLED"`        // 是否定时巡检孤立对象和文件完整性
+	StorageScanIntervalHours int  `mapstructure:"STORAGE_SCAN_INTERVAL_HOURS"` // 定时巡检间隔（小时）
+	StorageOrphanGraceHours  int  `mapstructure:"STORAGE_ORPHAN_GRACE_HOURS"`  // 孤立对象创建后多久才允许删除（小时）
+	StorageScanDeleteOrphans bool `mapstructure:"STORAGE_SCAN_DELETE_ORPHANS"` // 定时巡检是否删除孤立对象，关闭时只报告
+	StorageScanVerifyHashes  bool `mapstructure:"STORAGE_SCAN_VERIFY_HASHES"`  // 定时巡检是否读取文件校验 SHA-256
+
+	// 内容寻址存储配置
+	StorageDedup bool `mapstructure:"STORAGE_DEDUP"` // 新上传的文件是否按 SHA-256 存储，相同内容只保留一份
+
+	// 租户配额配置
+	QuotaWarningPercent float64 `mapstructure:"QUOTA_WARNING_PERCENT"` // 存储用量达到该百分比时在用量报告中标记为接近上限
+
+	// 版本保留策略配置
+	RetentionEnabled       bool `mapstructure:"RETENTION_ENABLED"`        // 是否定时执行版本保留策略
+	RetentionIntervalHours int  `mapstructure:"RETENTION_INTERVAL_HOURS"` // 执行间隔（小时）
+
+	// 软件仓库配置
+	AptRepositoryEnabled     bool   `mapstructure:"APT_REPOSITORY_ENABLED"`      // 是否以 APT 仓库形式提供 linux 包中的 .deb 文件
+	YumRepositoryEnabled     bool   `mapstructure:"YUM_REPOSITORY_ENABLED"`      // 是否以 YUM/DNF 仓库形式提供 linux 包中的 .rpm 文件
+	RepoSigningKeyFile       string `mapstructure:"REPO_SIGNING_KEY_FILE"`       // 仓库索引签名使用的 OpenPGP 私钥文件，不存在时自动生成；为空表示不签名
+	RepoSigningKeyPassphrase string `mapstructure:"REPO_SIGNING_KEY_PASSPHRASE"` // 私钥的保护密码
 }
 
 func setDefaults() {
@@ -64,6 +124,62 @@ func setDefaults() {
 	viper.SetDefault("S3_SECRET_KEY", "eIuV0i4ChbLqx54g9rhsZDRTC2LE1xEcnIAnAw1C")
 	viper.SetDefault("S3_BUCKET", "pkms")
 	viper.SetDefault("S3_TOKEN", "")
+	viper.SetDefault("S3_USE_SSL", false)
+	viper.SetDefault("S3_CA_FILE", "")
+	viper.SetDefault("S3_INSECURE_SKIP_VERIFY", false)
+	viper.SetDefault("S3_REGION", "")
+	viper.SetDefault("S3_BUCKET_LOOKUP", "auto")
+	viper.SetDefault("S3_SSE", "")
+	viper.SetDefault("S3_SSE_KMS_KEY_ID", "")
+	viper.SetDefault("S3_PREFIX", "")
+
+	// 差分补丁默认配置
+	viper.SetDefault("DELTA_PATCH_ENABLED", true)
+	viper.SetDefault("DELTA_PATCH_PREVIOUS_RELEASES", 3)
+	viper.SetDefault("DELTA_PATCH_MAX_SIZE_MB", 128)
+	viper.SetDefault("DELTA_PATCH_PENDING_TIMEOUT", 60)
+
+	// 灰度自动暂停默认配置
+	viper.SetDefault("ROLLOUT_FAILURE_THRESHOLD", 0.2)
+	viper.SetDefault("ROLLOUT_FAILURE_MIN_REPORTS", 20)
+
+	// 发布签名默认配置
+	viper.SetDefault("SIGNING_ENABLED", true)
+	viper.SetDefault("SIGNING_KEY_SECRET", "")
+
+	// 分片上传默认配置
+	viper.SetDefault("REPLICAS", 1)
+	viper.SetDefault("UPLOAD_SESSION_DIR", "./upload-sessions")
+	viper.SetDefault("UPLOAD_SESSION_EXPIRY_HOURS", 24)
+	viper.SetDefault("UPLOAD_MAX_SIZE_MB", 10240)
+
+	// 直接下载默认配置
+	viper.SetDefault("DOWNLOAD_REDIRECT", false)
+	viper.SetDefault("DOWNLOAD_URL_EXPIRY_SECONDS", 300)
+	viper.SetDefault("DOWNLOAD_URL_SECRET", "")
+
+	// 存储巡检默认配置
+	viper.SetDefault("STORAGE_SCAN_ENABLED", true)
+	viper.SetDefault("STORAGE_SCAN_INTERVAL_HOURS", 24)
+	viper.SetDefault("STORAGE_ORPHAN_GRACE_HOURS", 24)
+	viper.SetDefault("STORAGE_SCAN_DELETE_ORPHANS", false)
+	viper.SetDefault("STORAGE_SCAN_VERIFY_HASHES", false)
+
+	// 内容寻址存储默认配置
+	viper.SetDefault("STORAGE_DEDUP", false)
+
+	// 租户配额默认配置
+	viper.SetDefault("QUOTA_WARNING_PERCENT", 80)
+
+	// 版本保留策略默认配置
+	viper.SetDefault("RETENTION_ENABLED", true)
+	viper.SetDefault("RETENTION_INTERVAL_HOURS", 6)
+
+	// 软件仓库默认配置
+	viper.SetDefault("APT_REPOSITORY_ENABLED", true)
+	viper.SetDefault("YUM_REPOSITORY_ENABLED", true)
+	viper.SetDefault("REPO_SIGNING_KEY_FILE", "./database/repo-signing-key.asc")
+	viper.SetDefault("REPO_SIGNING_KEY_PASSPHRASE", "")
 }
 
 func NewEnv() *Env {
@@ -95,5 +211,21 @@ func NewEnv() *Env {
 		log.Println("The App is running in development env")
 	}
 
+	// 分片暂存在本地目录、同一会话的写入靠进程内的锁串行，多实例时后续分片可能落到没有暂存文件的实例上
+	if env.Replicas > 1 {
+		log.Fatalf("❌ REPLICAS=%d: 分片上传只支持单实例部署，请将 REPLICAS 设为 1", env.Replicas)
+	}
+
+	// 本地磁盘的直接下载地址由 DOWNLOAD_URL_SECRET 签名，未配置时下载改为服务端转发
+	if env.DownloadRedirect && env.CurrentStorageType() == domain.StorageTypeDisk && env.DownloadURLSecret == "" {
+		pkg.Log.Warn("未配置 DOWNLOAD_URL_SECRET，本地磁盘存储不提供直接下载地址")
+	}
+
+	// 私钥种子不能用公开的默认密钥加密，未配置密钥时关闭发布签名
+	if env.SigningEnabled && env.SigningKeySecret == "" {
+		pkg.Log.Warn("未配置 SIGNING_KEY_SECRET，发布签名已关闭")
+		env.SigningEnabled = false
+	}
+
 	return &env
 }
@@ -1,11 +1,14 @@
 package bootstrap
 
 import (
+	"crypto/x509"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"pkms/domain"
 	"pkms/repository"
 )
@@ -31,26 +34,138 @@ func InitStorage(env *Env) *StorageConfig {
 	return config
 }
 
+// CurrentStorageType 返回当前配置的存储类型，未识别的类型按默认的本地磁盘处理
+func (env *Env) CurrentStorageType() string {
+	if strings.ToLower(env.StorageType) == domain.StorageTypeMinio {
+		return domain.StorageTypeMinio
+	}
+	return domain.StorageTypeDisk
+}
+
+// NewFileStorage 按存储类型创建文件存储，用于存储迁移等需要同时访问两种后端的场景
+func NewFileStorage(env *Env, storageType string) (domain.FileRepository, error) {
+	switch storageType {
+	case domain.StorageTypeMinio:
+		minioClient, err := newMinioClient(env)
+		if err != nil {
+			return nil, err
+		}
+		return newS3FileRepository(env, minioClient)
+	case domain.StorageTypeDisk:
+		return repository.NewDiskFileRepository(env.StorageBasePath, env.DownloadURLSecret), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
+	}
+}
+
+func newMinioClient(env *Env) (*minio.Client, error) {
+	bucketLookup, err := s3BucketLookup(env.S3BucketLookup)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &minio.Options{
+		Creds:        credentials.NewStaticV4(env.S3AccessKey, env.S3SecretKey, env.S3Token),
+		Secure:       env.S3UseSSL,
+		Region:       env.S3Region,
+		BucketLookup: bucketLookup,
+	}
+	if env.S3UseSSL && (env.S3CAFile != "" || env.S3InsecureSkipVerify) {
+		transport, err := minio.DefaultTransport(true)
+		if err != nil {
+			return nil, err
+		}
+		if env.S3CAFile != "" {
+			rootCAs, err := loadCertPool(env.S3CAFile)
+			if err != nil {
+				return nil, err
+			}
+			transport.TLSClientConfig.RootCAs = rootCAs
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = env.S3InsecureSkipVerify
+		options.Transport = transport
+	}
+	return minio.New(env.S3Address, options)
+}
+
+// newS3FileRepository 按配置创建 S3 文件存储
+func newS3FileRepository(env *Env, client *minio.Client) (domain.FileRepository, error) {
+	encryption, err := s3ServerSideEncryption(env.S3SSE, env.S3SSEKMSKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewFileRepository(client, repository.S3Options{
+		Region:     env.S3Region,
+		Prefix:     env.S3Prefix,
+		Encryption: encryption,
+	}), nil
+}
+
+// s3BucketLookup auto 由客户端按地址判断（AWS 等使用虚拟主机方式，其余使用路径方式）
+func s3BucketLookup(lookup string) (minio.BucketLookupType, error) {
+	switch strings.ToLower(lookup) {
+	case "", "auto":
+		return minio.BucketLookupAuto, nil
+	case "path":
+		return minio.BucketLookupPath, nil
+	case "dns", "virtual-host":
+		return minio.BucketLookupDNS, nil
+	default:
+		return minio.BucketLookupAuto, fmt.Errorf("unsupported S3_BUCKET_LOOKUP: %s", lookup)
+	}
+}
+
+// s3ServerSideEncryption AES256 使用 SSE-S3，aws:kms 使用 SSE-KMS（未指定密钥时使用默认 KMS 密钥）
+func s3ServerSideEncryption(sse, kmsKeyID string) (encrypt.ServerSide, error) {
+	switch sse {
+	case "":
+		return nil, nil
+	case "AES256":
+		return encrypt.NewSSE(), nil
+	case "aws:kms":
+		return encrypt.NewSSEKMS(kmsKeyID, nil)
+	default:
+		return nil, fmt.Errorf("unsupported S3_SSE: %s", sse)
+	}
+}
+
+// loadCertPool 系统根证书加上 caFile 中的 PEM 证书，用于自签名证书的 MinIO/Ceph
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read S3_CA_FILE: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in S3_CA_FILE: %s", caFile)
+	}
+	return pool, nil
+}
+
 func (sc *StorageConfig) initMinioStorage(env *Env) {
-	minioClient, err := minio.New(env.S3Address, &minio.Options{
-		Creds:  credentials.NewStaticV4(env.S3AccessKey, env.S3SecretKey, env.S3Token),
-		Secure: false,
-	})
+	minioClient, err := newMinioClient(env)
 	if err != nil {
 		panic("Failed to connect to MinIO: " + err.Error())
 	}
+	fileStorage, err := newS3FileRepository(env, minioClient)
+	if err != nil {
+		panic("Invalid S3 storage configuration: " + err.Error())
+	}
 
 	sc.MinioClient = minioClient
-	sc.FileStorage = repository.NewFileRepository(minioClient)
-	fmt.Println("文件存储: 使用 MinIO 对象存储")
+	sc.FileStorage = fileStorage
+	fmt.Printf("文件存储: 使用 S3 对象存储 (%s)\n", env.S3Address)
 }
 
 func (sc *StorageConfig) initDiskStorage(env *Env) {
-	sc.FileStorage = repository.NewDiskFileRepository(env.StorageBasePath)
+	sc.FileStorage = repository.NewDiskFileRepository(env.StorageBasePath, env.DownloadURLSecret)
 	fmt.Printf("文件存储: 使用本地磁盘存储 (%s)\n", env.StorageBasePath)
 }
 
 func (sc *StorageConfig) initDefaultStorage(env *Env) {
-	sc.FileStorage = repository.NewDiskFileRepository(env.StorageBasePath)
+	sc.FileStorage = repository.NewDiskFileRepository(env.StorageBasePath, env.DownloadURLSecret)
 	fmt.Printf("文件存储: 使用默认本地磁盘存储 (%s)\n", env.StorageBasePath)
 }
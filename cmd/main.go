@@ -1,11 +1,13 @@
 package main
 
 import (
+	"os"
 	"pkms/pkg"
 	"time"
 
 	"pkms/api/route"
 	"pkms/bootstrap"
+	"pkms/internal/command"
 	"pkms/internal/initializer"
 
 	"github.com/gin-gonic/gin"
@@ -40,7 +42,7 @@ var (
 
 func main() {
 	// 记录版本信息
-	pkg.Log.Println("PKMS starting - Version: %s, Commit: %s, Built: %s", version, commit, date)
+	pkg.Log.Printf("PKMS starting - Version: %s, Commit: %s, Built: %s", version, commit, date)
 
 	app := bootstrap.App()
 	defer app.CloseDBConnection()
@@ -51,6 +53,20 @@ func main() {
 	casbin := app.CasbinManager
 	timeout := time.Duration(env.ContextTimeout) * time.Second
 
+	// 管理命令：存储迁移
+	if len(os.Args) > 1 && os.Args[1] == "migrate-storage" {
+		code := command.MigrateStorage(app, timeout, os.Args[2:])
+		app.CloseDBConnection()
+		os.Exit(code)
+	}
+
+	// 管理命令：内容寻址迁移
+	if len(os.Args) > 1 && os.Args[1] == "dedup-storage" {
+		code := command.DedupStorage(app, timeout, os.Args[2:])
+		app.CloseDBConnection()
+		os.Exit(code)
+	}
+
 	// 初始化RBAC系统（必须在admin用户创建之前）
 	rbacInitializer := initializer.NewRBACInitializer(db, casbin)
 	if err := rbacInitializer.Initialize(); err != nil {
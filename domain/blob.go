@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	// BlobPrefix 内容寻址对象的存储路径前缀：blobs/sha256/{hash[:2]}/{hash}
+	BlobPrefix = "blobs/sha256/"
+	// BlobStagingPrefix 上传过程中的临时路径，计算出摘要后移动到 BlobPrefix 下
+	BlobStagingPrefix = "blobs/tmp/"
+)
+
+// BlobPath 由 SHA-256 生成对象路径
+func BlobPath(hash string) string {
+	return BlobPrefix + hash[:2] + "/" + hash
+}
+
+// BlobHashFromPath 从对象路径解析 SHA-256，不是内容寻址路径时返回 false
+func BlobHashFromPath(objectPath string) (string, bool) {
+	if !strings.HasPrefix(objectPath, BlobPrefix) {
+		return "", false
+	}
+	hash := objectPath[strings.LastIndex(objectPath, "/")+1:]
+	if len(hash) != 64 || objectPath != BlobPath(hash) {
+		return "", false
+	}
+	return hash, true
+}
+
+// Blob 内容寻址存储中的对象，RefCount 为引用它的上传次数
+type Blob struct {
+	ID        string    `json:"id"`
+	Hash      string    `json:"hash"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	RefCount  int       `json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"` // 最近一次引用计数变更的时间
+}
+
+type BlobRepository interface {
+	// GetByHash 不存在时返回 nil, nil
+	GetByHash(c context.Context, hash string) (*Blob, error)
+	Create(c context.Context, blob *Blob) error
+	// AddRef 引用计数加一，对象不存在时返回 false
+	AddRef(c context.Context, hash string) (bool, error)
+	// Release 引用计数减一，归零时删除记录；返回剩余引用数，对象不存在时 found 为 false
+	Release(c context.Context, hash string) (remaining int, found bool, err error)
+	// Delete 不论引用计数直接删除记录，记录不存在时忽略
+	Delete(c context.Context, hash string) error
+}
+
+// BlobCollector 由内容寻址存储实现，供存储巡检回收没有被任何版本、构件或补丁引用的内容寻址对象
+// 这类对象的引用计数已经失真，按引用计数删除只会减一而不会真正删除
+type BlobCollector interface {
+	// CollectBlob 绕过引用计数删除对象及其记录；记录在 since 之后有过引用变更（可能是尚未写入数据库的上传）时不删除，返回是否已删除
+	CollectBlob(c context.Context, bucket, objectName string, since time.Time) (bool, error)
+}
+
+// StorageDedupResult 将已有文件迁移到内容寻址存储的结果
+type StorageDedupResult struct {
+	Total        int      `json:"total"`        // 需要处理的对象（按路径去重）
+	Moved        int      `json:"moved"`        // 移动为新的内容寻址对象
+	Deduplicated int      `json:"deduplicated"` // 与已有对象内容相同，改为引用已有对象并删除副本
+	Skipped      int      `json:"skipped"`      // 已经是内容寻址路径
+	Failed       int      `json:"failed"`
+	SavedBytes   int64    `json:"saved_bytes"`
+	Failures     []string `json:"failures,omitempty"`
+}
+
+type StorageDedupUsecase interface {
+	// Dedupe 将版本、构件和补丁引用的文件迁移为内容寻址对象，相同内容只保留一份；可重复执行
+	Dedupe(c context.Context) (*StorageDedupResult, error)
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 校验和文件支持的摘要算法
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// ChecksumFileName 校验和文件名，如 SHA256SUMS
+func ChecksumFileName(algorithm string) string {
+	return strings.ToUpper(algorithm) + "SUMS"
+}
+
+// ChecksumFile 生成版本的校验和文件（sha256sum/sha512sum 输出格式），包含主文件与各平台构件
+// 没有记录摘要的文件（摘要功能上线前上传的文件）会被跳过
+func (r *Release) ChecksumFile(algorithm string) ([]byte, error) {
+	if algorithm != ChecksumSHA256 && algorithm != ChecksumSHA512 {
+		return nil, fmt.Errorf("不支持的摘要算法: %s", algorithm)
+	}
+	digest := func(sha256, sha512 string) string {
+		if algorithm == ChecksumSHA512 {
+			return sha512
+		}
+		return sha256
+	}
+
+	entries := make(map[string]string)
+	if sum := digest(r.SHA256, r.SHA512); sum != "" && r.FileName != "" {
+		entries[r.FileName] = sum
+	}
+	for _, asset := range r.Assets {
+		if asset.Kind == "checksum" {
+			continue
+		}
+		if sum := digest(asset.SHA256, asset.SHA512); sum != "" {
+			entries[asset.FileName] = sum
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", entries[name], name)
+	}
+	return []byte(b.String()), nil
+}
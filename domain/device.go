@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Device 登记的客户端设备
+type Device struct {
+	ID             string            `json:"id"`
+	TenantID       string            `json:"tenant_id"`
+	ProjectID      string            `json:"project_id"`
+	PackageID      string            `json:"package_id"`
+	ClientAccessID string            `json:"client_access_id"`
+	DeviceID       string            `json:"device_id"`
+	CurrentVersion string            `json:"current_version"`
+	Channel        string            `json:"channel,omitempty"`
+	OS             string            `json:"os,omitempty"`
+	Arch           string            `json:"arch,omitempty"`
+	OSVersion      string            `json:"os_version,omitempty"`
+	Model          string            `json:"model,omitempty"`
+	Region         string            `json:"region,omitempty"`
+	Hostname       string            `json:"hostname,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	ClientInfo     string            `json:"client_info,omitempty"`
+	LastIP         string            `json:"last_ip,omitempty"`
+	CheckCount     int               `json:"check_count"`
+	FirstSeenAt    time.Time         `json:"first_seen_at"`
+	LastSeenAt     time.Time         `json:"last_seen_at"`
+}
+
+// DeviceAttributes 检查更新时客户端上报的设备属性，OSVersion/Model/Region 同时用于升级目标定向
+type DeviceAttributes struct {
+	OSVersion string            `json:"os_version,omitempty"`
+	Model     string            `json:"model,omitempty"`
+	Region    string            `json:"region,omitempty"` // 为空时取 labels 中的 region
+	Hostname  string            `json:"hostname,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// DevicePagedResult 设备分页查询结果
+type DevicePagedResult = PagedResult[*Device]
+
+// DeviceVersionCount 某个版本上的设备数量
+type DeviceVersionCount struct {
+	Version string `json:"version"`
+	Count   int    `json:"count"`
+}
+
+// DeviceRepository 设备登记数据仓库接口
+type DeviceRepository interface {
+	// 按 package_id + device_id 写入或更新设备
+	Upsert(ctx context.Context, device *Device) error
+	GetByID(ctx context.Context, id string) (*Device, error)
+	// 支持 package_id、version、os、channel、active_since 过滤
+	GetDevicesPaged(ctx context.Context, tenantID string, filters map[string]interface{}, params QueryParams) (*DevicePagedResult, error)
+	// 按当前版本统计设备数量
+	CountByVersion(ctx context.Context, tenantID, packageID string, activeSince *time.Time) ([]*DeviceVersionCount, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// DeviceUsecase 设备登记业务逻辑接口
+type DeviceUsecase interface {
+	GetDevicesPaged(ctx context.Context, tenantID string, filters map[string]interface{}, params QueryParams) (*DevicePagedResult, error)
+	GetDeviceByID(ctx context.Context, id string) (*Device, error)
+	GetVersionDistribution(ctx context.Context, tenantID, packageID string, activeSince *time.Time) ([]*DeviceVersionCount, error)
+	DeleteDevice(ctx context.Context, id string) error
+}
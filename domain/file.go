@@ -33,6 +33,9 @@ type UploadResult struct {
 	ETag       string `json:"etag"`
 	Size       int64  `json:"size"`
 	ObjectName string `json:"object_name"`
+	// 上传过程中流式计算的文件摘要（十六进制）
+	SHA256 string `json:"sha256,omitempty"`
+	SHA512 string `json:"sha512,omitempty"`
 }
 
 type DownloadRequest struct {
@@ -40,17 +43,35 @@ type DownloadRequest struct {
 	ObjectName string `json:"object_name"`
 }
 
+// PresignOptions 生成直接下载地址（S3 预签名地址或本地磁盘的签名地址）的参数
+type PresignOptions struct {
+	FileName string        // 下载时的文件名
+	FileSize int64         // 文件大小，磁盘签名地址用于生成响应头
+	Hash     string        // 文件摘要，磁盘签名地址用于生成 ETag
+	Expiry   time.Duration // 地址有效期
+}
+
 type FileRepository interface {
 	Upload(c context.Context, req *UploadRequest) (*UploadResult, error)
 	Download(c context.Context, req *DownloadRequest) (io.ReadCloser, error)
+	// DownloadRange 读取对象从 offset 开始的 length 个字节，length < 0 表示读到末尾
+	DownloadRange(c context.Context, req *DownloadRequest, offset, length int64) (io.ReadCloser, error)
+	// PresignDownload 生成有时效的直接下载地址，客户端可绕过服务端直接下载
+	PresignDownload(c context.Context, req *DownloadRequest, opts *PresignOptions) (string, error)
 	Delete(c context.Context, bucket, objectName string) error
 	List(c context.Context, bucket, prefix string) ([]FileInfo, error)
 	GetObjectStat(c context.Context, bucket, objectName string) (*FileInfo, error)
+	// Move 在同一存储内移动对象，目标已存在时覆盖
+	Move(c context.Context, bucket, source, target string) error
 }
 
 type FileUsecase interface {
 	Upload(c context.Context, req *UploadRequest) (*UploadResult, error)
 	Download(c context.Context, req *DownloadRequest) (io.ReadCloser, error)
+	// DownloadRange 读取对象从 offset 开始的 length 个字节，length < 0 表示读到末尾
+	DownloadRange(c context.Context, req *DownloadRequest, offset, length int64) (io.ReadCloser, error)
+	// PresignDownload 生成有时效的直接下载地址，客户端可绕过服务端直接下载
+	PresignDownload(c context.Context, req *DownloadRequest, opts *PresignOptions) (string, error)
 	Delete(c context.Context, bucket, objectName string) error
 	List(c context.Context, bucket, prefix string) ([]FileInfo, error)
 	GetObjectStat(c context.Context, bucket, objectName string) (*FileInfo, error)
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Linux 软件包文件格式
+const (
+	LinuxPackageFormatDeb = "deb"
+	LinuxPackageFormatRPM = "rpm"
+)
+
+// 软件仓库范围：项目下的所有 linux 类型包，或单个包
+const (
+	RepositoryScopeProject = "projects"
+	RepositoryScopePackage = "packages"
+)
+
+// 软件仓库的发布通道：APT 仓库 dists/ 下的发行版，YUM 仓库根目录下的子目录
+const (
+	RepositoryChannelStable  = "stable"  // 只包含稳定版本
+	RepositoryChannelTesting = "testing" // 包含预发布版本在内的所有已发布版本
+)
+
+// ErrRepositoryFileNotFound 仓库中没有请求的文件
+var ErrRepositoryFileNotFound = errors.New("repository file not found")
+
+// LinuxPackage 从版本文件（.deb/.rpm）中提取的软件包元数据
+type LinuxPackage struct {
+	ID           string    `json:"id"`
+	ProjectID    string    `json:"project_id"`
+	PackageID    string    `json:"package_id"`
+	ReleaseID    string    `json:"release_id"`
+	Format       string    `json:"format"`
+	Name         string    `json:"name"`
+	Version      string    `json:"version"`
+	Architecture string    `json:"architecture"`
+	FilePath     string    `json:"file_path"`
+	FileName     string    `json:"file_name"`
+	FileSize     int64     `json:"file_size"`
+	SHA256       string    `json:"sha256,omitempty"`
+	SHA512       string    `json:"sha512,omitempty"`
+	Metadata     string    `json:"metadata"`        // deb 为 control 段落原文，rpm 为头部信息 JSON
+	Error        string    `json:"error,omitempty"` // 无法解析时的错误信息，此类文件不会出现在仓库索引中
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RepositoryScope 软件仓库对应的项目或包
+type RepositoryScope struct {
+	Type string // RepositoryScopeProject/RepositoryScopePackage
+	ID   string
+}
+
+// Allows 客户端接入凭证是否可以访问该仓库：项目仓库要求凭证属于该项目，包仓库要求凭证属于该包
+func (s RepositoryScope) Allows(access *ClientAccess) bool {
+	switch s.Type {
+	case RepositoryScopeProject:
+		return access.ProjectID == s.ID
+	case RepositoryScopePackage:
+		return access.PackageID == s.ID
+	default:
+		return false
+	}
+}
+
+type LinuxPackageRepository interface {
+	// Create 同一版本的同一文件已经记录过时不会重复写入，p 被替换为已有记录
+	Create(c context.Context, p *LinuxPackage) error
+	GetByID(c context.Context, id string) (*LinuxPackage, error)
+	ListByPackageIDs(c context.Context, packageIDs []string, format string) ([]*LinuxPackage, error)
+}
+
+// LinuxPackageUsecase 在版本文件写入时提取其中 .deb/.rpm 文件的元数据，APT/YUM 仓库只使用已记录的结果
+type LinuxPackageUsecase interface {
+	// IndexRelease 提取版本主文件和构件中尚未记录的软件包文件；无法解析的文件记录错误信息并返回错误，不会进入仓库索引
+	IndexRelease(c context.Context, releaseID string) error
+}
+
+// AptRepositoryUsecase 将 linux 类型包中的 .deb 文件以 APT 仓库的形式提供
+type AptRepositoryUsecase interface {
+	// GetIndexFile 生成 dists/ 下的索引文件（InRelease、Release、Release.gpg、Packages、Packages.gz），path 相对于仓库根目录
+	GetIndexFile(c context.Context, scope RepositoryScope, path string) ([]byte, error)
+	// GetPoolPackage 获取 pool/ 下的软件包文件，不属于该仓库时返回 ErrRepositoryFileNotFound
+	GetPoolPackage(c context.Context, scope RepositoryScope, id string) (*LinuxPackage, error)
+	// PublicKey 仓库签名公钥（ASCII armor），未配置签名密钥时返回错误
+	PublicKey() ([]byte, error)
+}
+
+// YumRepositoryUsecase 将 linux 类型包中的 .rpm 文件以 YUM/DNF 仓库的形式提供
+type YumRepositoryUsecase interface {
+	// GetMetadataFile 生成 {channel}/repodata/ 下的元数据文件（repomd.xml、repomd.xml.asc 以及 primary/filelists/other），path 相对于仓库根目录
+	GetMetadataFile(c context.Context, scope RepositoryScope, path string) ([]byte, error)
+	// GetPackage 获取 {channel}/packages/ 下的软件包文件，不属于该仓库时返回 ErrRepositoryFileNotFound
+	GetPackage(c context.Context, scope RepositoryScope, id string) (*LinuxPackage, error)
+	// PublicKey 仓库签名公钥（ASCII armor），未配置签名密钥时返回错误
+	PublicKey() ([]byte, error)
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// PackageTypeLinux linux 类型的包，其中的 .deb/.rpm 文件可以通过 APT/YUM 仓库安装
+const PackageTypeLinux = "linux"
+
+// PackageTypeDesktop desktop 类型的包，可以为 electron-updater/Squirrel.Windows 生成更新源
+const PackageTypeDesktop = "desktop"
+
 // Package represents a package (without versions) - 新的包结构
 type Package struct {
 	ID             string    `json:"id"`
@@ -12,6 +18,7 @@ type Package struct {
 	Name           string    `json:"name"`
 	Type           string    `json:"type"`
 	Description    string    `json:"description"`
+	VersionScheme  string    `json:"version_scheme,omitempty"` // 版本号排序方案：auto/semver/integer
 	CreatedBy      string    `json:"created_by"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 差分补丁生成状态
+const (
+	PatchStatusPending = "pending"
+	PatchStatusReady   = "ready"
+	PatchStatusFailed  = "failed"
+)
+
+// ReleasePatch 两个版本之间的二进制差分补丁
+type ReleasePatch struct {
+	ID            string    `json:"id"`
+	PackageID     string    `json:"package_id"`
+	FromReleaseID string    `json:"from_release_id"`
+	ToReleaseID   string    `json:"to_release_id"`
+	ToAssetID     string    `json:"to_asset_id,omitempty"` // 为空表示版本主文件
+	Format        string    `json:"format"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	FilePath      string    `json:"file_path,omitempty"`
+	FileSize      int64     `json:"file_size"`
+	TargetHash    string    `json:"target_hash,omitempty"` // 应用补丁后文件的 SHA-256
+	TargetSize    int64     `json:"target_size"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PatchUsecase 差分补丁业务逻辑接口
+type PatchUsecase interface {
+	// 为目标版本生成来自最近若干旧版本的补丁，耗时较长，应在后台执行
+	GeneratePatches(ctx context.Context, toReleaseID string) error
+	// 查找可用的补丁，toAssetID 为空表示版本主文件
+	FindPatch(ctx context.Context, fromReleaseID, toReleaseID, toAssetID string) (*ReleasePatch, error)
+	GetPatchByID(ctx context.Context, id string) (*ReleasePatch, error)
+}
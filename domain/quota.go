@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrQuotaExceeded 上传后将超出租户存储总量或包的版本数上限
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrFileTooLarge 单个文件超过租户的文件大小上限
+	ErrFileTooLarge = errors.New("file too large")
+)
+
+// TenantQuota 租户配额，各项为 0 表示不限制
+type TenantQuota struct {
+	QuotaBytes            int64 `json:"quota_bytes"`              // 存储总量上限（字节）
+	MaxFileSize           int64 `json:"max_file_size"`            // 单个文件大小上限（字节）
+	MaxReleasesPerPackage int   `json:"max_releases_per_package"` // 每个包的版本数上限
+}
+
+// PackageUsage 包的存储用量
+type PackageUsage struct {
+	PackageID   string `json:"package_id"`
+	PackageName string `json:"package_name"`
+	Releases    int    `json:"releases"`
+	Bytes       int64  `json:"bytes"`
+}
+
+// ProjectUsage 项目的存储用量
+type ProjectUsage struct {
+	ProjectID   string         `json:"project_id"`
+	ProjectName string         `json:"project_name"`
+	Releases    int            `json:"releases"`
+	Bytes       int64          `json:"bytes"`
+	Packages    []PackageUsage `json:"packages,omitempty"`
+}
+
+// TenantUsage 租户的存储用量，统计版本主文件、构件和差分补丁，同一对象只计一次
+type TenantUsage struct {
+	TenantID     string         `json:"tenant_id"`
+	TenantName   string         `json:"tenant_name"`
+	Quota        TenantQuota    `json:"quota"`
+	UsedBytes    int64          `json:"used_bytes"`
+	Releases     int            `json:"releases"`
+	UsagePercent float64        `json:"usage_percent"` // 未设置存储总量上限时为 0
+	NearLimit    bool           `json:"near_limit"`    // 用量达到 QUOTA_WARNING_PERCENT
+	Projects     []ProjectUsage `json:"projects,omitempty"`
+}
+
+type QuotaRepository interface {
+	GetQuota(c context.Context, tenantID string) (*TenantQuota, error)
+	UpdateQuota(c context.Context, tenantID string, quota *TenantQuota) error
+	GetTenantUsage(c context.Context, tenantID string) (*TenantUsage, error)
+	ListTenantIDs(c context.Context) ([]string, error)
+	GetPackageTenantID(c context.Context, packageID string) (string, error)
+	CountReleases(c context.Context, packageID string) (int, error)
+}
+
+type QuotaUsecase interface {
+	// CheckUpload 上传前检查包所属租户的配额，newRelease 为 false 表示向已有版本追加构件
+	CheckUpload(c context.Context, packageID string, fileSize int64, newRelease bool) error
+	GetQuota(c context.Context, tenantID string) (*TenantQuota, error)
+	UpdateQuota(c context.Context, tenantID string, quota *TenantQuota) error
+	GetUsage(c context.Context, tenantID string) (*TenantUsage, error)
+	// ListUsage 所有租户的用量汇总（不含明细），按用量百分比从高到低排序
+	ListUsage(c context.Context) ([]*TenantUsage, error)
+}
@@ -2,27 +2,152 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
+
+	"pkms/internal/versioning"
+	"pkms/pkg"
 )
 
+// ErrReleaseExists 同一软件包下已有相同版本（并发上传同一新版本时由唯一约束保证）
+var ErrReleaseExists = errors.New("release already exists")
+
 // Release represents a package release/version - 发布版本
 type Release struct {
-	ID            string    `json:"id"`
-	PackageID     string    `json:"package_id"`
-	VersionCode   string    `json:"version_code"`
-	TagName       string    `json:"tag_name,omitempty"`
-	VersionName   string    `json:"version_name,omitempty"`
-	ChangeLog     string    `json:"changelog,omitempty"` // Release notes/changelog
-	FilePath      string    `json:"file_path"`
-	FileName      string    `json:"file_name"`
-	FileSize      int64     `json:"file_size"`
-	FileHash      string    `json:"file_hash,omitempty"`
-	DownloadCount int       `json:"download_count"`
-	ShareToken    string    `json:"share_token,omitempty"`
-	ShareExpiry   time.Time `json:"share_expiry,omitempty"`
-	CreatedBy     string    `json:"created_by"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID          string `json:"id"`
+	PackageID   string `json:"package_id"`
+	VersionCode string `json:"version_code"`
+	TagName     string `json:"tag_name,omitempty"`
+	VersionName string `json:"version_name,omitempty"`
+	ChangeLog   string `json:"changelog,omitempty"` // Release notes/changelog
+	FilePath    string `json:"file_path"`
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size"`
+	FileHash    string `json:"file_hash,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`
+	SHA512      string `json:"sha512,omitempty"`
+	// 分离签名（Ed25519ph，对 SHA-512 摘要签名）
+	Signature      string    `json:"signature,omitempty"`
+	SignatureKeyID string    `json:"signature_key_id,omitempty"`
+	DownloadCount  int       `json:"download_count"`
+	ShareToken     string    `json:"share_token,omitempty"`
+	ShareExpiry    time.Time `json:"share_expiry,omitempty"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// 发布状态：草稿 -> 已发布；预发布版本不参与最新稳定版计算
+	IsDraft      bool       `json:"is_draft"`
+	IsPrerelease bool       `json:"is_prerelease"`
+	IsLatest     bool       `json:"is_latest"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+
+	// 存储巡检结果：ok/missing/corrupt，空表示尚未检查
+	IntegrityStatus    string     `json:"integrity_status,omitempty"`
+	IntegrityCheckedAt *time.Time `json:"integrity_checked_at,omitempty"`
+
+	// 多平台构件（按 os/arch/kind 区分）
+	Assets []*ReleaseAsset `json:"assets,omitempty"`
+}
+
+// Version 用于比较的版本号：优先 version_code，为空时（如 GoReleaser 上传的版本）依次使用 tag_name、version_name
+func (r *Release) Version() string {
+	return ReleaseVersion(r.VersionCode, r.TagName, r.VersionName)
+}
+
+// ReleaseVersion 返回第一个非空的版本号
+func ReleaseVersion(versionCode, tagName, versionName string) string {
+	for _, v := range []string{versionCode, tagName, versionName} {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// IsStable 已发布且非预发布的版本；版本号本身带预发布标识（如 1.0.0-beta.1）时也视为预发布
+func (r *Release) IsStable() bool {
+	return !r.IsDraft && !r.IsPrerelease && !versioning.IsPrerelease(r.Version())
+}
+
+// ResolveLatestStable 计算包的最新稳定版本：优先使用手动标记为最新的已发布版本，否则取版本号（见 Version）最大的稳定版本
+func ResolveLatestStable(releases []*Release, scheme string) *Release {
+	var latest *Release
+	for _, r := range releases {
+		if r.IsDraft {
+			continue
+		}
+		if r.IsLatest {
+			return r
+		}
+		if !r.IsStable() {
+			continue
+		}
+		if latest == nil {
+			latest = r
+			continue
+		}
+		if cmp := versioning.Compare(scheme, r.Version(), latest.Version()); cmp > 0 ||
+			(cmp == 0 && r.CreatedAt.After(latest.CreatedAt)) {
+			latest = r
+		}
+	}
+	return latest
+}
+
+// ReleaseAsset 发布版本下某个平台的构件文件
+type ReleaseAsset struct {
+	ID        string `json:"id"`
+	ReleaseID string `json:"release_id"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	Kind      string `json:"kind"`
+	Artifact  string `json:"artifact,omitempty"`
+	FilePath  string `json:"file_path"`
+	FileName  string `json:"file_name"`
+	FileSize  int64  `json:"file_size"`
+	FileHash  string `json:"file_hash,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	SHA512    string `json:"sha512,omitempty"`
+	// 分离签名（Ed25519ph，对 SHA-512 摘要签名）
+	Signature      string    `json:"signature,omitempty"`
+	SignatureKeyID string    `json:"signature_key_id,omitempty"`
+	DownloadCount  int       `json:"download_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// MatchAsset 根据客户端平台选择最合适的构件，没有匹配时返回 nil
+// 优先级：os+arch 完全匹配 > os 匹配且构件不限架构 > 不限平台的构件
+func (r *Release) MatchAsset(osName, arch, kind string) *ReleaseAsset {
+	if len(r.Assets) == 0 || osName == "" {
+		return nil
+	}
+	osName = pkg.NormalizeOS(osName)
+	arch = pkg.NormalizeArch(arch)
+
+	var osOnly, universal *ReleaseAsset
+	for _, asset := range r.Assets {
+		// 未指定类型时忽略校验和等辅助文件
+		if (kind != "" && asset.Kind != kind) || (kind == "" && asset.Kind == "checksum") {
+			continue
+		}
+		assetOS := pkg.NormalizeOS(asset.OS)
+		assetArch := pkg.NormalizeArch(asset.Arch)
+		switch {
+		case assetOS == osName && assetArch == arch && arch != "":
+			return asset
+		case assetOS == osName && (assetArch == "" || pkg.IsUniversalArch(assetArch)):
+			if osOnly == nil {
+				osOnly = asset
+			}
+		case assetOS == "" && universal == nil:
+			universal = asset
+		}
+	}
+	if osOnly != nil {
+		return osOnly
+	}
+	return universal
 }
 
 // ReleaseUploadRequest 上传包文件创建发布版本的请求
@@ -62,14 +187,46 @@ type CreateReleaseRequest struct {
 
 // ReleaseRepository interface for release management
 type ReleaseRepository interface {
+	// 保存版本，同一软件包下已有相同版本时返回 ErrReleaseExists
 	Create(c context.Context, release *Release) error
 	GetByID(c context.Context, id string) (*Release, error)
 	GetByPackageID(c context.Context, packageID string) ([]*Release, error)
+	// 获取手动标记为最新的已发布版本
 	GetLatestByPackageID(c context.Context, packageID string) (*Release, error)
+	// 将版本标记为包的最新版本，同时清除其他版本的标记
+	SetAsLatest(c context.Context, packageID, releaseID string) error
+	// 发布草稿版本
+	Publish(c context.Context, id string) error
 	GetByShareToken(c context.Context, token string) (*Release, error)
 	Delete(c context.Context, id string) error
 	IncrementDownloadCount(c context.Context, id string) error
 	GetTotalDownloadsByTenant(c context.Context, tenantID string) (int, error)
+	// 构件管理
+	CreateAsset(c context.Context, asset *ReleaseAsset) error
+	GetAssetsByReleaseID(c context.Context, releaseID string) ([]*ReleaseAsset, error)
+	DeleteAsset(c context.Context, id string) error
+	// 差分补丁管理
+	CreatePatch(c context.Context, patch *ReleasePatch) error
+	UpdatePatch(c context.Context, id string, updates map[string]interface{}) error
+	GetPatchByID(c context.Context, id string) (*ReleasePatch, error)
+	GetPatch(c context.Context, fromReleaseID, toReleaseID, toAssetID string) (*ReleasePatch, error)
+	GetPatchesByReleaseID(c context.Context, releaseID string) ([]*ReleasePatch, error)
+	// 列出版本、构件与就绪补丁引用的全部存储对象（按路径去重排序），用于存储迁移与巡检
+	ListStorageObjects(c context.Context) ([]StorageObject, error)
+	// 记录巡检结果：statuses 中的版本设置为对应状态，其余版本设置为 ok
+	UpdateIntegrity(c context.Context, statuses map[string]string, checkedAt time.Time) error
+	// 将版本、构件和补丁中引用 oldPath 的记录改为引用 newPath，用于内容寻址迁移
+	ReplaceFilePath(c context.Context, oldPath, newPath string) error
+}
+
+// StorageObject 被版本、构件或补丁引用的存储对象，多处引用同一路径时记录第一个引用
+type StorageObject struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256,omitempty"` // 上传时记录的摘要，旧数据可能为空
+	ReleaseID string `json:"release_id,omitempty"`
+	AssetID   string `json:"asset_id,omitempty"`
+	PatchID   string `json:"patch_id,omitempty"`
 }
 
 // ReleaseUsecase interface for release business logic
@@ -77,7 +234,13 @@ type ReleaseUsecase interface {
 	CreateRelease(c context.Context, release *Release) error
 	GetReleaseByID(c context.Context, id string) (*Release, error)
 	GetReleasesByPackage(c context.Context, packageID string) ([]*Release, error)
+	// 最新稳定版本，草稿和预发布版本除外
 	GetLatestRelease(c context.Context, packageID string) (*Release, error)
+	// 发布草稿版本，发布后才对分享、下载和升级可见
+	PublishRelease(c context.Context, id string) (*Release, error)
+	// 手动将已发布版本标记为最新版本
+	SetLatestRelease(c context.Context, id string) (*Release, error)
 	DeleteRelease(c context.Context, id string) error
 	IncrementDownloadCount(c context.Context, releaseID string) error
+	AddAsset(c context.Context, asset *ReleaseAsset) error
 }
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 版本被保留策略选中删除的原因
+const (
+	RetentionReasonKeepLast      = "keep_last"
+	RetentionReasonPrereleaseAge = "prerelease_age"
+)
+
+// RetentionPolicy 版本保留策略，PackageID 为空表示项目级策略；包级策略优先于项目级策略
+// 最新版本、升级任务（包括已停用的）或升级上报引用过的版本、有未过期分享的版本永远不会被删除
+type RetentionPolicy struct {
+	ID                   string    `json:"id"`
+	TenantID             string    `json:"tenant_id"`
+	ProjectID            string    `json:"project_id"`
+	PackageID            string    `json:"package_id,omitempty"`
+	KeepLast             int       `json:"keep_last"`               // 保留最新的 N 个版本，0 表示不限制
+	PrereleaseMaxAgeDays int       `json:"prerelease_max_age_days"` // 删除创建超过 N 天的预发布版本，0 表示不限制
+	Enabled              bool      `json:"enabled"`
+	CreatedBy            string    `json:"created_by,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// CreateRetentionPolicyRequest 创建保留策略请求
+type CreateRetentionPolicyRequest struct {
+	ProjectID            string `json:"project_id" binding:"required"`
+	PackageID            string `json:"package_id"`
+	KeepLast             int    `json:"keep_last" binding:"min=0"`
+	PrereleaseMaxAgeDays int    `json:"prerelease_max_age_days" binding:"min=0"`
+	Enabled              *bool  `json:"enabled,omitempty"` // 默认启用
+}
+
+// UpdateRetentionPolicyRequest 更新保留策略请求
+type UpdateRetentionPolicyRequest struct {
+	KeepLast             *int  `json:"keep_last,omitempty" binding:"omitempty,min=0"`
+	PrereleaseMaxAgeDays *int  `json:"prerelease_max_age_days,omitempty" binding:"omitempty,min=0"`
+	Enabled              *bool `json:"enabled,omitempty"`
+}
+
+// RetentionCandidate 按保留策略应删除的版本
+type RetentionCandidate struct {
+	PolicyID    string    `json:"policy_id"`
+	ProjectID   string    `json:"project_id"`
+	PackageID   string    `json:"package_id"`
+	PackageName string    `json:"package_name"`
+	ReleaseID   string    `json:"release_id"`
+	VersionCode string    `json:"version_code"`
+	VersionName string    `json:"version_name,omitempty"`
+	FileSize    int64     `json:"file_size"`
+	CreatedAt   time.Time `json:"created_at"`
+	Reason      string    `json:"reason"`
+}
+
+// RetentionResult 保留策略的评估（dry-run）或执行结果
+type RetentionResult struct {
+	DryRun     bool                 `json:"dry_run"`
+	Candidates []RetentionCandidate `json:"candidates"`
+	Deleted    int                  `json:"deleted"`
+	Failed     int                  `json:"failed"`
+	FreedBytes int64                `json:"freed_bytes"` // dry-run 时为预计释放的字节数
+}
+
+type RetentionPolicyRepository interface {
+	Create(c context.Context, policy *RetentionPolicy) error
+	GetByID(c context.Context, id string) (*RetentionPolicy, error)
+	// ListByTenant 列出租户的保留策略，projectID 不为空时只列出该项目的策略
+	ListByTenant(c context.Context, tenantID, projectID string) ([]*RetentionPolicy, error)
+	ListEnabled(c context.Context) ([]*RetentionPolicy, error)
+	Update(c context.Context, id string, updates map[string]interface{}) error
+	Delete(c context.Context, id string) error
+	// GetProtectedReleaseIDs 包内被升级任务或升级上报引用过、或有未过期分享的版本
+	GetProtectedReleaseIDs(c context.Context, packageID string, now time.Time) (map[string]bool, error)
+}
+
+type RetentionUsecase interface {
+	CreatePolicy(c context.Context, tenantID, createdBy string, request *CreateRetentionPolicyRequest) (*RetentionPolicy, error)
+	ListPolicies(c context.Context, tenantID, projectID string) ([]*RetentionPolicy, error)
+	UpdatePolicy(c context.Context, tenantID, id string, request *UpdateRetentionPolicyRequest) (*RetentionPolicy, error)
+	DeletePolicy(c context.Context, tenantID, id string) error
+	// Evaluate 按租户的已启用策略计算待删除版本，dryRun 为 false 时通过 ReleaseUsecase.DeleteRelease 删除
+	Evaluate(c context.Context, tenantID, projectID string, dryRun bool) (*RetentionResult, error)
+	// ApplyAll 对所有租户执行保留策略（定时任务）
+	ApplyAll(c context.Context) (*RetentionResult, error)
+}
@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultTimezone 租户未设置时区时使用 UTC
+const DefaultTimezone = "UTC"
+
+// MaintenanceWindow 周期性维护窗口，只有在窗口内检查更新才会下发升级
+// Days 为星期几（0=周日 ... 6=周六），为空表示每天；Start/End 为租户时区的 HH:MM，End 不晚于 Start 时窗口跨越午夜
+type MaintenanceWindow struct {
+	Days  []int  `json:"days,omitempty"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Validate 校验维护窗口
+func (w *MaintenanceWindow) Validate() error {
+	for _, day := range w.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("维护窗口的星期必须在 0-6 之间: %d", day)
+		}
+	}
+	if _, err := parseClock(w.Start); err != nil {
+		return fmt.Errorf("维护窗口开始时间格式错误，应为 HH:MM: %s", w.Start)
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return fmt.Errorf("维护窗口结束时间格式错误，应为 HH:MM: %s", w.End)
+	}
+	return nil
+}
+
+// occurrence 返回窗口在 date 所在日期的起止时间，当天不在 Days 中时返回 false
+func (w *MaintenanceWindow) occurrence(date time.Time) (time.Time, time.Time, bool) {
+	if len(w.Days) > 0 {
+		matched := false
+		for _, day := range w.Days {
+			if time.Weekday(day) == date.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return time.Time{}, time.Time{}, false
+		}
+	}
+
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	y, m, d := date.Date()
+	startAt := time.Date(y, m, d, 0, start, 0, 0, date.Location())
+	endAt := time.Date(y, m, d, 0, end, 0, 0, date.Location())
+	if end <= start {
+		endAt = endAt.AddDate(0, 0, 1)
+	}
+	return startAt, endAt, true
+}
+
+// ValidateSchedule 校验生效时间段与维护窗口
+func ValidateSchedule(startsAt, endsAt *time.Time, windows []MaintenanceWindow) error {
+	if startsAt != nil && endsAt != nil && !startsAt.Before(*endsAt) {
+		return errors.New("生效时间必须早于失效时间")
+	}
+	for i := range windows {
+		if err := windows[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTimezone 加载租户时区，无效或为空时使用 UTC
+func LoadTimezone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// NormalizeTimezone 空时区视为 UTC
+func NormalizeTimezone(name string) string {
+	if name == "" {
+		return DefaultTimezone
+	}
+	return name
+}
+
+// IsValidTimezone 校验 IANA 时区名称
+func IsValidTimezone(name string) bool {
+	_, err := time.LoadLocation(name)
+	return name != "" && err == nil
+}
+
+// InSchedule 当前时间是否处于升级目标的生效时间段内（不考虑维护窗口）
+func (t *UpgradeTarget) InSchedule(now time.Time) bool {
+	if t.StartsAt != nil && now.Before(*t.StartsAt) {
+		return false
+	}
+	if t.EndsAt != nil && !now.Before(*t.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// NextAvailableAt 计算升级目标可下发更新的时间
+// 当前可下发时返回 now；之后不会再有可下发的时间（已失效）时返回 nil
+func (t *UpgradeTarget) NextAvailableAt(now time.Time) *time.Time {
+	if t.EndsAt != nil && !now.Before(*t.EndsAt) {
+		return nil
+	}
+	from := now
+	if t.StartsAt != nil && now.Before(*t.StartsAt) {
+		from = *t.StartsAt
+	}
+
+	next := t.nextWindowAt(from)
+	if next == nil || (t.EndsAt != nil && !next.Before(*t.EndsAt)) {
+		return nil
+	}
+	return next
+}
+
+// nextWindowAt 返回 from 之后（含）最早处于维护窗口内的时刻，没有维护窗口时即为 from
+func (t *UpgradeTarget) nextWindowAt(from time.Time) *time.Time {
+	if len(t.MaintenanceWindows) == 0 {
+		return &from
+	}
+
+	local := from.In(LoadTimezone(t.Timezone))
+	var earliest *time.Time
+	// 从前一天开始检查，覆盖跨越午夜的窗口；一周之内必然出现所有窗口
+	for offset := -1; offset <= 7; offset++ {
+		date := local.AddDate(0, 0, offset)
+		for i := range t.MaintenanceWindows {
+			start, end, ok := t.MaintenanceWindows[i].occurrence(date)
+			if !ok || !end.After(from) {
+				continue
+			}
+			candidate := start
+			if start.Before(from) {
+				candidate = from
+			}
+			if earliest == nil || candidate.Before(*earliest) {
+				c := candidate
+				earliest = &c
+			}
+		}
+	}
+	return earliest
+}
+
+// ScheduleOverlaps 两个升级目标的生效时间段是否重叠
+func (t *UpgradeTarget) ScheduleOverlaps(o *UpgradeTarget) bool {
+	if t.EndsAt != nil && o.StartsAt != nil && !o.StartsAt.Before(*t.EndsAt) {
+		return false
+	}
+	if o.EndsAt != nil && t.StartsAt != nil && !t.StartsAt.Before(*o.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(s string) (int, error) {
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
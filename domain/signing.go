@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrActiveSigningKeyExists 租户已有 active 密钥（并发生成或轮换时由唯一约束保证）
+var ErrActiveSigningKeyExists = errors.New("active signing key already exists")
+
+// 签名密钥状态
+const (
+	SigningKeyStatusActive  = "active"
+	SigningKeyStatusRetired = "retired"
+)
+
+// SignatureAlgorithmEd25519 发布签名算法
+// 构件签名为 Ed25519ph（对文件 SHA-512 摘要签名），清单签名为 Ed25519（对 payload 原文签名）
+const SignatureAlgorithmEd25519 = "ed25519"
+
+// SigningKey 租户的签名密钥，每个租户同一时间只有一个 active 密钥
+type SigningKey struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	KeyID      string     `json:"key_id"`
+	Algorithm  string     `json:"algorithm"`
+	PublicKey  string     `json:"public_key"` // Base64 编码的 32 字节公钥
+	PrivateKey string     `json:"-"`          // 加密后的私钥种子，只在服务端使用
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
+
+// ArtifactSignature 构件的分离签名
+type ArtifactSignature struct {
+	KeyID     string `json:"key_id"`
+	Signature string `json:"signature"` // Base64
+}
+
+// ManifestSignature 检查更新响应的签名
+// Payload 为响应（不含签名字段）的 JSON 原文的 Base64 编码，客户端验签通过后应以解码后的 Payload 为准
+type ManifestSignature struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+// SigningKeyRepository 签名密钥数据仓库接口
+type SigningKeyRepository interface {
+	// 保存为 active 密钥，租户已有 active 密钥时返回 ErrActiveSigningKeyExists
+	Create(ctx context.Context, key *SigningKey) error
+	// 获取租户当前的 active 密钥，没有时返回 nil
+	GetActiveByTenant(ctx context.Context, tenantID string) (*SigningKey, error)
+	// 列出租户的所有密钥（含已轮换的），按创建时间倒序
+	ListByTenant(ctx context.Context, tenantID string) ([]*SigningKey, error)
+	// 在同一事务中停用租户的 active 密钥并保存新密钥，并发轮换冲突时返回 ErrActiveSigningKeyExists
+	Rotate(ctx context.Context, key *SigningKey, retiredAt time.Time) error
+}
+
+// SigningUsecase 签名业务逻辑接口，租户首次签名时自动生成密钥
+type SigningUsecase interface {
+	ListKeys(ctx context.Context, tenantID string) ([]*SigningKey, error)
+	// 生成新的 active 密钥，旧密钥转为 retired，仍可用于校验历史签名
+	RotateKey(ctx context.Context, tenantID string) (*SigningKey, error)
+	// 对构件的 SHA-512 摘要（十六进制）签名
+	SignArtifact(ctx context.Context, tenantID, sha512Hex string) (*ArtifactSignature, error)
+	// 对任意可 JSON 序列化的清单签名
+	SignManifest(ctx context.Context, tenantID string, manifest interface{}) (*ManifestSignature, error)
+	// PEM 格式的公钥集合（含已轮换的密钥）
+	PublicKeysPEM(ctx context.Context, tenantID string) ([]byte, error)
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 存储类型
+const (
+	StorageTypeDisk  = "disk"
+	StorageTypeMinio = "minio"
+)
+
+// 存储迁移任务状态
+const (
+	StorageMigrationRunning     = "running"
+	StorageMigrationCompleted   = "completed"
+	StorageMigrationFailed      = "failed"      // 存在迁移失败的对象，可重新执行以重试
+	StorageMigrationInterrupted = "interrupted" // 进程退出或任务出错中断，可继续
+)
+
+// StorageMigration 存储后端迁移任务
+type StorageMigration struct {
+	ID         string     `json:"id"`
+	Source     string     `json:"source"`
+	Target     string     `json:"target"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Copied     int        `json:"copied"`
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	Bytes      int64      `json:"bytes"`
+	Cursor     string     `json:"cursor,omitempty"`
+	Failures   []string   `json:"failures,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// StartStorageMigrationRequest 发起存储迁移请求
+type StartStorageMigrationRequest struct {
+	Target string `json:"target" binding:"required,oneof=disk minio"`
+}
+
+// StorageMigrationRepository 存储迁移数据仓库接口
+type StorageMigrationRepository interface {
+	Create(ctx context.Context, migration *StorageMigration) error
+	GetByID(ctx context.Context, id string) (*StorageMigration, error)
+	List(ctx context.Context, limit int) ([]*StorageMigration, error)
+	Update(ctx context.Context, id string, updates map[string]interface{}) error
+	// 获取相同源/目标下最近一次未完成的任务，没有时返回 nil
+	GetResumable(ctx context.Context, source, target string) (*StorageMigration, error)
+	// 将上次进程退出时仍在运行的任务标记为中断
+	MarkRunningInterrupted(ctx context.Context) error
+}
+
+// StorageMigrationUsecase 存储迁移业务逻辑接口
+type StorageMigrationUsecase interface {
+	// 创建或继续迁移任务，Run 执行实际复制
+	Prepare(ctx context.Context, target, createdBy string) (*StorageMigration, error)
+	// 同步执行迁移，已处理的对象会被跳过；同一时间只允许一个任务运行
+	Run(ctx context.Context, migration *StorageMigration) error
+	GetMigration(ctx context.Context, id string) (*StorageMigration, error)
+	ListMigrations(ctx context.Context) ([]*StorageMigration, error)
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 存储巡检任务状态
+const (
+	StorageScanRunning   = "running"
+	StorageScanCompleted = "completed"
+	StorageScanFailed    = "failed"
+)
+
+// 巡检触发方式
+const (
+	StorageScanTriggerManual    = "manual"
+	StorageScanTriggerScheduled = "scheduled"
+)
+
+// 巡检问题类型
+const (
+	StorageIssueOrphan       = "orphan"        // 存储中存在但没有被任何版本、构件或补丁引用
+	StorageIssueMissing      = "missing"       // 被引用但存储中不存在
+	StorageIssueSizeMismatch = "size_mismatch" // 大小与记录不一致
+	StorageIssueHashMismatch = "hash_mismatch" // SHA-256 与记录不一致
+)
+
+// 版本文件完整性状态
+const (
+	IntegrityStatusOK      = "ok"
+	IntegrityStatusMissing = "missing"
+	IntegrityStatusCorrupt = "corrupt"
+)
+
+// StorageIssue 巡检发现的问题
+type StorageIssue struct {
+	Type         string     `json:"type"`
+	Path         string     `json:"path"`
+	Size         int64      `json:"size,omitempty"`
+	ReleaseID    string     `json:"release_id,omitempty"`
+	AssetID      string     `json:"asset_id,omitempty"`
+	PatchID      string     `json:"patch_id,omitempty"`
+	Expected     string     `json:"expected,omitempty"`
+	Actual       string     `json:"actual,omitempty"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	Deleted      bool       `json:"deleted,omitempty"` // 孤立对象已被删除
+}
+
+// StorageScan 存储巡检记录
+type StorageScan struct {
+	ID                string         `json:"id"`
+	Status            string         `json:"status"`
+	Trigger           string         `json:"trigger"`
+	DeleteOrphans     bool           `json:"delete_orphans"`
+	VerifyHashes      bool           `json:"verify_hashes"`
+	TotalObjects      int            `json:"total_objects"`
+	ReferencedObjects int            `json:"referenced_objects"`
+	OrphanCount       int            `json:"orphan_count"`
+	OrphanBytes       int64          `json:"orphan_bytes"`
+	DeletedCount      int            `json:"deleted_count"`
+	MissingCount      int            `json:"missing_count"`
+	CorruptCount      int            `json:"corrupt_count"`
+	Issues            []StorageIssue `json:"issues,omitempty"`
+	Error             string         `json:"error,omitempty"`
+	CreatedBy         string         `json:"created_by,omitempty"`
+	StartedAt         time.Time      `json:"started_at"`
+	FinishedAt        *time.Time     `json:"finished_at,omitempty"`
+}
+
+// StartStorageScanRequest 发起存储巡检请求
+type StartStorageScanRequest struct {
+	DeleteOrphans bool `json:"delete_orphans"` // 删除超过宽限期的孤立对象，否则只报告
+	VerifyHashes  bool `json:"verify_hashes"`  // 读取对象校验 SHA-256，耗时与存储总量成正比
+}
+
+// StorageScanRepository 存储巡检数据仓库接口
+type StorageScanRepository interface {
+	Create(ctx context.Context, scan *StorageScan) error
+	GetByID(ctx context.Context, id string) (*StorageScan, error)
+	List(ctx context.Context, limit int) ([]*StorageScan, error)
+	// 保存巡检结果
+	Save(ctx context.Context, scan *StorageScan) error
+	// 将上次进程退出时仍在运行的巡检标记为失败
+	MarkRunningFailed(ctx context.Context) error
+}
+
+// StorageScanUsecase 孤立对象回收与完整性巡检业务逻辑接口
+type StorageScanUsecase interface {
+	// 创建巡检记录并在后台执行；同一时间只允许一个巡检运行
+	StartScan(ctx context.Context, request *StartStorageScanRequest, trigger, createdBy string) (*StorageScan, error)
+	// 同步执行巡检
+	RunScan(ctx context.Context, scan *StorageScan) error
+	GetScan(ctx context.Context, id string) (*StorageScan, error)
+	ListScans(ctx context.Context) ([]*StorageScan, error)
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"pkms/internal/versioning"
+)
+
+// ErrNoMatchingUpgradeTarget 渠道内没有命中客户端的激活升级目标
+var ErrNoMatchingUpgradeTarget = errors.New("no matching upgrade target")
+
+// TargetingRules 升级目标的定向规则
+// 所有非空条件都满足时客户端才命中该目标，规则为空表示面向渠道内所有客户端
+type TargetingRules struct {
+	OS           []string `json:"os,omitempty"`             // 操作系统，如 windows/linux/darwin
+	OSVersionMin string   `json:"os_version_min,omitempty"` // 操作系统最低版本（含）
+	OSVersionMax string   `json:"os_version_max,omitempty"` // 操作系统最高版本（含）
+	Regions      []string `json:"regions,omitempty"`        // 地区标签
+	Models       []string `json:"models,omitempty"`         // 硬件型号
+	DeviceIDs    []string `json:"device_ids,omitempty"`     // 设备ID白名单
+}
+
+// TargetingAttributes 客户端检查更新时上报的、参与定向匹配的属性
+type TargetingAttributes struct {
+	DeviceID  string
+	OS        string
+	OSVersion string
+	Region    string
+	Model     string
+}
+
+// 各类条件的权重，命中多个目标时权重之和最大（最具体）的目标胜出
+const (
+	targetingWeightOS        = 1
+	targetingWeightOSVersion = 2
+	targetingWeightRegion    = 4
+	targetingWeightModel     = 8
+	targetingWeightDeviceIDs = 16
+)
+
+// Normalize 去除空白与空值，OS 统一为规范名称
+func (r *TargetingRules) Normalize() {
+	r.OS = normalizeRuleValues(r.OS)
+	for i, osName := range r.OS {
+		r.OS[i] = strings.ToLower(osName)
+	}
+	r.OSVersionMin = strings.TrimSpace(r.OSVersionMin)
+	r.OSVersionMax = strings.TrimSpace(r.OSVersionMax)
+	r.Regions = normalizeRuleValues(r.Regions)
+	r.Models = normalizeRuleValues(r.Models)
+	r.DeviceIDs = normalizeRuleValues(r.DeviceIDs)
+}
+
+// Validate 校验规则的合法性
+func (r *TargetingRules) Validate() error {
+	if r.OSVersionMin != "" && r.OSVersionMax != "" &&
+		versioning.Compare(versioning.SchemeAuto, r.OSVersionMin, r.OSVersionMax) > 0 {
+		return errors.New("定向规则中的系统最低版本不能高于最高版本")
+	}
+	return nil
+}
+
+// IsEmpty 规则是否为空
+func (r *TargetingRules) IsEmpty() bool {
+	return r == nil || r.Specificity() == 0
+}
+
+// Specificity 规则的具体程度，用于在多个命中的目标中选择
+func (r *TargetingRules) Specificity() int {
+	if r == nil {
+		return 0
+	}
+	score := 0
+	if len(r.OS) > 0 {
+		score += targetingWeightOS
+	}
+	if r.OSVersionMin != "" || r.OSVersionMax != "" {
+		score += targetingWeightOSVersion
+	}
+	if len(r.Regions) > 0 {
+		score += targetingWeightRegion
+	}
+	if len(r.Models) > 0 {
+		score += targetingWeightModel
+	}
+	if len(r.DeviceIDs) > 0 {
+		score += targetingWeightDeviceIDs
+	}
+	return score
+}
+
+// Matches 判断客户端属性是否满足规则，规则要求的属性客户端未上报时视为不匹配
+func (r *TargetingRules) Matches(attrs *TargetingAttributes) bool {
+	if r.IsEmpty() {
+		return true
+	}
+	if attrs == nil {
+		attrs = &TargetingAttributes{}
+	}
+
+	if len(r.OS) > 0 && !containsFold(r.OS, attrs.OS) {
+		return false
+	}
+	if r.OSVersionMin != "" || r.OSVersionMax != "" {
+		if attrs.OSVersion == "" {
+			return false
+		}
+		if r.OSVersionMin != "" && versioning.Compare(versioning.SchemeAuto, attrs.OSVersion, r.OSVersionMin) < 0 {
+			return false
+		}
+		if r.OSVersionMax != "" && versioning.Compare(versioning.SchemeAuto, attrs.OSVersion, r.OSVersionMax) > 0 {
+			return false
+		}
+	}
+	if len(r.Regions) > 0 && !containsFold(r.Regions, attrs.Region) {
+		return false
+	}
+	if len(r.Models) > 0 && !containsFold(r.Models, attrs.Model) {
+		return false
+	}
+	if len(r.DeviceIDs) > 0 && !contains(r.DeviceIDs, attrs.DeviceID) {
+		return false
+	}
+	return true
+}
+
+// Equal 判断两组规则是否等价（忽略值的顺序）
+func (r *TargetingRules) Equal(o *TargetingRules) bool {
+	if r.IsEmpty() || o.IsEmpty() {
+		return r.IsEmpty() && o.IsEmpty()
+	}
+	return r.OSVersionMin == o.OSVersionMin &&
+		r.OSVersionMax == o.OSVersionMax &&
+		sameValues(r.OS, o.OS) &&
+		sameValues(r.Regions, o.Regions) &&
+		sameValues(r.Models, o.Models) &&
+		sameValues(r.DeviceIDs, o.DeviceIDs)
+}
+
+// SelectUpgradeTarget 从同一渠道的激活目标中选出处于生效时间段内、命中客户端且最具体的目标
+// targets 需按创建时间倒序排列，具体程度相同时较新的目标胜出；没有命中时返回 nil
+func SelectUpgradeTarget(targets []*UpgradeTarget, attrs *TargetingAttributes, now time.Time) *UpgradeTarget {
+	var selected *UpgradeTarget
+	best := -1
+	for _, target := range targets {
+		if !target.InSchedule(now) || !target.TargetingRules.Matches(attrs) {
+			continue
+		}
+		if score := target.TargetingRules.Specificity(); score > best {
+			selected = target
+			best = score
+		}
+	}
+	return selected
+}
+
+// TargetingAttributes 从检查更新请求中提取定向属性，地区未单独上报时使用 region 标签
+func (r *CheckUpdateRequest) TargetingAttributes() *TargetingAttributes {
+	attrs := &TargetingAttributes{
+		DeviceID: r.ClientID,
+		OS:       r.OS,
+	}
+	if r.Device != nil {
+		attrs.OSVersion = r.Device.OSVersion
+		attrs.Model = r.Device.Model
+		attrs.Region = r.Device.Region
+		if attrs.Region == "" {
+			attrs.Region = r.Device.Labels["region"]
+		}
+	}
+	return attrs
+}
+
+func normalizeRuleValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	if v == "" {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int, len(a))
+	for _, v := range a {
+		counts[v]++
+	}
+	for _, v := range b {
+		if counts[v] == 0 {
+			return false
+		}
+		counts[v]--
+	}
+	return true
+}
@@ -27,6 +27,7 @@ type UserUpdateRequest struct {
 type Tenant struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
+	Timezone  string    `json:"timezone,omitempty"` // IANA 时区名称，默认 UTC
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
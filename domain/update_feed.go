@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUpdateFeedNotFound 包不是 desktop 类型、渠道没有生效中的升级目标，或升级目标的版本中没有该平台的文件
+var ErrUpdateFeedNotFound = errors.New("update feed not found")
+
+// ElectronDefaultChannel electron-updater 的默认渠道名（latest.yml），对应 stable 渠道或凭证绑定的渠道
+const ElectronDefaultChannel = "latest"
+
+// UpdateFeedUsecase 根据 desktop 类型包当前生效的升级目标生成桌面应用自动更新框架使用的更新源
+type UpdateFeedUsecase interface {
+	// ElectronFeed 生成 electron-updater 的 {channel}.yml、{channel}-mac.yml、{channel}-linux[-{arch}].yml
+	ElectronFeed(c context.Context, access *ClientAccess, name string) ([]byte, error)
+	// GetElectronFile 获取 electron 更新源中引用的版本文件
+	GetElectronFile(c context.Context, access *ClientAccess, releaseID, fileName string) (*ReleaseAsset, error)
+	// SquirrelReleases 生成 Squirrel.Windows 的 RELEASES 文件，列出升级目标版本中的 .nupkg 文件
+	SquirrelReleases(c context.Context, access *ClientAccess) ([]byte, error)
+	// GetSquirrelFile 按文件名获取 RELEASES 中引用的 .nupkg 文件
+	GetSquirrelFile(c context.Context, access *ClientAccess, fileName string) (*ReleaseAsset, error)
+}
@@ -2,9 +2,47 @@ package domain
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"regexp"
 	"time"
 )
 
+// 预置的发布渠道，也允许自定义渠道名
+const (
+	ChannelStable  = "stable"
+	ChannelBeta    = "beta"
+	ChannelNightly = "nightly"
+)
+
+var channelNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,31}$`)
+
+// IsValidChannel 校验渠道名：小写字母、数字及 . _ -，最长 32 个字符
+func IsValidChannel(channel string) bool {
+	return channelNamePattern.MatchString(channel)
+}
+
+// NormalizeChannel 空渠道视为 stable
+func NormalizeChannel(channel string) string {
+	if channel == "" {
+		return ChannelStable
+	}
+	return channel
+}
+
+// 灰度发布状态
+const (
+	RolloutStatusInProgress = "in_progress" // 灰度进行中，按比例放量
+	RolloutStatusPaused     = "paused"      // 已暂停，不再向客户端下发
+	RolloutStatusCompleted  = "completed"   // 已全量
+)
+
+// RolloutStep 灰度放量计划的一个阶段：到达 At 时间后比例提升到 Percentage
+type RolloutStep struct {
+	At         time.Time `json:"at"`
+	Percentage int       `json:"percentage"`
+}
+
 // UpgradeTarget 升级目标实体
 type UpgradeTarget struct {
 	ID          string    `json:"id"`
@@ -12,22 +50,93 @@ type UpgradeTarget struct {
 	ProjectID   string    `json:"project_id"`
 	PackageID   string    `json:"package_id"`
 	ReleaseID   string    `json:"release_id"`
+	Channel     string    `json:"channel"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	IsActive    bool      `json:"is_active"`
+	IsRollback  bool      `json:"is_rollback"` // 回滚目标：允许向更高版本的客户端下发降级
 	CreatedBy   string    `json:"created_by"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// 强制更新
+	IsMandatory         bool   `json:"is_mandatory"`
+	MinSupportedVersion string `json:"min_supported_version,omitempty"`
+
+	// 灰度发布
+	RolloutPercentage int           `json:"rollout_percentage"`
+	RolloutSchedule   []RolloutStep `json:"rollout_schedule,omitempty"`
+	RolloutStatus     string        `json:"rollout_status"`
+	PauseReason       string        `json:"pause_reason,omitempty"`
+
+	// 定向规则，为空表示面向渠道内所有客户端
+	TargetingRules *TargetingRules `json:"targeting_rules,omitempty"`
+
+	// 生效时间段与周期性维护窗口（按租户时区计算）
+	StartsAt           *time.Time          `json:"starts_at,omitempty"`
+	EndsAt             *time.Time          `json:"ends_at,omitempty"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows,omitempty"`
+
+	// 最近一次渠道晋级记录
+	PromotedBy   string     `json:"promoted_by,omitempty"`
+	PromotedAt   *time.Time `json:"promoted_at,omitempty"`
+	PromotedFrom string     `json:"promoted_from,omitempty"`
+
 	// 关联信息（从其他表查询获得）
-	ProjectName string `json:"project_name,omitempty"`
-	PackageName string `json:"package_name,omitempty"`
-	PackageType string `json:"package_type,omitempty"`
-	Version     string `json:"version,omitempty"`
-	FileName    string `json:"file_name,omitempty"`
-	FileSize    int64  `json:"file_size,omitempty"`
-	FileHash    string `json:"file_hash,omitempty"`
-	DownloadURL string `json:"download_url,omitempty"`
+	ProjectName    string `json:"project_name,omitempty"`
+	PackageName    string `json:"package_name,omitempty"`
+	PackageType    string `json:"package_type,omitempty"`
+	VersionScheme  string `json:"version_scheme,omitempty"`
+	Timezone       string `json:"timezone,omitempty"`
+	Version        string `json:"version,omitempty"`
+	FileName       string `json:"file_name,omitempty"`
+	FileSize       int64  `json:"file_size,omitempty"`
+	FileHash       string `json:"file_hash,omitempty"`
+	SHA256         string `json:"sha256,omitempty"`
+	SHA512         string `json:"sha512,omitempty"`
+	Signature      string `json:"signature,omitempty"`
+	SignatureKeyID string `json:"signature_key_id,omitempty"`
+	DownloadURL    string `json:"download_url,omitempty"`
+}
+
+// EffectiveRolloutPercentage 计算指定时刻的实际放量比例（取配置比例与已到期计划阶段的最大值）
+func (t *UpgradeTarget) EffectiveRolloutPercentage(now time.Time) int {
+	switch t.RolloutStatus {
+	case RolloutStatusPaused:
+		return 0
+	case RolloutStatusCompleted:
+		return 100
+	}
+
+	percentage := t.RolloutPercentage
+	for _, step := range t.RolloutSchedule {
+		if !step.At.After(now) && step.Percentage > percentage {
+			percentage = step.Percentage
+		}
+	}
+	if percentage > 100 {
+		percentage = 100
+	}
+	return percentage
+}
+
+// InRollout 判断客户端是否命中灰度，同一客户端对同一目标的分桶结果始终一致
+// 未上报 client_id 的客户端只有在全量时才会收到更新
+func (t *UpgradeTarget) InRollout(clientID string, now time.Time) bool {
+	percentage := t.EffectiveRolloutPercentage(now)
+	if percentage >= 100 {
+		return true
+	}
+	if percentage <= 0 || clientID == "" {
+		return false
+	}
+	return RolloutBucket(t.ID, clientID) < percentage
+}
+
+// RolloutBucket 将客户端稳定地映射到 [0, 100) 的桶中；按目标ID加盐，避免每次灰度都是同一批客户端
+func RolloutBucket(targetID, clientID string) int {
+	sum := sha256.Sum256([]byte(targetID + ":" + clientID))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
 }
 
 // UpgradeTargetPagedResult 升级目标分页查询结果
@@ -38,8 +147,22 @@ type CreateUpgradeTargetRequest struct {
 	ProjectID   string `json:"project_id" binding:"required"`
 	PackageID   string `json:"package_id" binding:"required"`
 	ReleaseID   string `json:"release_id" binding:"required"`
+	Channel     string `json:"channel"` // 发布渠道，默认 stable
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
+	IsRollback  bool   `json:"is_rollback"`
+	// 强制更新：所有客户端必须升级，或仅低于最低支持版本的客户端必须升级
+	IsMandatory         bool   `json:"is_mandatory"`
+	MinSupportedVersion string `json:"min_supported_version"`
+	// 灰度比例（0-100），为空表示全量
+	RolloutPercentage *int          `json:"rollout_percentage"`
+	RolloutSchedule   []RolloutStep `json:"rollout_schedule"`
+	// 定向规则，同一渠道可同时激活多个规则不同的目标
+	TargetingRules *TargetingRules `json:"targeting_rules"`
+	// 生效时间段与维护窗口，可提前创建升级目标
+	StartsAt           *time.Time          `json:"starts_at"`
+	EndsAt             *time.Time          `json:"ends_at"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows"`
 }
 
 // UpdateUpgradeTargetRequest 更新升级目标请求
@@ -47,12 +170,35 @@ type UpdateUpgradeTargetRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	IsActive    *bool  `json:"is_active"`
+	IsRollback  *bool  `json:"is_rollback"`
+	// 强制更新设置，MinSupportedVersion 传空字符串表示清除
+	IsMandatory         *bool   `json:"is_mandatory"`
+	MinSupportedVersion *string `json:"min_supported_version"`
+	// 调整灰度比例与放量计划
+	RolloutPercentage *int          `json:"rollout_percentage"`
+	RolloutSchedule   []RolloutStep `json:"rollout_schedule"`
+	// 替换定向规则，传空对象表示清除
+	TargetingRules *TargetingRules `json:"targeting_rules"`
+	// 生效时间段，传零值时间（0001-01-01T00:00:00Z）表示清除；维护窗口传空数组表示清除
+	StartsAt           *time.Time          `json:"starts_at"`
+	EndsAt             *time.Time          `json:"ends_at"`
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows"`
+}
+
+// PromoteUpgradeTargetRequest 渠道晋级请求，将升级目标的版本晋级到另一个渠道（如 beta -> stable）
+type PromoteUpgradeTargetRequest struct {
+	Channel string `json:"channel" binding:"required"`
 }
 
 // CheckUpdateRequest 检查更新请求
 type CheckUpdateRequest struct {
-	CurrentVersion string `json:"current_version" binding:"required"`
-	ClientInfo     string `json:"client_info,omitempty"` // 客户端信息，可选
+	CurrentVersion string            `json:"current_version" binding:"required"`
+	ClientInfo     string            `json:"client_info,omitempty"` // 客户端信息，可选
+	ClientID       string            `json:"client_id,omitempty"`   // 客户端稳定标识（设备ID），用于灰度分桶和设备登记
+	Channel        string            `json:"channel,omitempty"`     // 请求的发布渠道，凭证已绑定渠道时以绑定为准
+	OS             string            `json:"os,omitempty"`          // 客户端操作系统，用于选择对应平台的构件
+	Arch           string            `json:"arch,omitempty"`        // 客户端架构，用于选择对应平台的构件
+	Device         *DeviceAttributes `json:"device,omitempty"`      // 设备属性，随设备登记保存
 }
 
 // CheckUpdateResponse 检查更新响应
@@ -60,10 +206,30 @@ type CheckUpdateResponse struct {
 	HasUpdate      bool   `json:"has_update"`
 	CurrentVersion string `json:"current_version"`
 	LatestVersion  string `json:"latest_version"`
+	Channel        string `json:"channel,omitempty"`
 	DownloadURL    string `json:"download_url,omitempty"`
 	FileSize       int64  `json:"file_size,omitempty"`
 	FileHash       string `json:"file_hash,omitempty"`
+	SHA256         string `json:"sha256,omitempty"` // 安装包的 SHA-256 摘要，客户端应以此校验下载的文件
+	SHA512         string `json:"sha512,omitempty"`
+	FileSignature  string `json:"file_signature,omitempty"`   // 安装包的分离签名（Ed25519ph，对 SHA-512 摘要签名）
+	SignatureKeyID string `json:"signature_key_id,omitempty"` // 安装包签名使用的密钥
 	Changelog      string `json:"changelog,omitempty"`
+	FileName       string `json:"file_name,omitempty"`
+	IsRollback     bool   `json:"is_rollback,omitempty"` // 本次更新为回滚（降级）
+	ForceUpdate    bool   `json:"force_update"`          // 客户端必须更新后才能继续使用
+	MinVersion     string `json:"min_version,omitempty"` // 最低支持版本
+	// 当前不在生效时间或维护窗口内时，下一次可获取更新的时间，客户端可据此安排下次检查
+	NextWindowAt *time.Time `json:"next_window_at,omitempty"`
+
+	// 差分补丁：客户端当前版本存在可用补丁时下发，应用后文件的哈希为 PatchHash
+	PatchURL    string `json:"patch_url,omitempty"`
+	PatchSize   int64  `json:"patch_size,omitempty"`
+	PatchHash   string `json:"patch_hash,omitempty"`
+	PatchFormat string `json:"patch_format,omitempty"`
+
+	// 整个响应的签名，客户端验签后应以 Payload 中的内容为准
+	Signature *ManifestSignature `json:"signature,omitempty"`
 }
 
 // ClientAccess 客户端接入实体
@@ -72,6 +238,7 @@ type ClientAccess struct {
 	TenantID    string     `json:"tenant_id"`
 	ProjectID   string     `json:"project_id"`
 	PackageID   string     `json:"package_id"`
+	Channel     string     `json:"channel,omitempty"` // 绑定的发布渠道，为空表示由客户端指定
 	AccessToken string     `json:"access_token"`
 	Name        string     `json:"name"`
 	Description string     `json:"description,omitempty"`
@@ -94,6 +261,7 @@ type ClientAccess struct {
 type CreateClientAccessRequest struct {
 	ProjectID   string     `json:"project_id" binding:"required"`
 	PackageID   string     `json:"package_id" binding:"required"`
+	Channel     string     `json:"channel"`
 	Name        string     `json:"name" binding:"required"`
 	Description string     `json:"description"`
 	ExpiresAt   *time.Time `json:"expires_at"`
@@ -105,6 +273,7 @@ type UpdateClientAccessRequest struct {
 	Description string     `json:"description"`
 	IsActive    *bool      `json:"is_active"`
 	ExpiresAt   *time.Time `json:"expires_at"`
+	Channel     *string    `json:"channel"` // 传空字符串解除渠道绑定
 }
 
 // ClientAccessRepository 客户端接入数据仓库接口
@@ -142,8 +311,10 @@ type UpgradeRepository interface {
 	UpdateUpgradeTarget(ctx context.Context, id string, updates map[string]interface{}) error
 	// 删除升级目标
 	DeleteUpgradeTarget(ctx context.Context, id string) error
-	// 根据包ID获取激活的升级目标
-	GetActiveUpgradeTargetByPackageID(ctx context.Context, packageID string) (*UpgradeTarget, error)
+	// 根据包ID和渠道评估定向规则，返回命中客户端且最具体的激活升级目标
+	GetActiveUpgradeTargetByPackageID(ctx context.Context, packageID, channel string, attrs *TargetingAttributes) (*UpgradeTarget, error)
+	// 获取包在某个渠道下所有激活的升级目标，按创建时间倒序
+	GetActiveUpgradeTargetsByPackageID(ctx context.Context, packageID, channel string) ([]*UpgradeTarget, error)
 	// 检查项目下的包是否有升级目标
 	CheckProjectUpgradeTargets(ctx context.Context, projectID string, packageIDs []string) (map[string]*UpgradeTarget, error)
 }
@@ -165,4 +336,16 @@ type UpgradeUsecase interface {
 	CheckUpdateByToken(ctx context.Context, request *CheckUpdateRequest, clientIP, accessToken string) (*CheckUpdateResponse, error)
 	// 获取项目的所有升级目标
 	GetProjectUpgradeTargets(ctx context.Context, projectID string) ([]*UpgradeTarget, error)
+	// 暂停灰度发布
+	PauseRollout(ctx context.Context, id string) (*UpgradeTarget, error)
+	// 恢复灰度发布
+	ResumeRollout(ctx context.Context, id string) (*UpgradeTarget, error)
+	// 全量发布
+	CompleteRollout(ctx context.Context, id string) (*UpgradeTarget, error)
+	// 客户端上报升级结果，失败率超过阈值时自动暂停升级目标
+	ReportUpgradeResult(ctx context.Context, request *ReportUpgradeRequest, clientIP, accessToken string) (*UpgradeReport, error)
+	// 获取升级目标的上报统计
+	GetUpgradeTargetStats(ctx context.Context, id string) (*UpgradeTargetStats, error)
+	// 将升级目标的版本晋级到另一个渠道
+	PromoteUpgradeTarget(ctx context.Context, id string, request *PromoteUpgradeTargetRequest, userID string) (*UpgradeTarget, error)
 }
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// 升级结果上报的阶段与结果
+const (
+	ReportStageDownload = "download"
+	ReportStageInstall  = "install"
+	ReportStageStart    = "start"
+
+	ReportStatusSuccess = "success"
+	ReportStatusFailure = "failure"
+)
+
+// UpgradeReport 客户端上报的升级结果
+type UpgradeReport struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	PackageID      string    `json:"package_id"`
+	ReleaseID      string    `json:"release_id"`
+	UpgradeID      string    `json:"upgrade_id,omitempty"`
+	ClientAccessID string    `json:"client_access_id"`
+	ClientID       string    `json:"client_id"`
+	FromVersion    string    `json:"from_version,omitempty"`
+	Stage          string    `json:"stage"`
+	Status         string    `json:"status"`
+	Message        string    `json:"message,omitempty"`
+	ClientIP       string    `json:"client_ip,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ReportUpgradeRequest 客户端上报升级结果请求，release_id 与 version 二选一
+type ReportUpgradeRequest struct {
+	ClientID    string `json:"client_id" binding:"required"`
+	ReleaseID   string `json:"release_id"`
+	Version     string `json:"version"`
+	FromVersion string `json:"from_version"`
+	Stage       string `json:"stage" binding:"required,oneof=download install start"`
+	Status      string `json:"status" binding:"required,oneof=success failure"`
+	Message     string `json:"message"`
+}
+
+// StageStats 某个阶段的成功/失败数量
+type StageStats struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+}
+
+// UpgradeTargetStats 升级目标的上报统计
+type UpgradeTargetStats struct {
+	UpgradeID    string                `json:"upgrade_id"`
+	ReleaseID    string                `json:"release_id"`
+	Clients      int                   `json:"clients"` // 上报过结果的客户端数
+	Stages       map[string]StageStats `json:"stages"`
+	Reports      int                   `json:"reports"`       // 安装与启动阶段的上报数
+	FailureRatio float64               `json:"failure_ratio"` // 安装与启动阶段的失败比例
+}
+
+// UpgradeReportRepository 升级结果上报数据仓库接口
+type UpgradeReportRepository interface {
+	// 按 release_id + client_id + stage 写入或覆盖上报记录
+	Upsert(ctx context.Context, report *UpgradeReport) error
+	GetStatsByUpgradeID(ctx context.Context, upgradeID string) (*UpgradeTargetStats, error)
+}
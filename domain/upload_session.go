@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// TusVersion 支持的 tus 协议版本
+const TusVersion = "1.0.0"
+
+// 上传会话状态
+const (
+	UploadSessionStatusUploading  = "uploading"  // 接收分片中
+	UploadSessionStatusCompleted  = "completed"  // 已接收全部字节，等待完成（创建版本）
+	UploadSessionStatusFinalizing = "finalizing" // 正在写入存储并创建版本，同一会话只有一个请求能进入该状态
+	UploadSessionStatusFinalized  = "finalized"  // 已写入存储并创建版本
+)
+
+var (
+	// ErrUploadOffsetMismatch 分片的起始偏移与服务端已接收的字节数不一致（tus 409）
+	ErrUploadOffsetMismatch = errors.New("upload offset mismatch")
+	// ErrUploadSessionExpired 上传会话已过期（tus 410）
+	ErrUploadSessionExpired = errors.New("upload session expired")
+	// ErrUploadSessionClosed 会话已接收全部数据或已完成，不能继续写入
+	ErrUploadSessionClosed = errors.New("upload session is not accepting data")
+	// ErrUploadIncomplete 还有未接收的数据，不能完成上传
+	ErrUploadIncomplete = errors.New("upload is not complete")
+	// ErrUploadTooLarge 文件大小超过允许的上限（tus 413）
+	ErrUploadTooLarge = errors.New("upload exceeds maximum size")
+	// ErrUploadFinalizing 另一个请求正在完成该会话
+	ErrUploadFinalizing = errors.New("upload is being finalized")
+)
+
+// UploadSession 分片上传会话
+type UploadSession struct {
+	ID             string            `json:"id"`
+	TenantID       string            `json:"tenant_id"`
+	PackageID      string            `json:"package_id,omitempty"`
+	ClientAccessID string            `json:"client_access_id,omitempty"`
+	CreatedBy      string            `json:"created_by"`
+	FileName       string            `json:"file_name"`
+	ContentType    string            `json:"content_type,omitempty"`
+	Size           int64             `json:"size"`
+	Offset         int64             `json:"offset"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Status         string            `json:"status"`
+	ReleaseID      string            `json:"release_id,omitempty"` // 完成后创建（或追加构件）的版本
+	ExpiresAt      time.Time         `json:"expires_at"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// CreateUploadSessionRequest 创建上传会话请求（非 tus 客户端使用 JSON 创建）
+type CreateUploadSessionRequest struct {
+	FileName    string            `json:"file_name" binding:"required"`
+	Size        int64             `json:"size" binding:"required"`
+	ContentType string            `json:"content_type"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+// UploadSessionRepository 上传会话数据仓库接口
+type UploadSessionRepository interface {
+	Create(ctx context.Context, session *UploadSession) error
+	GetByID(ctx context.Context, id string) (*UploadSession, error)
+	Update(ctx context.Context, id string, updates map[string]interface{}) error
+	// 会话处于 from 状态时更新为 to，返回是否更新成功
+	UpdateStatus(ctx context.Context, id, from, to string) (bool, error)
+	Delete(ctx context.Context, id string) error
+	// 获取在 before 之前过期且未完成的会话
+	GetExpired(ctx context.Context, before time.Time) ([]*UploadSession, error)
+}
+
+// UploadSessionUsecase 分片上传业务逻辑接口
+type UploadSessionUsecase interface {
+	CreateSession(ctx context.Context, session *UploadSession) error
+	GetSession(ctx context.Context, id string) (*UploadSession, error)
+	// 从 offset 处写入一个分片，返回更新后的会话；连接中断时已写入的部分仍会保留
+	WriteChunk(ctx context.Context, id string, offset int64, reader io.Reader) (*UploadSession, error)
+	// 将已接收完整的会话转为 finalizing，保证同一会话只被完成一次
+	// 会话已完成时直接返回（status 为 finalized），其他请求正在完成时返回 ErrUploadFinalizing
+	BeginFinalize(ctx context.Context, id string) (*UploadSession, error)
+	// 完成失败时恢复为 completed，允许客户端重试
+	AbortFinalize(ctx context.Context, id string) error
+	// 打开 finalizing 会话的暂存文件
+	OpenUpload(ctx context.Context, id string) (io.ReadCloser, error)
+	// 标记会话已完成并记录创建的版本，删除暂存文件
+	MarkFinalized(ctx context.Context, id, releaseID string) error
+	DeleteSession(ctx context.Context, id string) error
+	// 清理过期会话及其暂存文件，返回清理的数量
+	CleanupExpired(ctx context.Context) (int, error)
+}
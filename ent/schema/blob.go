@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"github.com/rs/xid"
+)
+
+// Blob holds the schema definition for the Blob entity.
+// 内容寻址存储中的对象：按 SHA-256 存储一份，版本、构件和补丁通过路径引用，引用计数归零时删除
+type Blob struct {
+	ent.Schema
+}
+
+// Fields of the Blob.
+func (Blob) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("hash").
+			MaxLen(64).
+			Unique().
+			Comment("SHA-256（十六进制）"),
+		field.String("path").
+			MaxLen(500),
+		field.Int64("size"),
+		field.Int("ref_count").
+			Default(1).
+			Comment("引用该对象的上传次数"),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
@@ -32,6 +32,10 @@ func (ClientAccess) Fields() []ent.Field {
 		field.String("package_id").
 			MaxLen(50).
 			Comment("关联的包ID"),
+		field.String("channel").
+			MaxLen(32).
+			Optional().
+			Comment("绑定的发布渠道，为空表示由客户端自行指定（默认 stable）"),
 		field.String("access_token").
 			Unique().
 			Comment("客户端访问令牌"),
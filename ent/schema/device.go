@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// Device holds the schema definition for the Device entity.
+// 设备登记表，客户端每次检查更新时按 包 + 设备ID 写入或更新
+type Device struct {
+	ent.Schema
+}
+
+// Fields of the Device.
+func (Device) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("tenant_id").
+			MaxLen(50),
+		field.String("project_id").
+			MaxLen(50),
+		field.String("package_id").
+			MaxLen(50),
+		field.String("client_access_id").
+			MaxLen(50).
+			Comment("最近一次检查更新使用的接入凭证"),
+		field.String("device_id").
+			MaxLen(255).
+			Comment("客户端上报的稳定设备标识"),
+		field.String("current_version").
+			MaxLen(100).
+			Comment("设备当前版本"),
+		field.String("channel").
+			MaxLen(32).
+			Optional(),
+		field.String("os").
+			MaxLen(50).
+			Optional(),
+		field.String("arch").
+			MaxLen(50).
+			Optional(),
+		field.String("os_version").
+			MaxLen(100).
+			Optional().
+			Comment("操作系统版本，用于升级目标定向"),
+		field.String("model").
+			MaxLen(100).
+			Optional().
+			Comment("硬件型号"),
+		field.String("region").
+			MaxLen(50).
+			Optional().
+			Comment("地区"),
+		field.String("hostname").
+			MaxLen(255).
+			Optional(),
+		field.JSON("labels", map[string]string{}).
+			Optional().
+			Comment("自定义标签"),
+		field.Text("client_info").
+			Optional().
+			Comment("客户端上报的原始信息"),
+		field.String("last_ip").
+			MaxLen(45).
+			Optional(),
+		field.Int("check_count").
+			Default(0),
+		field.Time("first_seen_at").
+			Default(time.Now),
+		field.Time("last_seen_at").
+			Default(time.Now),
+	}
+}
+
+// Indexes of the Device.
+func (Device) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("package_id", "device_id").Unique(),
+		index.Fields("tenant_id"),
+		index.Fields("package_id", "current_version"),
+		index.Fields("last_seen_at"),
+	}
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// LinuxPackage holds the schema definition for the LinuxPackage entity.
+// 从 linux 类型包的版本文件（.deb/.rpm）中提取的元数据，用于生成 APT/YUM 仓库索引
+type LinuxPackage struct {
+	ent.Schema
+}
+
+// Fields of the LinuxPackage.
+func (LinuxPackage) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("project_id").
+			MaxLen(50),
+		field.String("package_id").
+			MaxLen(50),
+		field.String("release_id").
+			MaxLen(50),
+		field.String("format").
+			MaxLen(10).
+			Comment("deb/rpm"),
+		field.String("name").
+			MaxLen(255).
+			Comment("文件中声明的软件包名"),
+		field.String("version").
+			MaxLen(255),
+		field.String("architecture").
+			MaxLen(50),
+		field.String("file_path").
+			MaxLen(500),
+		field.String("file_name").
+			MaxLen(255),
+		field.Int64("file_size"),
+		field.String("sha256").
+			MaxLen(64).
+			Optional(),
+		field.String("sha512").
+			MaxLen(128).
+			Optional(),
+		field.Text("metadata").
+			Comment("deb 为 control 段落原文，rpm 为头部信息 JSON"),
+		field.Text("error").
+			Optional().
+			Comment("无法解析时的错误信息，此类文件不会出现在仓库索引中"),
+		field.Time("created_at").
+			Default(time.Now),
+	}
+}
+
+// Indexes of the LinuxPackage.
+func (LinuxPackage) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("project_id", "format"),
+		index.Fields("package_id", "format"),
+		// 版本主文件与首个构件可能是同一个文件，按路径只记录一次
+		index.Fields("release_id", "file_path").Unique(),
+	}
+}
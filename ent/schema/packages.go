@@ -34,6 +34,10 @@ func (Packages) Fields() []ent.Field {
 		field.String("icon").
 			MaxLen(500).
 			Optional(),
+		field.Enum("version_scheme").
+			Values("auto", "semver", "integer").
+			Default("auto").
+			Comment("版本号排序方案：auto/semver/integer(Android versionCode)"),
 		field.Time("created_at").
 			Default(time.Now),
 		field.Time("updated_at").
@@ -42,8 +42,43 @@ func (Release) Fields() []ent.Field {
 		field.String("file_hash").
 			MaxLen(64).
 			Optional(),
+		field.String("sha256").
+			MaxLen(64).
+			Optional().
+			Comment("文件 SHA-256 摘要（十六进制），上传时计算"),
+		field.String("sha512").
+			MaxLen(128).
+			Optional().
+			Comment("文件 SHA-512 摘要（十六进制），上传时计算"),
+		field.String("signature").
+			MaxLen(128).
+			Optional().
+			Comment("Ed25519ph 对 SHA-512 摘要的分离签名（Base64）"),
+		field.String("signature_key_id").
+			MaxLen(32).
+			Optional().
+			Comment("签名使用的密钥指纹"),
+		field.String("integrity_status").
+			MaxLen(20).
+			Optional().
+			Comment("存储巡检结果 ok/missing/corrupt，空表示未检查"),
+		field.Time("integrity_checked_at").
+			Optional().
+			Nillable(),
 		field.Int("download_count").
 			Default(0),
+		field.Bool("is_draft").
+			Default(false).
+			Comment("草稿版本，发布前对分享、下载和升级不可见"),
+		field.Bool("is_prerelease").
+			Default(false).
+			Comment("预发布版本，不参与最新稳定版的计算"),
+		field.Bool("is_latest").
+			Default(false).
+			Comment("手动标记为最新版本，每个包最多一个"),
+		field.Time("published_at").
+			Optional().
+			Comment("发布时间，草稿发布时设置"),
 		field.String("created_by").
 			MaxLen(50),
 		field.Time("created_at").
@@ -69,6 +104,10 @@ func (Release) Edges() []ent.Edge {
 		edge.To("shares", Share.Type),
 		// Release has upgrade targets
 		edge.To("upgrades", Upgrade.Type),
+		// Release has per-platform assets
+		edge.To("assets", ReleaseAsset.Type),
+		// Release has binary delta patches from older releases
+		edge.To("patches", ReleasePatch.Type),
 	}
 }
 
@@ -78,6 +117,7 @@ func (Release) Indexes() []ent.Index {
 		index.Fields("package_id"),
 		index.Fields("version_code"),
 		index.Fields("created_at"),
+		index.Fields("package_id", "is_latest"),
 		// Unique constraint: one version per package
 		index.Fields("package_id", "version_code", "version_name", "tag_name").Unique(),
 	}
@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// ReleaseAsset holds the schema definition for the ReleaseAsset entity.
+// 发布版本的构件，一个版本可以包含多个不同操作系统/架构的文件
+type ReleaseAsset struct {
+	ent.Schema
+}
+
+// Fields of the ReleaseAsset.
+func (ReleaseAsset) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("release_id"),
+		field.String("os").
+			MaxLen(50).
+			Default("").
+			Comment("目标操作系统，空表示不限"),
+		field.String("arch").
+			MaxLen(50).
+			Default("").
+			Comment("目标架构，空表示不限"),
+		field.String("kind").
+			MaxLen(50).
+			Default("binary").
+			Comment("构件类型：binary/archive/installer/package/checksum 等"),
+		field.String("artifact").
+			MaxLen(255).
+			Optional().
+			Comment("构件名称（GoReleaser artifact）"),
+		field.String("file_path").
+			MaxLen(500),
+		field.String("file_name").
+			MaxLen(255),
+		field.Int64("file_size"),
+		field.String("file_hash").
+			MaxLen(64).
+			Optional(),
+		field.String("sha256").
+			MaxLen(64).
+			Optional().
+			Comment("文件 SHA-256 摘要（十六进制），上传时计算"),
+		field.String("sha512").
+			MaxLen(128).
+			Optional().
+			Comment("文件 SHA-512 摘要（十六进制），上传时计算"),
+		field.String("signature").
+			MaxLen(128).
+			Optional().
+			Comment("Ed25519ph 对 SHA-512 摘要的分离签名（Base64）"),
+		field.String("signature_key_id").
+			MaxLen(32).
+			Optional().
+			Comment("签名使用的密钥指纹"),
+		field.Int("download_count").
+			Default(0),
+		field.Time("created_at").
+			Default(time.Now),
+	}
+}
+
+// Edges of the ReleaseAsset.
+func (ReleaseAsset) Edges() []ent.Edge {
+	return []ent.Edge{
+		// Asset belongs to a release
+		edge.From("release", Release.Type).
+			Ref("assets").
+			Field("release_id").
+			Unique().
+			Required(),
+	}
+}
+
+// Indexes of the ReleaseAsset.
+func (ReleaseAsset) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("release_id"),
+		// 同一版本下每个平台/类型只能有一个构件
+		index.Fields("release_id", "os", "arch", "kind").Unique(),
+	}
+}
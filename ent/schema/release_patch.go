@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// ReleasePatch holds the schema definition for the ReleasePatch entity.
+// 版本间的二进制差分补丁，由旧版本文件生成到目标版本文件
+type ReleasePatch struct {
+	ent.Schema
+}
+
+// Fields of the ReleasePatch.
+func (ReleasePatch) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("package_id").
+			MaxLen(50),
+		field.String("from_release_id").
+			MaxLen(50).
+			Comment("旧版本ID"),
+		field.String("to_release_id").
+			MaxLen(50).
+			Comment("目标版本ID"),
+		field.String("to_asset_id").
+			MaxLen(50).
+			Default("").
+			Comment("目标构件ID，空表示版本主文件"),
+		field.String("format").
+			MaxLen(50).
+			Comment("补丁格式"),
+		field.Enum("status").
+			Values("pending", "ready", "failed").
+			Default("pending").
+			Comment("生成状态"),
+		field.String("error").
+			Optional().
+			Comment("生成失败原因"),
+		field.String("file_path").
+			MaxLen(500).
+			Optional(),
+		field.Int64("file_size").
+			Default(0).
+			Comment("补丁文件大小"),
+		field.String("target_hash").
+			MaxLen(64).
+			Optional().
+			Comment("应用补丁后文件的 SHA-256"),
+		field.Int64("target_size").
+			Default(0).
+			Comment("应用补丁后文件的大小"),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Edges of the ReleasePatch.
+func (ReleasePatch) Edges() []ent.Edge {
+	return []ent.Edge{
+		// Patch produces a target release
+		edge.From("release", Release.Type).
+			Ref("patches").
+			Field("to_release_id").
+			Unique().
+			Required(),
+	}
+}
+
+// Indexes of the ReleasePatch.
+func (ReleasePatch) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("from_release_id"),
+		index.Fields("to_release_id"),
+		// 同一对版本、同一构件只生成一个补丁
+		index.Fields("from_release_id", "to_release_id", "to_asset_id").Unique(),
+	}
+}
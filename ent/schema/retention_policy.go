@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// RetentionPolicy holds the schema definition for the RetentionPolicy entity.
+// 版本保留策略：项目级策略作用于项目下所有包，包级策略优先于项目级策略
+type RetentionPolicy struct {
+	ent.Schema
+}
+
+// Fields of the RetentionPolicy.
+func (RetentionPolicy) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("tenant_id").
+			MaxLen(20),
+		field.String("project_id").
+			MaxLen(50),
+		field.String("package_id").
+			MaxLen(50).
+			Default("").
+			Comment("为空表示项目级策略"),
+		field.Int("keep_last").
+			Default(0).
+			Comment("保留最新的 N 个版本，0 表示不限制"),
+		field.Int("prerelease_max_age_days").
+			Default(0).
+			Comment("删除创建超过 N 天的预发布版本，0 表示不限制"),
+		field.Bool("enabled").
+			Default(true),
+		field.String("created_by").
+			MaxLen(50).
+			Default(""),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the RetentionPolicy.
+func (RetentionPolicy) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id"),
+		// 每个项目（或包）只有一条策略
+		index.Fields("project_id", "package_id").Unique(),
+	}
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// SigningKey holds the schema definition for the SigningKey entity.
+// 租户的发布签名密钥，轮换后旧密钥保留为 retired 状态以便继续校验历史签名
+type SigningKey struct {
+	ent.Schema
+}
+
+// Fields of the SigningKey.
+func (SigningKey) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("tenant_id").
+			MaxLen(50),
+		field.String("key_id").
+			MaxLen(32).
+			Unique().
+			Comment("公钥指纹，签名中携带以便客户端选择公钥"),
+		field.String("algorithm").
+			MaxLen(20).
+			Default("ed25519"),
+		field.String("public_key").
+			MaxLen(255).
+			Comment("Base64 编码的公钥"),
+		field.Text("private_key").
+			Sensitive().
+			Comment("使用服务端密钥加密后的私钥种子"),
+		field.String("status").
+			MaxLen(20).
+			Default("active").
+			Comment("active/retired"),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("retired_at").
+			Optional(),
+	}
+}
+
+// Indexes of the SigningKey.
+func (SigningKey) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "status"),
+		// 每个租户最多一个 active 密钥，并发生成或轮换时后提交的一方违反约束
+		index.Fields("tenant_id").
+			Unique().
+			Annotations(entsql.IndexWhere("status = 'active'")),
+	}
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// StorageMigration holds the schema definition for the StorageMigration entity.
+// 存储后端迁移任务：将版本、构件和补丁引用的对象从一个后端复制到另一个后端并校验
+type StorageMigration struct {
+	ent.Schema
+}
+
+// Fields of the StorageMigration.
+func (StorageMigration) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("source").
+			MaxLen(20).
+			Comment("源存储类型 disk/minio"),
+		field.String("target").
+			MaxLen(20).
+			Comment("目标存储类型 disk/minio"),
+		field.String("status").
+			MaxLen(20).
+			Default("running").
+			Comment("running/completed/failed/interrupted"),
+		field.Int("total").
+			Default(0).
+			Comment("需要迁移的对象数"),
+		field.Int("processed").
+			Default(0),
+		field.Int("copied").
+			Default(0),
+		field.Int("skipped").
+			Default(0).
+			Comment("目标中已存在且校验一致的对象"),
+		field.Int("failed").
+			Default(0),
+		field.Int64("bytes").
+			Default(0).
+			Comment("已复制的字节数"),
+		field.String("cursor").
+			MaxLen(500).
+			Default("").
+			Comment("最后处理的对象路径，中断后从这里继续"),
+		field.JSON("failures", []string{}).
+			Optional().
+			Comment("失败对象及原因"),
+		field.String("error").
+			Optional(),
+		field.String("created_by").
+			MaxLen(50).
+			Default(""),
+		field.Time("started_at").
+			Default(time.Now),
+		field.Time("finished_at").
+			Optional().
+			Nillable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the StorageMigration.
+func (StorageMigration) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("source", "target", "status"),
+	}
+}
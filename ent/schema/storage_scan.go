@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// StorageIssue 巡检发现的问题：孤立对象、缺失对象或摘要不一致
+type StorageIssue struct {
+	Type         string     `json:"type"`
+	Path         string     `json:"path"`
+	Size         int64      `json:"size,omitempty"`
+	ReleaseID    string     `json:"release_id,omitempty"`
+	AssetID      string     `json:"asset_id,omitempty"`
+	PatchID      string     `json:"patch_id,omitempty"`
+	Expected     string     `json:"expected,omitempty"`
+	Actual       string     `json:"actual,omitempty"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+	Deleted      bool       `json:"deleted,omitempty"`
+}
+
+// StorageScan holds the schema definition for the StorageScan entity.
+// 存储巡检记录：对比存储中的对象与数据库引用，回收孤立对象并校验版本文件的完整性
+type StorageScan struct {
+	ent.Schema
+}
+
+// Fields of the StorageScan.
+func (StorageScan) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("status").
+			MaxLen(20).
+			Default("running").
+			Comment("running/completed/failed"),
+		field.String("trigger").
+			MaxLen(20).
+			Default("manual").
+			Comment("manual/scheduled"),
+		field.Bool("delete_orphans").
+			Default(false).
+			Comment("是否删除超过宽限期的孤立对象"),
+		field.Bool("verify_hashes").
+			Default(false).
+			Comment("是否读取对象校验 SHA-256"),
+		field.Int("total_objects").
+			Default(0).
+			Comment("存储中的对象数"),
+		field.Int("referenced_objects").
+			Default(0).
+			Comment("数据库引用的对象数"),
+		field.Int("orphan_count").
+			Default(0),
+		field.Int64("orphan_bytes").
+			Default(0),
+		field.Int("deleted_count").
+			Default(0),
+		field.Int("missing_count").
+			Default(0),
+		field.Int("corrupt_count").
+			Default(0),
+		field.JSON("issues", []StorageIssue{}).
+			Optional().
+			Comment("发现的问题明细"),
+		field.String("error").
+			Optional(),
+		field.String("created_by").
+			MaxLen(50).
+			Default(""),
+		field.Time("started_at").
+			Default(time.Now),
+		field.Time("finished_at").
+			Optional().
+			Nillable(),
+	}
+}
+
+// Indexes of the StorageScan.
+func (StorageScan) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("started_at"),
+	}
+}
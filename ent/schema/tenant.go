@@ -21,6 +21,19 @@ func (Tenant) Fields() []ent.Field {
 		field.String("name").
 			Unique().
 			NotEmpty(),
+		field.String("timezone").
+			MaxLen(64).
+			Default("UTC").
+			Comment("租户时区（IANA 名称），升级维护窗口按该时区计算"),
+		field.Int64("quota_bytes").
+			Default(0).
+			Comment("存储总量上限（字节），0 表示不限制"),
+		field.Int64("max_file_size").
+			Default(0).
+			Comment("单个文件大小上限（字节），0 表示不限制"),
+		field.Int("max_releases_per_package").
+			Default(0).
+			Comment("每个包的版本数上限，0 表示不限制"),
 		field.Time("created_at").
 			Default(time.Now),
 		field.Time("updated_at").
@@ -10,6 +10,29 @@ import (
 	"entgo.io/ent/schema/index"
 )
 
+// RolloutStep 灰度发布计划中的一个阶段：到达 At 时间后放量到 Percentage
+type RolloutStep struct {
+	At         time.Time `json:"at"`
+	Percentage int       `json:"percentage"`
+}
+
+// TargetingRules 升级目标的定向规则，所有非空条件都满足时客户端才命中该目标
+type TargetingRules struct {
+	OS           []string `json:"os,omitempty"`
+	OSVersionMin string   `json:"os_version_min,omitempty"`
+	OSVersionMax string   `json:"os_version_max,omitempty"`
+	Regions      []string `json:"regions,omitempty"`
+	Models       []string `json:"models,omitempty"`
+	DeviceIDs    []string `json:"device_ids,omitempty"`
+}
+
+// MaintenanceWindow 周期性维护窗口：Days 中的每一天 Start 到 End（HH:MM，租户时区），End 不晚于 Start 时跨越午夜
+type MaintenanceWindow struct {
+	Days  []int  `json:"days,omitempty"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
 // Upgrade holds the schema definition for the Upgrade entity.
 // 升级目标实体，用于设置项目、包、版本的升级目标，供客户端查询和下载
 type Upgrade struct {
@@ -27,6 +50,10 @@ func (Upgrade) Fields() []ent.Field {
 		field.String("project_id").MaxLen(50),
 		field.String("package_id").MaxLen(50),
 		field.String("release_id").MaxLen(50),
+		field.String("channel").
+			MaxLen(32).
+			Default("stable").
+			Comment("发布渠道，如 stable/beta/nightly"),
 		field.String("name").
 			MaxLen(255).
 			Comment("升级目标名称"),
@@ -36,6 +63,54 @@ func (Upgrade) Fields() []ent.Field {
 		field.Bool("is_active").
 			Default(true).
 			Comment("是否激活，用于启用/禁用升级目标"),
+		field.Bool("is_rollback").
+			Default(false).
+			Comment("是否为回滚目标，只有回滚目标才会向更高版本的客户端下发降级"),
+		field.Bool("is_mandatory").
+			Default(false).
+			Comment("是否强制更新，客户端必须升级后才能继续使用"),
+		field.String("min_supported_version").
+			MaxLen(100).
+			Optional().
+			Comment("最低支持版本，低于该版本的客户端将被强制更新"),
+		field.JSON("targeting_rules", TargetingRules{}).
+			Optional().
+			Comment("定向规则，为空表示面向渠道内所有客户端；同一渠道可同时激活多个不同规则的目标"),
+		field.Time("starts_at").
+			Optional().
+			Comment("生效时间，之前不下发更新"),
+		field.Time("ends_at").
+			Optional().
+			Comment("失效时间，之后不再下发更新"),
+		field.JSON("maintenance_windows", []MaintenanceWindow{}).
+			Optional().
+			Comment("周期性维护窗口，为空表示任意时间都可下发"),
+		field.Int("rollout_percentage").
+			Default(100).
+			Min(0).
+			Max(100).
+			Comment("灰度发布比例（0-100）"),
+		field.JSON("rollout_schedule", []RolloutStep{}).
+			Optional().
+			Comment("灰度放量计划，按时间逐步提升比例"),
+		field.Enum("rollout_status").
+			Values("in_progress", "paused", "completed").
+			Default("completed").
+			Comment("灰度发布状态：进行中/已暂停/已全量"),
+		field.String("pause_reason").
+			Optional().
+			Comment("暂停原因，自动暂停时记录失败率"),
+		field.String("promoted_by").
+			MaxLen(50).
+			Optional().
+			Comment("最近一次晋级操作人"),
+		field.Time("promoted_at").
+			Optional().
+			Comment("最近一次晋级时间"),
+		field.String("promoted_from").
+			MaxLen(32).
+			Optional().
+			Comment("最近一次晋级的来源渠道"),
 		field.String("created_by").
 			MaxLen(50),
 		field.Time("created_at").
@@ -95,5 +170,6 @@ func (Upgrade) Indexes() []ent.Index {
 		index.Fields("tenant_id", "is_active"),
 		index.Fields("project_id", "is_active"),
 		index.Fields("package_id", "is_active"),
+		index.Fields("package_id", "channel", "is_active"),
 	}
 }
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// UpgradeReport holds the schema definition for the UpgradeReport entity.
+// 客户端上报的升级结果（下载/安装/启动），每个客户端在每个版本的每个阶段只保留最新一条
+type UpgradeReport struct {
+	ent.Schema
+}
+
+// Fields of the UpgradeReport.
+func (UpgradeReport) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("tenant_id").
+			MaxLen(50),
+		field.String("package_id").
+			MaxLen(50),
+		field.String("release_id").
+			MaxLen(50),
+		field.String("upgrade_id").
+			MaxLen(50).
+			Optional().
+			Comment("上报时该版本对应的升级目标ID"),
+		field.String("client_access_id").
+			MaxLen(50).
+			Comment("上报使用的客户端接入凭证"),
+		field.String("client_id").
+			MaxLen(255).
+			Comment("客户端稳定标识"),
+		field.String("from_version").
+			MaxLen(100).
+			Optional().
+			Comment("升级前版本"),
+		field.Enum("stage").
+			Values("download", "install", "start").
+			Comment("阶段：下载/安装/启动"),
+		field.Enum("status").
+			Values("success", "failure").
+			Comment("结果"),
+		field.String("message").
+			Optional().
+			Comment("失败原因等附加信息"),
+		field.String("client_ip").
+			MaxLen(45).
+			Optional(),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the UpgradeReport.
+func (UpgradeReport) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("upgrade_id"),
+		index.Fields("release_id", "client_id", "stage").Unique(),
+		index.Fields("package_id", "created_at"),
+	}
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/rs/xid"
+)
+
+// UploadSession holds the schema definition for the UploadSession entity.
+// 可续传的分片上传会话（兼容 tus 协议），分片暂存在本地，完成后写入存储并创建版本
+type UploadSession struct {
+	ent.Schema
+}
+
+// Fields of the UploadSession.
+func (UploadSession) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("id").
+			DefaultFunc(func() string {
+				return xid.New().String()
+			}),
+		field.String("tenant_id").
+			MaxLen(50),
+		field.String("package_id").
+			MaxLen(50).
+			Optional(),
+		field.String("client_access_id").
+			MaxLen(50).
+			Optional().
+			Comment("通过客户端接入凭证创建的会话"),
+		field.String("created_by").
+			MaxLen(50),
+		field.String("file_name").
+			MaxLen(255),
+		field.String("content_type").
+			MaxLen(255).
+			Optional(),
+		field.Int64("size").
+			Comment("文件总大小"),
+		field.Int64("offset").
+			Default(0).
+			Comment("已接收的字节数"),
+		field.JSON("metadata", map[string]string{}).
+			Optional().
+			Comment("创建会话时携带的元数据（tus Upload-Metadata）"),
+		field.String("status").
+			MaxLen(20).
+			Default("uploading").
+			Comment("uploading/completed/finalizing/finalized"),
+		field.String("release_id").
+			MaxLen(50).
+			Optional().
+			Comment("完成后创建的版本"),
+		field.Time("expires_at"),
+		field.Time("created_at").
+			Default(time.Now),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the UploadSession.
+func (UploadSession) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id"),
+		index.Fields("expires_at"),
+	}
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"pkms/bootstrap"
+	"pkms/pkg"
+	"pkms/repository"
+	"pkms/usecase"
+)
+
+// DedupStorage 内容寻址迁移命令：pkms dedup-storage
+// 将已有的版本、构件和补丁文件移动为内容寻址对象，相同内容只保留一份；可重复执行
+// 迁移过程中会移动对象，应在服务停止时执行。返回进程退出码
+func DedupStorage(app *bootstrap.Application, timeout time.Duration, args []string) int {
+	env := app.Env
+	if len(args) > 0 {
+		pkg.Log.Errorf("dedup-storage 不接受参数: %v", args)
+		return 2
+	}
+
+	// 直接操作底层存储，不经过内容寻址包装
+	storage, err := bootstrap.NewFileStorage(env, env.CurrentStorageType())
+	if err != nil {
+		pkg.Log.Errorf("无法打开存储: %v", err)
+		return 1
+	}
+
+	dedupUsecase := usecase.NewStorageDedupUsecase(
+		repository.NewReleaseRepository(app.DB),
+		repository.NewBlobRepository(app.DB),
+		storage,
+		env.S3Bucket,
+		timeout,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := dedupUsecase.Dedupe(ctx)
+	if result != nil {
+		fmt.Printf("内容寻址迁移：共 %d 个对象，移动 %d，去重 %d，跳过 %d，失败 %d，节省 %d 字节\n",
+			result.Total, result.Moved, result.Deduplicated, result.Skipped, result.Failed, result.SavedBytes)
+		for _, failure := range result.Failures {
+			fmt.Println("  失败:", failure)
+		}
+	}
+	if err != nil {
+		pkg.Log.Errorf("内容寻址迁移中断，重新执行命令可继续: %v", err)
+		return 1
+	}
+	if result.Failed > 0 {
+		return 1
+	}
+	if !env.StorageDedup {
+		fmt.Println("已有文件迁移完成，设置 STORAGE_DEDUP=true 后新上传的文件也会去重")
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+// Package command 提供随服务二进制一起发布的管理命令
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/pkg"
+	"pkms/repository"
+	"pkms/usecase"
+)
+
+// MigrateStorage 存储迁移命令：pkms migrate-storage -to minio
+// 将当前 STORAGE_TYPE 下被引用的对象复制到目标存储并校验，中断后重新执行会从上次的位置继续
+// 返回进程退出码
+func MigrateStorage(app *bootstrap.Application, timeout time.Duration, args []string) int {
+	env := app.Env
+	source := env.CurrentStorageType()
+	defaultTarget := domain.StorageTypeMinio
+	if source == domain.StorageTypeMinio {
+		defaultTarget = domain.StorageTypeDisk
+	}
+
+	flags := flag.NewFlagSet("migrate-storage", flag.ContinueOnError)
+	target := flags.String("to", defaultTarget, "目标存储类型 (disk/minio)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	migrationUsecase := usecase.NewStorageMigrationUsecase(
+		repository.NewStorageMigrationRepository(app.DB),
+		repository.NewReleaseRepository(app.DB),
+		app.FileStorage,
+		source,
+		env.S3Bucket,
+		func(storageType string) (domain.FileRepository, error) {
+			return bootstrap.NewFileStorage(env, storageType)
+		},
+		timeout,
+	)
+
+	migration, err := migrationUsecase.Prepare(context.Background(), *target, "cli")
+	if err != nil {
+		pkg.Log.Errorf("无法开始存储迁移: %v", err)
+		return 1
+	}
+
+	// Ctrl+C 时停止迁移，任务标记为中断，重新执行命令即可继续
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := migrationUsecase.Run(ctx, migration); err != nil {
+		pkg.Log.Errorf("存储迁移中断，重新执行命令可继续: %v", err)
+		return 1
+	}
+
+	fmt.Printf("存储迁移 %s: %s -> %s，共 %d 个对象，复制 %d，跳过 %d，失败 %d\n",
+		migration.Status, migration.Source, migration.Target, migration.Total, migration.Copied, migration.Skipped, migration.Failed)
+	for _, failure := range migration.Failures {
+		fmt.Println("  失败:", failure)
+	}
+	if migration.Status != domain.StorageMigrationCompleted {
+		return 1
+	}
+	fmt.Printf("全部对象已校验，可将 STORAGE_TYPE 设置为 %s 后重启服务\n", migration.Target)
+	return 0
+}
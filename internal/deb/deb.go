@@ -0,0 +1,213 @@
+// Package deb 读取 Debian 二进制包（.deb）的控制信息
+//
+// .deb 是 ar 归档，依次包含 debian-binary、control.tar[.gz|.xz|.zst] 和 data.tar.*，
+// 控制信息位于 control.tar 中的 ./control 文件。读取时只解析到 control.tar 为止，不读取数据部分。
+package deb
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	arMagic        = "!<arch>\n"
+	arHeaderSize   = 60
+	maxControlSize = 1 << 20  // control 文件大小上限
+	maxZstdMemory  = 64 << 20 // 解压 control.tar.zst 的内存上限，避免声明超大窗口的压缩数据
+)
+
+// ErrNotDeb 文件不是 .deb 格式
+var ErrNotDeb = errors.New("not a debian package")
+
+// ReadControl 从 .deb 文件读取控制信息
+func ReadControl(r io.Reader) (*Control, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != arMagic {
+		return nil, ErrNotDeb
+	}
+
+	header := make([]byte, arHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("%w: control.tar not found", ErrNotDeb)
+			}
+			return nil, err
+		}
+		if string(header[58:60]) != "`\n" {
+			return nil, fmt.Errorf("%w: invalid ar header", ErrNotDeb)
+		}
+		// GNU ar 的成员名以 "/" 结尾
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("%w: invalid member size", ErrNotDeb)
+		}
+
+		if strings.HasPrefix(name, "control.tar") {
+			return readControlTar(name, io.LimitReader(br, size))
+		}
+		// 成员数据按 2 字节对齐
+		if _, err := br.Discard(int(size + size%2)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readControlTar(name string, r io.Reader) (*Control, error) {
+	var tr *tar.Reader
+	switch path.Ext(name) {
+	case ".tar":
+		tr = tar.NewReader(r)
+	case ".gz":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		tr = tar.NewReader(gz)
+	case ".xz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		tr = tar.NewReader(xr)
+	case ".zst":
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxZstdMemory))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		tr = tar.NewReader(zr)
+	default:
+		return nil, fmt.Errorf("unsupported control archive: %s", name)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("control file not found in " + name)
+			}
+			return nil, err
+		}
+		if path.Clean(strings.TrimPrefix(hdr.Name, "./")) != "control" {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxControlSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxControlSize {
+			return nil, errors.New("control file too large")
+		}
+		return ParseControl(string(data))
+	}
+}
+
+// Field 控制文件中的一个字段，多行字段的 Value 保留续行（以空格开头）
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Control 控制文件的一个段落（deb822 格式），保留字段顺序
+type Control struct {
+	Fields []Field
+}
+
+// ParseControl 解析单个段落的控制文件
+func ParseControl(text string) (*Control, error) {
+	control := &Control{}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			// 只取第一个段落
+			if len(control.Fields) > 0 {
+				break
+			}
+			continue
+		}
+		if line[0] == '#' {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if len(control.Fields) == 0 {
+				return nil, errors.New("invalid control file: continuation line without field")
+			}
+			last := &control.Fields[len(control.Fields)-1]
+			last.Value += "\n" + line
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid control line: %q", line)
+		}
+		control.Fields = append(control.Fields, Field{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+
+	for _, required := range []string{"Package", "Version", "Architecture"} {
+		if control.Get(required) == "" {
+			return nil, fmt.Errorf("invalid control file: missing %s", required)
+		}
+	}
+	return control, nil
+}
+
+// Get 返回字段值，字段名不区分大小写
+func (c *Control) Get(name string) string {
+	for _, field := range c.Fields {
+		if strings.EqualFold(field.Name, name) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Set 设置字段值，字段不存在时追加到末尾
+func (c *Control) Set(name, value string) {
+	for i, field := range c.Fields {
+		if strings.EqualFold(field.Name, name) {
+			c.Fields[i].Value = value
+			return
+		}
+	}
+	c.Fields = append(c.Fields, Field{Name: name, Value: value})
+}
+
+// Del 删除字段
+func (c *Control) Del(name string) {
+	fields := c.Fields[:0]
+	for _, field := range c.Fields {
+		if !strings.EqualFold(field.Name, name) {
+			fields = append(fields, field)
+		}
+	}
+	c.Fields = fields
+}
+
+// String 序列化为控制文件段落，以换行结尾
+func (c *Control) String() string {
+	var buf bytes.Buffer
+	for _, field := range c.Fields {
+		buf.WriteString(field.Name)
+		buf.WriteString(":")
+		if field.Value != "" && field.Value[0] != '\n' {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(field.Value)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
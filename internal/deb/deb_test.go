@@ -0,0 +1,168 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testControl = `Package: hello
+Version: 1:2.10-3
+Architecture: amd64
+Maintainer: Test <test@example.com>
+Description: greeting program
+ Prints a friendly greeting.
+`
+
+// controlTar 生成只包含 ./control 的 control.tar
+func controlTar(t *testing.T, control string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "./control", Mode: 0o644, Size: int64(len(control))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(control)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// controlTarGz 生成只包含 ./control 的 control.tar.gz
+func controlTarGz(t *testing.T, control string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(controlTar(t, control)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type arMember struct {
+	name string
+	data []byte
+}
+
+// buildAr 生成 ar 归档，成员数据按 2 字节对齐
+func buildAr(members ...arMember) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	for _, m := range members {
+		fmt.Fprintf(&buf, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", m.name, "0", "0", "0", "100644", len(m.data))
+		buf.Write(m.data)
+		if len(m.data)%2 != 0 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+func buildDeb(t *testing.T, control string) []byte {
+	t.Helper()
+	return buildAr(
+		arMember{"debian-binary", []byte("2.0\n")},
+		arMember{"control.tar.gz", controlTarGz(t, control)},
+		arMember{"data.tar.gz", []byte("payload")},
+	)
+}
+
+func TestReadControl(t *testing.T) {
+	control, err := ReadControl(bytes.NewReader(buildDeb(t, testControl)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if control.Get("package") != "hello" || control.Get("Version") != "1:2.10-3" || control.Get("Architecture") != "amd64" {
+		t.Fatalf("unexpected control: %+v", control.Fields)
+	}
+	if control.String() != testControl {
+		t.Fatalf("control round trip mismatch:\n%s", control.String())
+	}
+}
+
+func TestReadControlTruncated(t *testing.T) {
+	data := buildAr(
+		arMember{"debian-binary", []byte("2.0\n")},
+		arMember{"control.tar", controlTar(t, testControl)},
+	)
+	if _, err := ReadControl(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	// 读到完整的 control 文件之后的截断不影响读取
+	end := bytes.Index(data, []byte(testControl)) + len(testControl)
+	for n := 0; n < end; n++ {
+		if _, err := ReadControl(bytes.NewReader(data[:n])); err == nil {
+			t.Fatalf("expected error for %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestReadControlRejectsInvalidArchives(t *testing.T) {
+	header := func(name, size string) []byte {
+		return []byte(fmt.Sprintf("%s%-16s%-12s%-6s%-6s%-8s%-10s`\n", arMagic, name, "0", "0", "0", "100644", size))
+	}
+	tests := map[string][]byte{
+		"empty":             nil,
+		"not ar":            []byte("PK\x03\x04 not a deb at all"),
+		"bad member header": append([]byte(arMagic), bytes.Repeat([]byte{'x'}, arHeaderSize)...),
+		"negative size":     header("debian-binary", "-1"),
+		"non numeric size":  header("debian-binary", "ffff"),
+		"no control":        buildAr(arMember{"debian-binary", []byte("2.0\n")}, arMember{"data.tar.gz", []byte("payload")}),
+	}
+	for name, data := range tests {
+		if _, err := ReadControl(bytes.NewReader(data)); !errors.Is(err, ErrNotDeb) {
+			t.Errorf("%s: expected ErrNotDeb, got %v", name, err)
+		}
+	}
+}
+
+func TestReadControlRejectsHostileMembers(t *testing.T) {
+	tests := map[string][]byte{
+		// 声明的成员大小远超实际数据
+		"oversized member": append(buildAr(arMember{"debian-binary", []byte("2.0\n")})[:len(arMagic)+48], "9999999999`\n2.0\n"...),
+		"corrupt gzip":     buildAr(arMember{"control.tar.gz", []byte("\x1f\x8b\x08\x00garbage")}),
+		"corrupt zstd":     buildAr(arMember{"control.tar.zst", []byte("\x28\xb5\x2f\xfd garbage")}),
+		"corrupt xz":       buildAr(arMember{"control.tar.xz", []byte("\xfd7zXZ\x00garbage")}),
+		"unknown archive":  buildAr(arMember{"control.tar.bz2", []byte("BZh9")}),
+		"oversized control": buildAr(arMember{"control.tar.gz", controlTarGz(t,
+			testControl+"X-Padding: "+strings.Repeat("a", maxControlSize)+"\n")}),
+		"missing field": buildAr(arMember{"control.tar.gz", controlTarGz(t, "Package: hello\nVersion: 1.0\n")}),
+	}
+	for name, data := range tests {
+		if _, err := ReadControl(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseControl(t *testing.T) {
+	control, err := ParseControl("# comment\n\nPackage: a\nVersion: 1\nArchitecture: all\nDescription: x\n more\n\nPackage: b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if control.Get("Package") != "a" || control.Get("Description") != "x\n more" {
+		t.Fatalf("unexpected control: %+v", control.Fields)
+	}
+
+	for _, text := range []string{
+		"",
+		" continuation first\n",
+		"Package hello\n",
+		": value\n",
+		"Package: a\nVersion: 1\n",
+	} {
+		if _, err := ParseControl(text); err == nil {
+			t.Errorf("expected error for %q", text)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Package delta 实现 bsdiff 风格的二进制差分补丁
+//
+// 算法与 bsdiff 4.3 一致（qsufsort 后缀排序 + 近似匹配），补丁格式参照 ENDSLEY/BSDIFF43：
+// 控制块、差异块、新增块交错写入同一个数据流，由于标准库不提供 bzip2 压缩，数据流改用 gzip 压缩。
+//
+//	0   16  Magic
+//	16  8   新文件长度
+//	24  ... gzip( [ctrl x,y,z][diff x 字节][extra y 字节] ... )
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// Magic 补丁文件头
+	Magic = "PKMS/BSDIFF43GZ\x00"
+	// Format 补丁格式名称，随检查更新响应下发给客户端
+	Format = "bsdiff43-gzip"
+	// MaxSize 参与差分的文件大小上限（后缀数组使用 int32 下标）
+	MaxSize = math.MaxInt32 - 1
+)
+
+// ErrCorruptPatch 补丁数据损坏
+var ErrCorruptPatch = errors.New("corrupt patch")
+
+// Diff 计算 oldData 到 newData 的差分补丁并写入 w
+func Diff(oldData, newData []byte, w io.Writer) error {
+	if len(oldData) > MaxSize || len(newData) > MaxSize {
+		return fmt.Errorf("file too large for delta: max %d bytes", MaxSize)
+	}
+
+	header := make([]byte, len(Magic)+8)
+	copy(header, Magic)
+	putOff(header[len(Magic):], int64(len(newData)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriterSize(gz, 64*1024)
+
+	I := qsufsort(oldData)
+	oldSize, newSize := len(oldData), len(newData)
+
+	var scan, pos, length int
+	var lastScan, lastPos, lastOffset int
+	ctrl := make([]byte, 24)
+
+	for scan < newSize {
+		oldScore := 0
+		scan += length
+		for scsc := scan; scan < newSize; scan++ {
+			pos, length = search(I, oldData, newData[scan:], 0, oldSize)
+
+			for ; scsc < scan+length; scsc++ {
+				if scsc+lastOffset < oldSize && oldData[scsc+lastOffset] == newData[scsc] {
+					oldScore++
+				}
+			}
+
+			if (length == oldScore && length != 0) || length > oldScore+8 {
+				break
+			}
+
+			if scan+lastOffset < oldSize && oldData[scan+lastOffset] == newData[scan] {
+				oldScore--
+			}
+		}
+
+		if length == oldScore && scan != newSize {
+			continue
+		}
+
+		// 向前扩展
+		s, sf, lenf := 0, 0, 0
+		for i := 0; lastScan+i < scan && lastPos+i < oldSize; {
+			if oldData[lastPos+i] == newData[lastScan+i] {
+				s++
+			}
+			i++
+			if s*2-i > sf*2-lenf {
+				sf = s
+				lenf = i
+			}
+		}
+
+		// 向后扩展
+		lenb := 0
+		if scan < newSize {
+			s, sb := 0, 0
+			for i := 1; scan >= lastScan+i && pos >= i; i++ {
+				if oldData[pos-i] == newData[scan-i] {
+					s++
+				}
+				if s*2-i > sb*2-lenb {
+					sb = s
+					lenb = i
+				}
+			}
+		}
+
+		// 处理重叠部分
+		if lastScan+lenf > scan-lenb {
+			overlap := (lastScan + lenf) - (scan - lenb)
+			s, ss, lens := 0, 0, 0
+			for i := 0; i < overlap; i++ {
+				if newData[lastScan+lenf-overlap+i] == oldData[lastPos+lenf-overlap+i] {
+					s++
+				}
+				if newData[scan-lenb+i] == oldData[pos-lenb+i] {
+					s--
+				}
+				if s > ss {
+					ss = s
+					lens = i + 1
+				}
+			}
+			lenf += lens - overlap
+			lenb -= lens
+		}
+
+		extraLen := (scan - lenb) - (lastScan + lenf)
+		putOff(ctrl[0:], int64(lenf))
+		putOff(ctrl[8:], int64(extraLen))
+		putOff(ctrl[16:], int64((pos-lenb)-(lastPos+lenf)))
+		if _, err := bw.Write(ctrl); err != nil {
+			return err
+		}
+
+		for i := 0; i < lenf; i++ {
+			if err := bw.WriteByte(newData[lastScan+i] - oldData[lastPos+i]); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.Write(newData[lastScan+lenf : lastScan+lenf+extraLen]); err != nil {
+			return err
+		}
+
+		lastScan = scan - lenb
+		lastPos = pos - lenb
+		lastOffset = pos - scan
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Patch 将补丁应用到 oldData，返回新文件内容
+func Patch(oldData []byte, patch io.Reader) ([]byte, error) {
+	header := make([]byte, len(Magic)+8)
+	if _, err := io.ReadFull(patch, header); err != nil {
+		return nil, ErrCorruptPatch
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, ErrCorruptPatch
+	}
+	newSize := getOff(header[len(Magic):])
+	if newSize < 0 || newSize > MaxSize {
+		return nil, ErrCorruptPatch
+	}
+
+	gz, err := gzip.NewReader(patch)
+	if err != nil {
+		return nil, ErrCorruptPatch
+	}
+	defer gz.Close()
+	r := bufio.NewReaderSize(gz, 64*1024)
+
+	newData := make([]byte, newSize)
+	oldSize := int64(len(oldData))
+	var oldPos, newPos int64
+	ctrl := make([]byte, 24)
+
+	for newPos < newSize {
+		if _, err := io.ReadFull(r, ctrl); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		diffLen, extraLen, seek := getOff(ctrl[0:]), getOff(ctrl[8:]), getOff(ctrl[16:])
+
+		if diffLen < 0 || newPos+diffLen > newSize {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(r, newData[newPos:newPos+diffLen]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		for i := int64(0); i < diffLen; i++ {
+			if oldPos+i >= 0 && oldPos+i < oldSize {
+				newData[newPos+i] += oldData[oldPos+i]
+			}
+		}
+		newPos += diffLen
+		oldPos += diffLen
+
+		if extraLen < 0 || newPos+extraLen > newSize {
+			return nil, ErrCorruptPatch
+		}
+		if _, err := io.ReadFull(r, newData[newPos:newPos+extraLen]); err != nil {
+			return nil, ErrCorruptPatch
+		}
+		newPos += extraLen
+		oldPos += seek
+	}
+
+	return newData, nil
+}
+
+// search 在后缀数组 I[st:en] 中二分查找与 target 最长的匹配
+func search(I []int32, oldData, target []byte, st, en int) (pos, length int) {
+	for en-st >= 2 {
+		x := st + (en-st)/2
+		suffix := oldData[I[x]:]
+		n := len(suffix)
+		if len(target) < n {
+			n = len(target)
+		}
+		if bytes.Compare(suffix[:n], target[:n]) < 0 {
+			st = x
+		} else {
+			en = x
+		}
+	}
+
+	x := matchLen(oldData[I[st]:], target)
+	y := matchLen(oldData[I[en]:], target)
+	if x > y {
+		return int(I[st]), x
+	}
+	return int(I[en]), y
+}
+
+func matchLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// qsufsort Larsson-Sadakane 后缀排序，返回长度为 len(buf)+1 的后缀数组
+func qsufsort(buf []byte) []int32 {
+	size := len(buf)
+	I := make([]int32, size+1)
+	V := make([]int32, size+1)
+
+	var buckets [256]int32
+	for _, c := range buf {
+		buckets[c]++
+	}
+	for i := 1; i < 256; i++ {
+		buckets[i] += buckets[i-1]
+	}
+	for i := 255; i > 0; i-- {
+		buckets[i] = buckets[i-1]
+	}
+	buckets[0] = 0
+
+	for i, c := range buf {
+		buckets[c]++
+		I[buckets[c]] = int32(i)
+	}
+	I[0] = int32(size)
+	for i, c := range buf {
+		V[i] = buckets[c]
+	}
+	V[size] = 0
+	for i := 1; i < 256; i++ {
+		if buckets[i] == buckets[i-1]+1 {
+			I[buckets[i]] = -1
+		}
+	}
+	I[0] = -1
+
+	for h := int32(1); I[0] != -int32(size+1); h += h {
+		var length int32
+		i := int32(0)
+		for i < int32(size+1) {
+			if I[i] < 0 {
+				length -= I[i]
+				i -= I[i]
+			} else {
+				if length != 0 {
+					I[i-length] = -length
+				}
+				length = V[I[i]] + 1 - i
+				split(I, V, i, length, h)
+				i += length
+				length = 0
+			}
+		}
+		if length != 0 {
+			I[i-length] = -length
+		}
+	}
+
+	for i := 0; i < size+1; i++ {
+		I[V[i]] = int32(i)
+	}
+	return I
+}
+
+func split(I, V []int32, start, length, h int32) {
+	if length < 16 {
+		var j int32
+		for k := start; k < start+length; k += j {
+			j = 1
+			x := V[I[k]+h]
+			for i := int32(1); k+i < start+length; i++ {
+				if V[I[k+i]+h] < x {
+					x = V[I[k+i]+h]
+					j = 0
+				}
+				if V[I[k+i]+h] == x {
+					I[k+j], I[k+i] = I[k+i], I[k+j]
+					j++
+				}
+			}
+			for i := int32(0); i < j; i++ {
+				V[I[k+i]] = k + j - 1
+			}
+			if j == 1 {
+				I[k] = -1
+			}
+		}
+		return
+	}
+
+	x := V[I[start+length/2]+h]
+	var jj, kk int32
+	for i := start; i < start+length; i++ {
+		if V[I[i]+h] < x {
+			jj++
+		}
+		if V[I[i]+h] == x {
+			kk++
+		}
+	}
+	jj += start
+	kk += jj
+
+	i, j, k := start, int32(0), int32(0)
+	for i < jj {
+		switch {
+		case V[I[i]+h] < x:
+			i++
+		case V[I[i]+h] == x:
+			I[i], I[jj+j] = I[jj+j], I[i]
+			j++
+		default:
+			I[i], I[kk+k] = I[kk+k], I[i]
+			k++
+		}
+	}
+	for jj+j < kk {
+		if V[I[jj+j]+h] == x {
+			j++
+		} else {
+			I[jj+j], I[kk+k] = I[kk+k], I[jj+j]
+			k++
+		}
+	}
+
+	if jj > start {
+		split(I, V, start, jj-start, h)
+	}
+	for i := int32(0); i < kk-jj; i++ {
+		V[I[jj+i]] = kk - 1
+	}
+	if jj == kk-1 {
+		I[jj] = -1
+	}
+	if start+length > kk {
+		split(I, V, kk, start+length-kk, h)
+	}
+}
+
+// putOff 以 bsdiff 的符号-幅值小端格式写入 64 位偏移
+func putOff(buf []byte, x int64) {
+	y := x
+	if x < 0 {
+		y = -x
+	}
+	for i := 0; i < 8; i++ {
+		buf[i] = byte(y >> (8 * i))
+	}
+	if x < 0 {
+		buf[7] |= 0x80
+	}
+}
+
+func getOff(buf []byte) int64 {
+	y := int64(buf[7] & 0x7f)
+	for i := 6; i >= 0; i-- {
+		y = y<<8 | int64(buf[i])
+	}
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
@@ -0,0 +1,106 @@
+package delta
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func roundTrip(t *testing.T, oldData, newData []byte) []byte {
+	t.Helper()
+	var patch bytes.Buffer
+	if err := Diff(oldData, newData, &patch); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Patch(oldData, bytes.NewReader(patch.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, newData) {
+		t.Fatalf("patched data mismatch: got %d bytes, want %d bytes", len(got), len(newData))
+	}
+	return patch.Bytes()
+}
+
+func randomData(r *rand.Rand, n int) []byte {
+	data := make([]byte, n)
+	r.Read(data)
+	return data
+}
+
+func TestRoundTripIdentical(t *testing.T) {
+	data := randomData(rand.New(rand.NewSource(1)), 64<<10)
+	patch := roundTrip(t, data, data)
+	if len(patch) > 1024 {
+		t.Fatalf("patch for identical input is %d bytes", len(patch))
+	}
+}
+
+func TestRoundTripEmpty(t *testing.T) {
+	data := randomData(rand.New(rand.NewSource(2)), 4096)
+	roundTrip(t, nil, data)
+	roundTrip(t, data, nil)
+	roundTrip(t, nil, nil)
+}
+
+func TestRoundTripRandomEdits(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 20; i++ {
+		oldData := randomData(r, 1+r.Intn(32<<10))
+		newData := append([]byte(nil), oldData...)
+		for edits := r.Intn(8); edits >= 0; edits-- {
+			pos := r.Intn(len(newData) + 1)
+			switch r.Intn(3) {
+			case 0: // 修改
+				if pos < len(newData) {
+					newData[pos] ^= byte(1 + r.Intn(255))
+				}
+			case 1: // 插入
+				newData = append(newData[:pos], append(randomData(r, r.Intn(256)), newData[pos:]...)...)
+			case 2: // 删除
+				end := pos + r.Intn(256)
+				if end > len(newData) {
+					end = len(newData)
+				}
+				newData = append(newData[:pos], newData[end:]...)
+			}
+		}
+		patch := roundTrip(t, oldData, newData)
+		if len(newData) > 4096 && len(patch) >= len(newData) {
+			t.Fatalf("patch (%d bytes) is not smaller than the new file (%d bytes)", len(patch), len(newData))
+		}
+	}
+}
+
+func TestPatchRejectsCorruptPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	oldData := randomData(r, 8<<10)
+	newData := append(randomData(r, 1024), oldData...)
+	var buf bytes.Buffer
+	if err := Diff(oldData, newData, &buf); err != nil {
+		t.Fatal(err)
+	}
+	patch := buf.Bytes()
+	header := len(Magic) + 8
+
+	badMagic := append([]byte(nil), patch...)
+	badMagic[0] ^= 0xff
+	negativeSize := append([]byte(nil), patch...)
+	negativeSize[header-1] |= 0x80
+
+	tests := map[string][]byte{
+		"empty":         nil,
+		"short header":  patch[:header-1],
+		"bad magic":     badMagic,
+		"negative size": negativeSize,
+		"no stream":     patch[:header],
+		"bad stream":    append(append([]byte(nil), patch[:header]...), "not gzip"...),
+		"truncated":     patch[:header+(len(patch)-header)/2],
+	}
+	for name, data := range tests {
+		if _, err := Patch(oldData, bytes.NewReader(data)); !errors.Is(err, ErrCorruptPatch) {
+			t.Errorf("%s: expected ErrCorruptPatch, got %v", name, err)
+		}
+	}
+}
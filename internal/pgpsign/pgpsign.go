@@ -0,0 +1,127 @@
+// Package pgpsign 使用 OpenPGP 密钥为软件仓库的索引文件签名（APT 的 InRelease/Release.gpg、YUM 的 repomd.xml.asc）
+package pgpsign
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// 新生成密钥的 RSA 位数
+const rsaBits = 3072
+
+// Signer 持有已解密的签名私钥
+type Signer struct {
+	entity *openpgp.Entity
+}
+
+// LoadOrCreate 从 ASCII armor 格式的私钥文件加载签名密钥，文件不存在时生成新密钥并写入该文件
+// 受密码保护的私钥使用 passphrase 解密；新生成的密钥不加密保存，文件权限为 0600
+func LoadOrCreate(path, passphrase, name string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(path, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %s: %v", path, err)
+	}
+	if len(entities) == 0 || entities[0].PrivateKey == nil {
+		return nil, fmt.Errorf("no private key found in %s", path)
+	}
+	entity := entities[0]
+	if entity.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return nil, fmt.Errorf("signing key %s is encrypted but no passphrase is configured", path)
+		}
+		if err := entity.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key: %v", err)
+		}
+	}
+	return &Signer{entity: entity}, nil
+}
+
+func create(path, name string) (*Signer, error) {
+	entity, err := openpgp.NewEntity(name, "", "", &packet.Config{RSABits: rsaBits})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return nil, err
+	}
+	return &Signer{entity: entity}, nil
+}
+
+// Fingerprint 主密钥指纹（40 位十六进制大写）
+func (s *Signer) Fingerprint() string {
+	return strings.ToUpper(fmt.Sprintf("%x", s.entity.PrimaryKey.Fingerprint))
+}
+
+// PublicKey ASCII armor 格式的公钥，供客户端导入
+func (s *Signer) PublicKey() ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.entity.Serialize(w); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ClearSign 生成内嵌签名的明文文档（APT 的 InRelease）
+func (s *Signer) ClearSign(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := clearsign.Encode(&buf, s.entity.PrivateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DetachSign 生成 ASCII armor 格式的分离签名（APT 的 Release.gpg、YUM 的 repomd.xml.asc）
+func (s *Signer) DetachSign(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&buf, s.entity, bytes.NewReader(data), nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,492 @@
+// Package rpm 读取 RPM 软件包的头部信息
+//
+// .rpm 文件依次为 96 字节的 lead、签名头部（按 8 字节对齐）、主头部和压缩的 payload，
+// 读取时只解析到主头部为止，不读取 payload。
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	leadSize       = 96
+	leadTypeSource = 1
+	maxIndexCount  = 1 << 16
+	maxStoreSize   = 64 << 20 // 头部数据区大小上限
+	maxChangelogs  = 10       // 只保留最近的更新日志
+)
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+// ErrNotRPM 文件不是 RPM 格式
+var ErrNotRPM = errors.New("not an rpm package")
+
+// 头部数据类型
+const (
+	typeInt8        = 2
+	typeInt16       = 3
+	typeInt32       = 4
+	typeInt64       = 5
+	typeString      = 6
+	typeStringArray = 8
+	typeI18NString  = 9
+)
+
+// 主头部中用到的标签
+const (
+	tagName            = 1000
+	tagVersion         = 1001
+	tagRelease         = 1002
+	tagEpoch           = 1003
+	tagSummary         = 1004
+	tagDescription     = 1005
+	tagBuildTime       = 1006
+	tagBuildHost       = 1007
+	tagSize            = 1009
+	tagVendor          = 1011
+	tagLicense         = 1014
+	tagPackager        = 1015
+	tagGroup           = 1016
+	tagURL             = 1020
+	tagArch            = 1022
+	tagOldFilenames    = 1027
+	tagFileModes       = 1030
+	tagFileFlags       = 1037
+	tagSourceRPM       = 1044
+	tagArchiveSize     = 1046
+	tagProvideName     = 1047
+	tagRequireFlags    = 1048
+	tagRequireName     = 1049
+	tagRequireVersion  = 1050
+	tagConflictFlags   = 1053
+	tagConflictName    = 1054
+	tagConflictVersion = 1055
+	tagChangelogTime   = 1080
+	tagChangelogName   = 1081
+	tagChangelogText   = 1082
+	tagObsoleteName    = 1090
+	tagProvideFlags    = 1112
+	tagProvideVersion  = 1113
+	tagObsoleteFlags   = 1114
+	tagObsoleteVersion = 1115
+	tagDirIndexes      = 1116
+	tagBaseNames       = 1117
+	tagDirNames        = 1118
+	tagLongSize        = 5009
+
+	// 签名头部中的 payload 大小
+	sigTagPayloadSize     = 1007
+	sigTagLongArchiveSize = 271
+)
+
+// 依赖标志位
+const (
+	senseLess       = 1 << 1
+	senseGreater    = 1 << 2
+	senseEqual      = 1 << 3
+	sensePrereq     = 1 << 6
+	senseScriptPre  = 1 << 9
+	senseScriptPost = 1 << 10
+	senseRPMLib     = 1 << 24
+
+	fileFlagGhost = 1 << 6
+	fileModeDir   = 0o040000
+	fileModeType  = 0o170000
+)
+
+// Dependency 依赖关系（provides/requires/conflicts/obsoletes 中的一项）
+type Dependency struct {
+	Name    string `json:"name"`
+	Flags   string `json:"flags,omitempty"` // EQ/LT/GT/LE/GE，无版本约束时为空
+	Epoch   string `json:"epoch,omitempty"`
+	Version string `json:"ver,omitempty"`
+	Release string `json:"rel,omitempty"`
+	Pre     bool   `json:"pre,omitempty"` // 安装前需要满足的依赖
+}
+
+// File 软件包中的文件，Type 为 dir/ghost，普通文件为空
+type File struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"`
+}
+
+// Changelog 更新日志
+type Changelog struct {
+	Author string `json:"author"`
+	Date   int64  `json:"date"`
+	Text   string `json:"text"`
+}
+
+// Package 生成 YUM 仓库元数据（primary/filelists/other）所需的头部信息
+type Package struct {
+	Name          string       `json:"name"`
+	Epoch         string       `json:"epoch"`
+	Version       string       `json:"version"`
+	Release       string       `json:"release"`
+	Arch          string       `json:"arch"`
+	Summary       string       `json:"summary,omitempty"`
+	Description   string       `json:"description,omitempty"`
+	Packager      string       `json:"packager,omitempty"`
+	URL           string       `json:"url,omitempty"`
+	License       string       `json:"license,omitempty"`
+	Vendor        string       `json:"vendor,omitempty"`
+	Group         string       `json:"group,omitempty"`
+	BuildHost     string       `json:"buildhost,omitempty"`
+	SourceRPM     string       `json:"sourcerpm,omitempty"`
+	BuildTime     int64        `json:"buildtime,omitempty"`
+	InstalledSize int64        `json:"installed_size,omitempty"`
+	ArchiveSize   int64        `json:"archive_size,omitempty"`
+	HeaderStart   int64        `json:"header_start"` // 主头部在文件中的起止位置
+	HeaderEnd     int64        `json:"header_end"`
+	Provides      []Dependency `json:"provides,omitempty"`
+	Requires      []Dependency `json:"requires,omitempty"`
+	Conflicts     []Dependency `json:"conflicts,omitempty"`
+	Obsoletes     []Dependency `json:"obsoletes,omitempty"`
+	Files         []File       `json:"files,omitempty"`
+	Changelogs    []Changelog  `json:"changelogs,omitempty"` // 按时间从旧到新
+}
+
+// EVR epoch:version-release，epoch 为 0 时省略
+func (p *Package) EVR() string {
+	evr := p.Version + "-" + p.Release
+	if p.Epoch != "" && p.Epoch != "0" {
+		evr = p.Epoch + ":" + evr
+	}
+	return evr
+}
+
+// FileName 按 RPM 惯例命名的文件名 name-version-release.arch.rpm
+func (p *Package) FileName() string {
+	return fmt.Sprintf("%s-%s-%s.%s.rpm", p.Name, p.Version, p.Release, p.Arch)
+}
+
+// Read 从 .rpm 文件读取头部信息
+func Read(r io.Reader) (*Package, error) {
+	br := bufio.NewReader(r)
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(br, lead); err != nil || !bytes.Equal(lead[:4], leadMagic) {
+		return nil, ErrNotRPM
+	}
+
+	signature, err := readHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature header: %v", ErrNotRPM, err)
+	}
+	// 签名头部按 8 字节对齐
+	padding := (8 - signature.size%8) % 8
+	if _, err := br.Discard(int(padding)); err != nil {
+		return nil, err
+	}
+
+	main, err := readHeader(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %v", ErrNotRPM, err)
+	}
+
+	p := &Package{
+		Name:        main.string(tagName),
+		Epoch:       "0",
+		Version:     main.string(tagVersion),
+		Release:     main.string(tagRelease),
+		Arch:        main.string(tagArch),
+		Summary:     main.string(tagSummary),
+		Description: main.string(tagDescription),
+		Packager:    main.string(tagPackager),
+		URL:         main.string(tagURL),
+		License:     main.string(tagLicense),
+		Vendor:      main.string(tagVendor),
+		Group:       main.string(tagGroup),
+		BuildHost:   main.string(tagBuildHost),
+		SourceRPM:   main.string(tagSourceRPM),
+		BuildTime:   main.int(tagBuildTime),
+		HeaderStart: leadSize + signature.size + padding,
+	}
+	p.HeaderEnd = p.HeaderStart + main.size
+	if p.Name == "" || p.Version == "" {
+		return nil, fmt.Errorf("%w: missing name or version", ErrNotRPM)
+	}
+	if epochs := main.ints(tagEpoch); len(epochs) > 0 {
+		p.Epoch = strconv.FormatInt(epochs[0], 10)
+	}
+	// lead 中的类型：0 为二进制包，1 为源码包
+	if binary.BigEndian.Uint16(lead[6:8]) == leadTypeSource {
+		p.Arch = "src"
+	}
+
+	p.InstalledSize = main.int(tagLongSize)
+	if p.InstalledSize == 0 {
+		p.InstalledSize = main.int(tagSize)
+	}
+	p.ArchiveSize = signature.int(sigTagLongArchiveSize)
+	if p.ArchiveSize == 0 {
+		p.ArchiveSize = signature.int(sigTagPayloadSize)
+	}
+	if p.ArchiveSize == 0 {
+		p.ArchiveSize = main.int(tagArchiveSize)
+	}
+
+	p.Provides = main.dependencies(tagProvideName, tagProvideFlags, tagProvideVersion)
+	p.Requires = main.dependencies(tagRequireName, tagRequireFlags, tagRequireVersion)
+	p.Conflicts = main.dependencies(tagConflictName, tagConflictFlags, tagConflictVersion)
+	p.Obsoletes = main.dependencies(tagObsoleteName, tagObsoleteFlags, tagObsoleteVersion)
+	p.Files = main.files()
+	p.Changelogs = main.changelogs()
+	return p, nil
+}
+
+type indexEntry struct {
+	tag, typ, offset, count int32
+}
+
+type header struct {
+	entries map[int32]indexEntry
+	store   []byte
+	size    int64 // 头部总长度（含 16 字节的头部信息和索引）
+}
+
+func readHeader(r io.Reader) (*header, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(intro[:4], headerMagic) {
+		return nil, errors.New("bad header magic")
+	}
+	count := binary.BigEndian.Uint32(intro[8:12])
+	storeSize := binary.BigEndian.Uint32(intro[12:16])
+	if count > maxIndexCount || storeSize > maxStoreSize {
+		return nil, errors.New("header too large")
+	}
+
+	index := make([]byte, int(count)*16)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, err
+	}
+	// 数据区按实际读到的内容增长，截断的文件不会按声明的大小分配内存
+	store, err := io.ReadAll(io.LimitReader(r, int64(storeSize)))
+	if err != nil {
+		return nil, err
+	}
+	if len(store) != int(storeSize) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	h := &header{
+		entries: make(map[int32]indexEntry, count),
+		store:   store,
+		size:    16 + int64(count)*16 + int64(storeSize),
+	}
+	for i := 0; i < int(count); i++ {
+		b := index[i*16 : i*16+16]
+		entry := indexEntry{
+			tag:    int32(binary.BigEndian.Uint32(b[0:4])),
+			typ:    int32(binary.BigEndian.Uint32(b[4:8])),
+			offset: int32(binary.BigEndian.Uint32(b[8:12])),
+			count:  int32(binary.BigEndian.Uint32(b[12:16])),
+		}
+		if entry.offset < 0 || int(entry.offset) > len(h.store) || entry.count < 0 {
+			return nil, fmt.Errorf("invalid index entry for tag %d", entry.tag)
+		}
+		h.entries[entry.tag] = entry
+	}
+	return h, nil
+}
+
+// strings 字符串类型标签的值，I18N 字符串只取默认语言
+func (h *header) strings(tag int32) []string {
+	entry, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+	count := int(entry.count)
+	switch entry.typ {
+	case typeString:
+		count = 1
+	case typeStringArray, typeI18NString:
+	default:
+		return nil
+	}
+
+	// 每个字符串至少占 1 字节，count 来自文件，不能直接作为容量
+	data := h.store[entry.offset:]
+	values := make([]string, 0, min(count, len(data)))
+	for i := 0; i < count; i++ {
+		end := bytes.IndexByte(data, 0)
+		if end < 0 {
+			break
+		}
+		values = append(values, string(data[:end]))
+		data = data[end+1:]
+	}
+	return values
+}
+
+func (h *header) string(tag int32) string {
+	if values := h.strings(tag); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ints 整数类型标签的值，INT16/INT32 按无符号数读取
+func (h *header) ints(tag int32) []int64 {
+	entry, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+	var width int
+	switch entry.typ {
+	case typeInt8:
+		width = 1
+	case typeInt16:
+		width = 2
+	case typeInt32:
+		width = 4
+	case typeInt64:
+		width = 8
+	default:
+		return nil
+	}
+	data := h.store[entry.offset:]
+	if int(entry.count)*width > len(data) {
+		return nil
+	}
+
+	values := make([]int64, entry.count)
+	for i := range values {
+		b := data[i*width : (i+1)*width]
+		switch width {
+		case 1:
+			values[i] = int64(b[0])
+		case 2:
+			values[i] = int64(binary.BigEndian.Uint16(b))
+		case 4:
+			values[i] = int64(binary.BigEndian.Uint32(b))
+		case 8:
+			values[i] = int64(binary.BigEndian.Uint64(b))
+		}
+	}
+	return values
+}
+
+func (h *header) int(tag int32) int64 {
+	if values := h.ints(tag); len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
+
+// dependencies 读取一组依赖关系，跳过 rpmlib() 内部依赖和重复项
+func (h *header) dependencies(nameTag, flagsTag, versionTag int32) []Dependency {
+	names := h.strings(nameTag)
+	flags := h.ints(flagsTag)
+	versions := h.strings(versionTag)
+
+	seen := make(map[Dependency]bool)
+	var dependencies []Dependency
+	for i, name := range names {
+		var flag int64
+		if i < len(flags) {
+			flag = flags[i]
+		}
+		if flag&senseRPMLib != 0 || strings.HasPrefix(name, "rpmlib(") {
+			continue
+		}
+
+		dependency := Dependency{Name: name, Pre: flag&(sensePrereq|senseScriptPre|senseScriptPost) != 0}
+		if i < len(versions) && versions[i] != "" {
+			dependency.Flags = senseFlags(flag)
+			dependency.Epoch, dependency.Version, dependency.Release = parseEVR(versions[i])
+		}
+		if seen[dependency] {
+			continue
+		}
+		seen[dependency] = true
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies
+}
+
+func senseFlags(flag int64) string {
+	switch flag & (senseLess | senseGreater | senseEqual) {
+	case senseEqual:
+		return "EQ"
+	case senseLess:
+		return "LT"
+	case senseGreater:
+		return "GT"
+	case senseLess | senseEqual:
+		return "LE"
+	case senseGreater | senseEqual:
+		return "GE"
+	default:
+		return ""
+	}
+}
+
+// parseEVR 拆分 [epoch:]version[-release]，没有 epoch 时为 0
+func parseEVR(evr string) (epoch, version, release string) {
+	epoch = "0"
+	if e, rest, ok := strings.Cut(evr, ":"); ok {
+		epoch, evr = e, rest
+	}
+	version = evr
+	if i := strings.LastIndexByte(evr, '-'); i >= 0 {
+		version, release = evr[:i], evr[i+1:]
+	}
+	return epoch, version, release
+}
+
+// files 文件列表：新格式由 DIRNAMES/BASENAMES/DIRINDEXES 组合，旧格式为 OLDFILENAMES
+func (h *header) files() []File {
+	var paths []string
+	if baseNames := h.strings(tagBaseNames); len(baseNames) > 0 {
+		dirNames := h.strings(tagDirNames)
+		dirIndexes := h.ints(tagDirIndexes)
+		for i, baseName := range baseNames {
+			if i >= len(dirIndexes) || int(dirIndexes[i]) >= len(dirNames) {
+				break
+			}
+			paths = append(paths, dirNames[dirIndexes[i]]+baseName)
+		}
+	} else {
+		paths = h.strings(tagOldFilenames)
+	}
+
+	modes := h.ints(tagFileModes)
+	flags := h.ints(tagFileFlags)
+	files := make([]File, len(paths))
+	for i, path := range paths {
+		files[i].Path = path
+		switch {
+		case i < len(flags) && flags[i]&fileFlagGhost != 0:
+			files[i].Type = "ghost"
+		case i < len(modes) && modes[i]&fileModeType == fileModeDir:
+			files[i].Type = "dir"
+		}
+	}
+	return files
+}
+
+// changelogs 最近的更新日志，头部中按从新到旧存放
+func (h *header) changelogs() []Changelog {
+	times := h.ints(tagChangelogTime)
+	names := h.strings(tagChangelogName)
+	texts := h.strings(tagChangelogText)
+
+	count := min(len(times), len(names), len(texts), maxChangelogs)
+	changelogs := make([]Changelog, 0, count)
+	for i := count - 1; i >= 0; i-- {
+		changelogs = append(changelogs, Changelog{Author: names[i], Date: times[i], Text: texts[i]})
+	}
+	return changelogs
+}
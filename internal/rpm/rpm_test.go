@@ -0,0 +1,202 @@
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// testEntry 构造头部时的一个标签，value 为 string、[]string 或 []uint32
+type testEntry struct {
+	tag   int32
+	value interface{}
+}
+
+// buildHeader 按 RPM 头部格式序列化标签
+func buildHeader(entries []testEntry) []byte {
+	var index, store bytes.Buffer
+	for _, e := range entries {
+		var typ, count int
+		offset := store.Len()
+		switch v := e.value.(type) {
+		case string:
+			typ, count = typeString, 1
+			store.WriteString(v + "\x00")
+		case []string:
+			typ, count = typeStringArray, len(v)
+			for _, s := range v {
+				store.WriteString(s + "\x00")
+			}
+		case []uint32:
+			for store.Len()%4 != 0 {
+				store.WriteByte(0)
+			}
+			offset = store.Len()
+			typ, count = typeInt32, len(v)
+			for _, n := range v {
+				_ = binary.Write(&store, binary.BigEndian, n)
+			}
+		}
+		_ = binary.Write(&index, binary.BigEndian, []int32{e.tag, int32(typ), int32(offset), int32(count)})
+	}
+
+	var buf bytes.Buffer
+	buf.Write(headerMagic)
+	buf.Write(make([]byte, 4))
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{uint32(len(entries)), uint32(store.Len())})
+	buf.Write(index.Bytes())
+	buf.Write(store.Bytes())
+	return buf.Bytes()
+}
+
+// buildRPM 由 lead、签名头部（补齐到 8 字节）和主头部组成，不含 payload
+func buildRPM(signature, main []testEntry) []byte {
+	var buf bytes.Buffer
+	lead := make([]byte, leadSize)
+	copy(lead, leadMagic)
+	buf.Write(lead)
+	buf.Write(buildHeader(signature))
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(buildHeader(main))
+	return buf.Bytes()
+}
+
+func validRPM() []byte {
+	return buildRPM(
+		[]testEntry{{sigTagPayloadSize, []uint32{4096}}},
+		[]testEntry{
+			{tagName, "hello"},
+			{tagVersion, "1.2.3"},
+			{tagRelease, "1.el9"},
+			{tagEpoch, []uint32{2}},
+			{tagArch, "x86_64"},
+			{tagRequireName, []string{"rpmlib(PayloadIsZstd)", "glibc"}},
+			{tagRequireFlags, []uint32{senseRPMLib, senseGreater | senseEqual}},
+			{tagRequireVersion, []string{"5.4.18-1", "2.34"}},
+			{tagBaseNames, []string{"bin", "hello"}},
+			{tagDirNames, []string{"/usr/", "/usr/bin/"}},
+			{tagDirIndexes, []uint32{0, 1}},
+			{tagFileModes, []uint32{fileModeDir | 0o755, 0o100755}},
+		},
+	)
+}
+
+func TestRead(t *testing.T) {
+	data := validRPM()
+	p, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "hello" || p.EVR() != "2:1.2.3-1.el9" || p.Arch != "x86_64" || p.ArchiveSize != 4096 {
+		t.Fatalf("unexpected package: %+v", p)
+	}
+	if p.HeaderEnd != int64(len(data)) {
+		t.Fatalf("header end = %d, want %d", p.HeaderEnd, len(data))
+	}
+	if len(p.Requires) != 1 || p.Requires[0] != (Dependency{Name: "glibc", Flags: "GE", Epoch: "0", Version: "2.34"}) {
+		t.Fatalf("unexpected requires: %+v", p.Requires)
+	}
+	if len(p.Files) != 2 || p.Files[0] != (File{Path: "/usr/bin", Type: "dir"}) || p.Files[1] != (File{Path: "/usr/bin/hello"}) {
+		t.Fatalf("unexpected files: %+v", p.Files)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	data := validRPM()
+	for n := 0; n < len(data); n++ {
+		if _, err := Read(bytes.NewReader(data[:n])); err == nil {
+			t.Fatalf("expected error for %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestReadRejectsInvalidFiles(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":         nil,
+		"bad lead":      bytes.Repeat([]byte{0}, 200),
+		"missing name":  buildRPM(nil, []testEntry{{tagVersion, "1.0"}}),
+		"bad signature": append(append(make([]byte, 0, leadSize+16), validRPM()[:leadSize]...), bytes.Repeat([]byte{0xff}, 16)...),
+	}
+	for name, data := range tests {
+		if _, err := Read(bytes.NewReader(data)); !errors.Is(err, ErrNotRPM) {
+			t.Errorf("%s: expected ErrNotRPM, got %v", name, err)
+		}
+	}
+}
+
+// headerIntro 只有头部信息，声明的索引数量和数据区大小由参数指定
+func headerIntro(count, storeSize uint32) []byte {
+	var buf bytes.Buffer
+	buf.Write(headerMagic)
+	buf.Write(make([]byte, 4))
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{count, storeSize})
+	return buf.Bytes()
+}
+
+func TestReadHeaderRejectsOversizedHeader(t *testing.T) {
+	for _, intro := range [][]byte{
+		headerIntro(maxIndexCount+1, 0),
+		headerIntro(0, maxStoreSize+1),
+		headerIntro(0xffffffff, 0xffffffff),
+	} {
+		if _, err := readHeader(bytes.NewReader(intro)); err == nil {
+			t.Fatal("expected error for oversized header")
+		}
+	}
+}
+
+func TestReadHeaderTruncatedStore(t *testing.T) {
+	// 声明了最大的数据区但只有几个字节，不应按声明的大小分配
+	data := append(headerIntro(0, maxStoreSize), "short"...)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := readHeader(bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+	if err == nil {
+		t.Fatal("expected error for truncated store")
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("allocated %d bytes for a truncated header", allocated)
+	}
+}
+
+func TestReadHeaderRejectsOutOfRangeOffset(t *testing.T) {
+	var data bytes.Buffer
+	data.Write(headerIntro(1, 4))
+	_ = binary.Write(&data, binary.BigEndian, []int32{tagName, typeString, 5, 1})
+	data.WriteString("abc\x00")
+	if _, err := readHeader(&data); err == nil {
+		t.Fatal("expected error for offset beyond store")
+	}
+}
+
+func TestHostileCounts(t *testing.T) {
+	// 标签声明的数量远大于数据区，读取时不能按声明的数量分配
+	var data bytes.Buffer
+	data.Write(headerIntro(3, 8))
+	_ = binary.Write(&data, binary.BigEndian, []int32{tagBaseNames, typeStringArray, 0, 0x7fffffff})
+	_ = binary.Write(&data, binary.BigEndian, []int32{tagDirIndexes, typeInt32, 0, 0x7fffffff})
+	_ = binary.Write(&data, binary.BigEndian, []int32{tagChangelogText, typeI18NString, 4, 0x7fffffff})
+	data.WriteString("a\x00b\x00c\x00d\x00")
+
+	h, err := readHeader(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := h.strings(tagBaseNames); len(names) != 4 {
+		t.Fatalf("unexpected names: %q", names)
+	}
+	if indexes := h.ints(tagDirIndexes); indexes != nil {
+		t.Fatalf("expected no indexes, got %d", len(indexes))
+	}
+	if files := h.files(); len(files) != 0 {
+		t.Fatalf("expected no files, got %+v", files)
+	}
+	if changelogs := h.changelogs(); len(changelogs) != 0 {
+		t.Fatalf("expected no changelogs, got %+v", changelogs)
+	}
+}
@@ -0,0 +1,265 @@
+package versioning
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 版本号排序方案，按包配置（packages.version_scheme）
+const (
+	SchemeAuto    = "auto"    // 自动：能解析为 SemVer 则按 SemVer，否则按整数/点分段比较
+	SchemeSemVer  = "semver"  // 语义化版本 SemVer 2.0
+	SchemeInteger = "integer" // 整数版本号（如 Android versionCode）
+)
+
+// Schemes 所有支持的版本号方案
+var Schemes = []string{SchemeAuto, SchemeSemVer, SchemeInteger}
+
+// IsValidScheme 判断版本号方案是否受支持
+func IsValidScheme(scheme string) bool {
+	for _, s := range Schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// SemVer 语义化版本 https://semver.org/spec/v2.0.0.html
+type SemVer struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      string
+}
+
+// ParseSemVer 解析语义化版本号，允许 "v" 前缀，缺省的 minor/patch 视为 0
+func ParseSemVer(v string) (*SemVer, error) {
+	s := strings.TrimSpace(v)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V")
+	if s == "" {
+		return nil, errors.New("empty version")
+	}
+
+	result := &SemVer{}
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		result.Build = s[i+1:]
+		if !validIdentifiers(result.Build) {
+			return nil, fmt.Errorf("invalid build metadata in %q", v)
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre := s[i+1:]
+		if !validIdentifiers(pre) {
+			return nil, fmt.Errorf("invalid pre-release in %q", v)
+		}
+		result.Prerelease = strings.Split(pre, ".")
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("too many version components in %q", v)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version component %q in %q", part, v)
+		}
+		numbers[i] = n
+	}
+	result.Major, result.Minor, result.Patch = numbers[0], numbers[1], numbers[2]
+	return result, nil
+}
+
+// Compare 比较两个语义化版本，返回 1 表示 v > o，-1 表示 v < o，0 表示优先级相同（忽略构建元数据）
+func (v *SemVer) Compare(o *SemVer) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// 有预发布标识的版本优先级低于正式版本
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrereleaseIdentifier(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(o.Prerelease)))
+}
+
+// IsPrerelease 是否为预发布版本
+func (v *SemVer) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// String 返回规范化的版本字符串
+func (v *SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// ParseInteger 解析整数版本号（Android versionCode 等）
+func ParseInteger(v string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+}
+
+// Compare 按指定方案比较两个版本号，返回 1 表示 a > b，-1 表示 a < b，0 表示相等
+// 版本号无法按指定方案解析时，退化为逐段比较，保证总能给出确定的顺序
+func Compare(scheme, a, b string) int {
+	switch scheme {
+	case SchemeInteger:
+		if x, err := ParseInteger(a); err == nil {
+			if y, err := ParseInteger(b); err == nil {
+				return compareInt(x, y)
+			}
+		}
+	case SchemeSemVer, SchemeAuto, "":
+		if x, err := ParseSemVer(a); err == nil {
+			if y, err := ParseSemVer(b); err == nil {
+				return x.Compare(y)
+			}
+		}
+		if scheme != SchemeSemVer {
+			if x, err := ParseInteger(a); err == nil {
+				if y, err := ParseInteger(b); err == nil {
+					return compareInt(x, y)
+				}
+			}
+		}
+	}
+	return compareSegments(a, b)
+}
+
+// IsPrerelease 判断版本号是否为预发布版本（仅 SemVer 可识别）
+func IsPrerelease(v string) bool {
+	sv, err := ParseSemVer(v)
+	return err == nil && sv.IsPrerelease()
+}
+
+// compareSegments 按点分段比较，数字段按数值比较，其余按字符串比较
+func compareSegments(v1, v2 string) int {
+	if v1 == v2 {
+		return 0
+	}
+
+	parts1 := strings.Split(v1, ".")
+	parts2 := strings.Split(v2, ".")
+
+	maxLen := len(parts1)
+	if len(parts2) > maxLen {
+		maxLen = len(parts2)
+	}
+
+	for i := 0; i < maxLen; i++ {
+		var n1, n2 int
+		var err1, err2 error
+
+		// 获取第i部分的数值，如果不存在则为0
+		if i < len(parts1) {
+			n1, err1 = strconv.Atoi(parts1[i])
+		}
+		if i < len(parts2) {
+			n2, err2 = strconv.Atoi(parts2[i])
+		}
+
+		// 如果解析失败，使用字符串比较
+		if err1 != nil || err2 != nil {
+			part1 := ""
+			part2 := ""
+			if i < len(parts1) {
+				part1 = parts1[i]
+			}
+			if i < len(parts2) {
+				part2 = parts2[i]
+			}
+			if c := strings.Compare(part1, part2); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		if c := compareInt(int64(n1), int64(n2)); c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+// comparePrereleaseIdentifier 数字标识按数值比较且低于字母数字标识，其余按 ASCII 比较
+func comparePrereleaseIdentifier(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return compareUint(x, y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func validIdentifiers(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package versioning
+
+import "testing"
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"V1.2.3", "1.2.3"},
+		{" 1.2.3 ", "1.2.3"},
+		{"1", "1.0.0"},
+		{"1.2", "1.2.0"},
+		{"1.2.3-rc.1", "1.2.3-rc.1"},
+		{"1.2.3+build.5", "1.2.3+build.5"},
+		{"v2.0.0-beta.2+exp.sha.5114f85", "2.0.0-beta.2+exp.sha.5114f85"},
+		{"1.0.0-x-y-z.--", "1.0.0-x-y-z.--"},
+	}
+	for _, tt := range tests {
+		v, err := ParseSemVer(tt.in)
+		if err != nil {
+			t.Errorf("ParseSemVer(%q): %v", tt.in, err)
+			continue
+		}
+		if got := v.String(); got != tt.want {
+			t.Errorf("ParseSemVer(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSemVerInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"v",
+		"1.2.3.4",
+		"1.x.3",
+		"-1.2.3",
+		"1.2.3-",
+		"1.2.3-rc..1",
+		"1.2.3-rc_1",
+		"1.2.3+",
+		"1.2.3+build!",
+		"1..3",
+		"release",
+	} {
+		if _, err := ParseSemVer(in); err == nil {
+			t.Errorf("ParseSemVer(%q): expected error", in)
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	// 按优先级从低到高排列（semver.org 第 11 节的示例）
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"v1.10.0",
+		"2.0.0",
+	}
+	for _, scheme := range []string{SchemeSemVer, SchemeAuto} {
+		for i := range ordered {
+			for j := range ordered {
+				want := compareInt(int64(i), int64(j))
+				if got := Compare(scheme, ordered[i], ordered[j]); got != want {
+					t.Errorf("Compare(%s, %q, %q) = %d, want %d", scheme, ordered[i], ordered[j], got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		scheme string
+		a, b   string
+		want   int
+	}{
+		// 构建元数据不影响优先级，"v" 前缀与缺省段等价
+		{SchemeSemVer, "1.0.0+build.1", "1.0.0+build.2", 0},
+		{SchemeSemVer, "v1.2", "1.2.0", 0},
+		{SchemeAuto, "1.0.0+20230101", "1.0.0", 0},
+		// 整数方案按数值比较，而不是按字符串
+		{SchemeInteger, "10", "9", 1},
+		{SchemeInteger, "100", "1000", -1},
+		{SchemeInteger, " 42 ", "42", 0},
+		// auto 方案下无法解析为 SemVer 的纯数字按整数比较
+		{SchemeAuto, "20240101", "9", 1},
+		// 无法按方案解析时逐段比较
+		{SchemeInteger, "1.10", "1.9", 1},
+		{SchemeSemVer, "1.2.3.4", "1.2.3.10", -1},
+		{SchemeSemVer, "release-b", "release-a", 1},
+		{SchemeAuto, "", "1.0.0", -1},
+		{SchemeSemVer, "build", "build", 0},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.scheme, tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%s, %q, %q) = %d, want %d", tt.scheme, tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(tt.scheme, tt.b, tt.a); got != -tt.want {
+			t.Errorf("Compare(%s, %q, %q) = %d, want %d", tt.scheme, tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := map[string]bool{
+		"1.0.0":            false,
+		"v1.0.0-rc.1":      true,
+		"1.0.0-0":          true,
+		"1.0.0+build-1":    false,
+		"1.0.0-beta+build": true,
+		"2.0":              false,
+		"100":              false,
+		"":                 false,
+		"not-a-version":    false,
+	}
+	for in, want := range tests {
+		if got := IsPrerelease(in); got != want {
+			t.Errorf("IsPrerelease(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
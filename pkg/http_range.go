@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRangeNotSatisfiable Range 请求的区间不在文件范围内（416）
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+	// ErrMultipleRanges 请求了多个区间；下载接口只支持单一区间（416）
+	ErrMultipleRanges = errors.New("multiple ranges are not supported")
+)
+
+// ByteRange 字节区间，Length 为区间长度
+type ByteRange struct {
+	Start  int64
+	Length int64
+}
+
+// ContentRange 返回 Content-Range 头的值
+func (r ByteRange) ContentRange(size int64) string {
+	return "bytes " + strconv.FormatInt(r.Start, 10) + "-" + strconv.FormatInt(r.Start+r.Length-1, 10) + "/" + strconv.FormatInt(size, 10)
+}
+
+// ParseRange 解析 Range 请求头（RFC 7233）
+// 返回 nil, nil 表示应忽略该头并返回完整文件：头为空、单位不是 bytes 或语法错误
+// 多个区间返回 ErrMultipleRanges；所有区间都超出文件范围返回 ErrRangeNotSatisfiable
+func ParseRange(header string, size int64) (*ByteRange, error) {
+	const prefix = "bytes="
+	if header == "" || !strings.HasPrefix(header, prefix) {
+		return nil, nil
+	}
+
+	specs := strings.Split(header[len(prefix):], ",")
+	if len(specs) > 1 {
+		// 语法错误的多区间请求同样忽略
+		for _, spec := range specs {
+			if _, _, ok := parseRangeSpec(strings.TrimSpace(spec)); !ok {
+				return nil, nil
+			}
+		}
+		return nil, ErrMultipleRanges
+	}
+
+	start, end, ok := parseRangeSpec(strings.TrimSpace(specs[0]))
+	if !ok {
+		return nil, nil
+	}
+
+	if start < 0 {
+		// 后缀区间 "-N"：最后 N 个字节
+		if end == 0 || size == 0 {
+			return nil, ErrRangeNotSatisfiable
+		}
+		if end > size {
+			end = size
+		}
+		return &ByteRange{Start: size - end, Length: end}, nil
+	}
+
+	if start >= size {
+		return nil, ErrRangeNotSatisfiable
+	}
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	return &ByteRange{Start: start, Length: end - start + 1}, nil
+}
+
+// parseRangeSpec 解析单个区间 "a-b"、"a-" 或 "-n"
+// 后缀区间返回 start=-1、end=n；开放区间返回 end=-1
+func parseRangeSpec(spec string) (start, end int64, ok bool) {
+	i := strings.Index(spec, "-")
+	if i < 0 {
+		return 0, 0, false
+	}
+	first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		return -1, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if last == "" {
+		return start, -1, true
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// ETagMatches 判断 If-None-Match / If-Match 头是否命中 etag
+// weak 为 true 时使用弱比较（忽略 W/ 前缀），用于 If-None-Match
+func ETagMatches(header, etag string, weak bool) bool {
+	if header == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModifiedSince 判断资源在 If-Modified-Since 之后是否未修改（按秒比较）
+func NotModifiedSince(header string, modTime time.Time) bool {
+	if header == "" || modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(t)
+}
+
+// IfRangeMatches 判断 If-Range 条件是否成立，不成立时应忽略 Range 返回完整文件
+// If-Range 可以是强 ETag 或 HTTP 日期
+func IfRangeMatches(header, etag string, modTime time.Time) bool {
+	if header == "" {
+		return true
+	}
+	if strings.HasPrefix(header, "\"") || strings.HasPrefix(header, "W/") {
+		return ETagMatches(header, etag, false)
+	}
+	t, err := http.ParseTime(header)
+	if err != nil || modTime.IsZero() {
+		return false
+	}
+	return modTime.Truncate(time.Second).Equal(t)
+}
@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"path"
+	"strings"
+)
+
+// NormalizeOS 将客户端上报的操作系统名称统一为 GOOS 风格
+func NormalizeOS(osName string) string {
+	osName = strings.ToLower(strings.TrimSpace(osName))
+	switch osName {
+	case "win", "win32", "win64", "windows":
+		return "windows"
+	case "mac", "macos", "osx", "darwin":
+		return "darwin"
+	}
+	return osName
+}
+
+// NormalizeArch 将客户端上报的架构名称统一为 GOARCH 风格
+func NormalizeArch(arch string) string {
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	switch arch {
+	case "x86_64", "x64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64", "armv8", "arm64-v8a":
+		return "arm64"
+	case "x86", "i386", "i686", "386":
+		return "386"
+	case "armv7", "armv7l", "armeabi-v7a", "arm":
+		return "arm"
+	}
+	return arch
+}
+
+// IsUniversalArch 判断是否为不区分架构的构件（如 macOS universal 包）
+func IsUniversalArch(arch string) bool {
+	switch arch {
+	case "all", "any", "universal", "noarch":
+		return true
+	}
+	return false
+}
+
+// GetArtifactKind 根据文件名推断构件类型
+func GetArtifactKind(fileName string) string {
+	name := strings.ToLower(fileName)
+	if strings.Contains(name, "checksums") || strings.HasSuffix(name, ".sha256") {
+		return "checksum"
+	}
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tar.xz") {
+		return "archive"
+	}
+	switch path.Ext(name) {
+	case ".zip", ".tgz", ".gz", ".xz", ".7z", ".tar":
+		return "archive"
+	case ".deb", ".rpm", ".apk", ".apkg", ".ipk":
+		return "package"
+	case ".msi", ".dmg", ".pkg", ".appimage":
+		return "installer"
+	}
+	return "binary"
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"pkms/domain"
+	"pkms/pkg"
+
+	"github.com/rs/xid"
+)
+
+// dedupFileRepository 内容寻址存储：开启后上传的文件按 SHA-256 存储，相同内容只保留一份
+// 每次上传计一次引用，删除内容寻址路径时引用计数减一，归零才删除对象；未开启时新上传仍使用原路径，
+// 但已有的内容寻址对象依旧按引用计数删除
+type dedupFileRepository struct {
+	domain.FileRepository
+	blobRepository domain.BlobRepository
+	enabled        bool
+	// 引用计数变更与对象的移动/删除必须原子完成，否则并发的上传和删除可能删掉刚被引用的对象
+	mu sync.Mutex
+}
+
+func NewDedupFileRepository(fileRepository domain.FileRepository, blobRepository domain.BlobRepository, enabled bool) domain.FileRepository {
+	return &dedupFileRepository{
+		FileRepository: fileRepository,
+		blobRepository: blobRepository,
+		enabled:        enabled,
+	}
+}
+
+func (dr *dedupFileRepository) Upload(c context.Context, req *domain.UploadRequest) (*domain.UploadResult, error) {
+	if !dr.enabled || req.Reader == nil {
+		return dr.FileRepository.Upload(c, req)
+	}
+
+	// 摘要在写入过程中才能得到，先写入临时路径
+	hasher := sha256.New()
+	staging := *req
+	staging.Prefix = ""
+	staging.ObjectName = domain.BlobStagingPrefix + xid.New().String()
+	staging.Reader = io.TeeReader(req.Reader, hasher)
+
+	result, err := dr.FileRepository.Upload(c, &staging)
+	if err != nil {
+		return nil, err
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+
+	blobPath, err := dr.acquire(c, req.Bucket, staging.ObjectName, hash, result.Size)
+	if err != nil {
+		_ = dr.FileRepository.Delete(c, req.Bucket, staging.ObjectName)
+		return nil, err
+	}
+
+	result.Key = blobPath
+	result.ObjectName = blobPath
+	return result, nil
+}
+
+// acquire 将临时对象登记为内容寻址对象：内容已存在时增加引用并删除临时对象，否则移动到内容寻址路径
+func (dr *dedupFileRepository) acquire(c context.Context, bucket, stagingPath, hash string, size int64) (string, error) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	blobPath := domain.BlobPath(hash)
+	existing, err := dr.blobRepository.GetByHash(c, hash)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		if _, err := dr.blobRepository.AddRef(c, hash); err != nil {
+			return "", err
+		}
+		if err := dr.FileRepository.Delete(c, bucket, stagingPath); err != nil {
+			pkg.Log.Printf("Failed to delete staging file %s: %v", stagingPath, err)
+		}
+		return existing.Path, nil
+	}
+
+	if err := dr.FileRepository.Move(c, bucket, stagingPath, blobPath); err != nil {
+		return "", fmt.Errorf("failed to move blob: %v", err)
+	}
+	if err := dr.blobRepository.Create(c, &domain.Blob{Hash: hash, Path: blobPath, Size: size}); err != nil {
+		return "", err
+	}
+	return blobPath, nil
+}
+
+func (dr *dedupFileRepository) Delete(c context.Context, bucket, objectName string) error {
+	hash, ok := domain.BlobHashFromPath(objectName)
+	if !ok {
+		return dr.FileRepository.Delete(c, bucket, objectName)
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	remaining, found, err := dr.blobRepository.Release(c, hash)
+	if err != nil {
+		return err
+	}
+	if found && remaining > 0 {
+		return nil
+	}
+	// 引用归零，或对象没有登记（记录丢失）时直接删除
+	return dr.FileRepository.Delete(c, bucket, objectName)
+}
+
+// CollectBlob 巡检发现内容寻址对象没有任何引用时调用，直接删除底层对象并移除记录
+func (dr *dedupFileRepository) CollectBlob(c context.Context, bucket, objectName string, since time.Time) (bool, error) {
+	hash, ok := domain.BlobHashFromPath(objectName)
+	if !ok {
+		return false, fmt.Errorf("not a blob path: %s", objectName)
+	}
+
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+
+	existing, err := dr.blobRepository.GetByHash(c, hash)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && existing.UpdatedAt.After(since) {
+		return false, nil
+	}
+	if err := dr.FileRepository.Delete(c, bucket, objectName); err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if err := dr.blobRepository.Delete(c, hash); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -5,26 +5,31 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"pkms/domain"
 	"pkms/pkg"
 )
 
 type diskFileRepository struct {
-	basePath string
+	basePath  string
+	urlSecret string // 签名直接下载地址的 HMAC 密钥
 }
 
-func NewDiskFileRepository(basePath string) domain.FileRepository {
+func NewDiskFileRepository(basePath, urlSecret string) domain.FileRepository {
 	// 确保基础路径存在
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		panic(fmt.Sprintf("Failed to create base path %s: %v", basePath, err))
 	}
 	return &diskFileRepository{
-		basePath: basePath,
+		basePath:  basePath,
+		urlSecret: urlSecret,
 	}
 }
 
@@ -88,8 +93,23 @@ func (dfr *diskFileRepository) Upload(c context.Context, req *domain.UploadReque
 	}, nil
 }
 
+// objectFilePath 对象在磁盘上的路径，拒绝清理后跳出存储桶目录的对象名（如包含 ..）
+func (dfr *diskFileRepository) objectFilePath(bucket, objectName string) (string, error) {
+	bucketPath := filepath.Join(dfr.basePath, bucket)
+	filePath := filepath.Join(bucketPath, objectName)
+	rel, err := filepath.Rel(bucketPath, filePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) ||
+		!strings.HasPrefix(bucketPath, filepath.Clean(dfr.basePath)+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object name: %s", objectName)
+	}
+	return filePath, nil
+}
+
 func (dfr *diskFileRepository) Download(c context.Context, req *domain.DownloadRequest) (io.ReadCloser, error) {
-	filePath := filepath.Join(dfr.basePath, req.Bucket, req.ObjectName)
+	filePath, err := dfr.objectFilePath(req.Bucket, req.ObjectName)
+	if err != nil {
+		return nil, err
+	}
 
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -102,8 +122,50 @@ func (dfr *diskFileRepository) Download(c context.Context, req *domain.DownloadR
 	return file, nil
 }
 
+func (dfr *diskFileRepository) DownloadRange(c context.Context, req *domain.DownloadRequest, offset, length int64) (io.ReadCloser, error) {
+	reader, err := dfr.Download(c, req)
+	if err != nil {
+		return nil, err
+	}
+	file := reader.(*os.File)
+
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to seek file: %v", err)
+		}
+	}
+	if length < 0 {
+		return file, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(file, length), Closer: file}, nil
+}
+
+// PresignDownload 本地磁盘没有对象存储的预签名能力，生成由服务端签名校验的 /files 地址
+func (dfr *diskFileRepository) PresignDownload(c context.Context, req *domain.DownloadRequest, opts *domain.PresignOptions) (string, error) {
+	if dfr.urlSecret == "" {
+		return "", fmt.Errorf("download url secret is not configured")
+	}
+	params := url.Values{}
+	params.Set("filename", opts.FileName)
+	params.Set("size", strconv.FormatInt(opts.FileSize, 10))
+	if opts.Hash != "" {
+		params.Set("hash", opts.Hash)
+	}
+	return pkg.SignDownloadURL(dfr.urlSecret, path.Join(req.Bucket, req.ObjectName), params, time.Now().Add(opts.Expiry)), nil
+}
+
+// limitedReadCloser 只读取区间内的字节，关闭时关闭底层文件
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
 func (dfr *diskFileRepository) Delete(c context.Context, bucket, objectName string) error {
-	filePath := filepath.Join(dfr.basePath, bucket, objectName)
+	filePath, err := dfr.objectFilePath(bucket, objectName)
+	if err != nil {
+		return err
+	}
 
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
@@ -118,6 +180,27 @@ func (dfr *diskFileRepository) Delete(c context.Context, bucket, objectName stri
 	return nil
 }
 
+func (dfr *diskFileRepository) Move(c context.Context, bucket, source, target string) error {
+	sourcePath, err := dfr.objectFilePath(bucket, source)
+	if err != nil {
+		return err
+	}
+	targetPath, err := dfr.objectFilePath(bucket, target)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %v", err)
+	}
+	if err := os.Rename(sourcePath, targetPath); err != nil {
+		return fmt.Errorf("failed to move file: %v", err)
+	}
+
+	dfr.removeEmptyDirs(filepath.Dir(sourcePath), filepath.Join(dfr.basePath, bucket))
+	return nil
+}
+
 func (dfr *diskFileRepository) List(c context.Context, bucket, prefix string) ([]domain.FileInfo, error) {
 	bucketPath := filepath.Join(dfr.basePath, bucket)
 
@@ -192,7 +275,10 @@ func (dfr *diskFileRepository) List(c context.Context, bucket, prefix string) ([
 }
 
 func (dfr *diskFileRepository) GetObjectStat(c context.Context, bucket, objectName string) (*domain.FileInfo, error) {
-	filePath := filepath.Join(dfr.basePath, bucket, objectName)
+	filePath, err := dfr.objectFilePath(bucket, objectName)
+	if err != nil {
+		return nil, err
+	}
 
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/blob"
+)
+
+type entBlobRepository struct {
+	client *ent.Client
+}
+
+func NewBlobRepository(client *ent.Client) domain.BlobRepository {
+	return &entBlobRepository{
+		client: client,
+	}
+}
+
+func (r *entBlobRepository) GetByHash(c context.Context, hash string) (*domain.Blob, error) {
+	b, err := r.client.Blob.
+		Query().
+		Where(blob.Hash(hash)).
+		Only(c)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return convertBlobToDomain(b), nil
+}
+
+func (r *entBlobRepository) Create(c context.Context, b *domain.Blob) error {
+	created, err := r.client.Blob.
+		Create().
+		SetHash(b.Hash).
+		SetPath(b.Path).
+		SetSize(b.Size).
+		SetRefCount(1).
+		Save(c)
+	if err != nil {
+		return err
+	}
+
+	*b = *convertBlobToDomain(created)
+	return nil
+}
+
+func (r *entBlobRepository) AddRef(c context.Context, hash string) (bool, error) {
+	n, err := r.client.Blob.
+		Update().
+		Where(blob.Hash(hash)).
+		AddRefCount(1).
+		Save(c)
+	return n > 0, err
+}
+
+func (r *entBlobRepository) Release(c context.Context, hash string) (int, bool, error) {
+	tx, err := r.client.Tx(c)
+	if err != nil {
+		return 0, false, err
+	}
+
+	n, err := tx.Blob.
+		Update().
+		Where(blob.Hash(hash)).
+		AddRefCount(-1).
+		Save(c)
+	if err != nil || n == 0 {
+		_ = tx.Rollback()
+		return 0, false, err
+	}
+
+	b, err := tx.Blob.Query().Where(blob.Hash(hash)).Only(c)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, false, err
+	}
+	if b.RefCount <= 0 {
+		if err := tx.Blob.DeleteOne(b).Exec(c); err != nil {
+			_ = tx.Rollback()
+			return 0, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return max(b.RefCount, 0), true, nil
+}
+
+func (r *entBlobRepository) Delete(c context.Context, hash string) error {
+	_, err := r.client.Blob.
+		Delete().
+		Where(blob.Hash(hash)).
+		Exec(c)
+	return err
+}
+
+func convertBlobToDomain(b *ent.Blob) *domain.Blob {
+	return &domain.Blob{
+		ID:        b.ID,
+		Hash:      b.Hash,
+		Path:      b.Path,
+		Size:      b.Size,
+		RefCount:  b.RefCount,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+}
@@ -48,6 +48,9 @@ func (r *entClientAccessRepository) Create(ctx context.Context, access *domain.C
 	if access.Description != "" {
 		builder = builder.SetDescription(access.Description)
 	}
+	if access.Channel != "" {
+		builder = builder.SetChannel(access.Channel)
+	}
 	if access.ExpiresAt != nil {
 		builder = builder.SetExpiresAt(*access.ExpiresAt)
 	}
@@ -162,6 +165,13 @@ func (r *entClientAccessRepository) Update(ctx context.Context, id string, updat
 			query = query.ClearExpiresAt()
 		}
 	}
+	if channel, ok := updates["channel"].(string); ok {
+		if channel != "" {
+			query = query.SetChannel(channel)
+		} else {
+			query = query.ClearChannel()
+		}
+	}
 	if accessToken, ok := updates["access_token"].(string); ok {
 		query = query.SetAccessToken(accessToken)
 	}
@@ -195,6 +205,7 @@ func (r *entClientAccessRepository) entToClientAccess(ca *ent.ClientAccess) *dom
 		TenantID:    ca.TenantID,
 		ProjectID:   ca.ProjectID,
 		PackageID:   ca.PackageID,
+		Channel:     ca.Channel,
 		AccessToken: ca.AccessToken,
 		Name:        ca.Name,
 		Description: ca.Description,
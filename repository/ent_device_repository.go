@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/device"
+)
+
+type entDeviceRepository struct {
+	client *ent.Client
+}
+
+func NewDeviceRepository(client *ent.Client) domain.DeviceRepository {
+	return &entDeviceRepository{
+		client: client,
+	}
+}
+
+func (r *entDeviceRepository) Upsert(ctx context.Context, d *domain.Device) error {
+	now := time.Now()
+	existing, err := r.client.Device.
+		Query().
+		Where(
+			device.PackageID(d.PackageID),
+			device.DeviceID(d.DeviceID),
+		).
+		Only(ctx)
+
+	if err != nil && !ent.IsNotFound(err) {
+		return err
+	}
+
+	if existing != nil {
+		update := existing.Update().
+			SetClientAccessID(d.ClientAccessID).
+			SetCurrentVersion(d.CurrentVersion).
+			SetLastIP(d.LastIP).
+			SetLastSeenAt(now).
+			AddCheckCount(1)
+
+		// 只覆盖本次上报了的属性
+		if d.Channel != "" {
+			update = update.SetChannel(d.Channel)
+		}
+		if d.OS != "" {
+			update = update.SetOs(d.OS)
+		}
+		if d.Arch != "" {
+			update = update.SetArch(d.Arch)
+		}
+		if d.OSVersion != "" {
+			update = update.SetOsVersion(d.OSVersion)
+		}
+		if d.Model != "" {
+			update = update.SetModel(d.Model)
+		}
+		if d.Region != "" {
+			update = update.SetRegion(d.Region)
+		}
+		if d.Hostname != "" {
+			update = update.SetHostname(d.Hostname)
+		}
+		if d.Labels != nil {
+			update = update.SetLabels(d.Labels)
+		}
+		if d.ClientInfo != "" {
+			update = update.SetClientInfo(d.ClientInfo)
+		}
+
+		updated, err := update.Save(ctx)
+		if err != nil {
+			return err
+		}
+		*d = *convertDeviceToDomain(updated)
+		return nil
+	}
+
+	created, err := r.client.Device.
+		Create().
+		SetTenantID(d.TenantID).
+		SetProjectID(d.ProjectID).
+		SetPackageID(d.PackageID).
+		SetClientAccessID(d.ClientAccessID).
+		SetDeviceID(d.DeviceID).
+		SetCurrentVersion(d.CurrentVersion).
+		SetChannel(d.Channel).
+		SetOs(d.OS).
+		SetArch(d.Arch).
+		SetOsVersion(d.OSVersion).
+		SetModel(d.Model).
+		SetRegion(d.Region).
+		SetHostname(d.Hostname).
+		SetLabels(d.Labels).
+		SetClientInfo(d.ClientInfo).
+		SetLastIP(d.LastIP).
+		SetCheckCount(1).
+		SetFirstSeenAt(now).
+		SetLastSeenAt(now).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	*d = *convertDeviceToDomain(created)
+	return nil
+}
+
+func (r *entDeviceRepository) GetByID(ctx context.Context, id string) (*domain.Device, error) {
+	d, err := r.client.Device.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertDeviceToDomain(d), nil
+}
+
+func (r *entDeviceRepository) GetDevicesPaged(ctx context.Context, tenantID string, filters map[string]interface{}, params domain.QueryParams) (*domain.DevicePagedResult, error) {
+	query := r.client.Device.
+		Query().
+		Where(device.TenantID(tenantID)).
+		Order(ent.Desc(device.FieldLastSeenAt)) // 最近活跃的设备排在前面
+
+	// 应用过滤条件
+	if packageID, ok := filters["package_id"].(string); ok && packageID != "" {
+		query = query.Where(device.PackageID(packageID))
+	}
+	if version, ok := filters["version"].(string); ok && version != "" {
+		query = query.Where(device.CurrentVersion(version))
+	}
+	if osName, ok := filters["os"].(string); ok && osName != "" {
+		query = query.Where(device.Os(osName))
+	}
+	if channel, ok := filters["channel"].(string); ok && channel != "" {
+		query = query.Where(device.Channel(channel))
+	}
+	if activeSince, ok := filters["active_since"].(time.Time); ok {
+		query = query.Where(device.LastSeenAtGTE(activeSince))
+	}
+
+	// 获取总数
+	total, err := query.Clone().Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 应用分页
+	offset := (params.Page - 1) * params.PageSize
+	devices, err := query.Offset(offset).Limit(params.PageSize).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.Device, 0, len(devices))
+	for _, d := range devices {
+		result = append(result, convertDeviceToDomain(d))
+	}
+
+	return domain.NewPagedResult(result, total, params.Page, params.PageSize), nil
+}
+
+func (r *entDeviceRepository) CountByVersion(ctx context.Context, tenantID, packageID string, activeSince *time.Time) ([]*domain.DeviceVersionCount, error) {
+	query := r.client.Device.
+		Query().
+		Where(
+			device.TenantID(tenantID),
+			device.PackageID(packageID),
+		)
+	if activeSince != nil {
+		query = query.Where(device.LastSeenAtGTE(*activeSince))
+	}
+
+	var rows []struct {
+		CurrentVersion string `json:"current_version"`
+		Count          int    `json:"count"`
+	}
+	if err := query.
+		GroupBy(device.FieldCurrentVersion).
+		Aggregate(ent.Count()).
+		Scan(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.DeviceVersionCount, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &domain.DeviceVersionCount{
+			Version: row.CurrentVersion,
+			Count:   row.Count,
+		})
+	}
+	return result, nil
+}
+
+func (r *entDeviceRepository) Delete(ctx context.Context, id string) error {
+	return r.client.Device.DeleteOneID(id).Exec(ctx)
+}
+
+func convertDeviceToDomain(d *ent.Device) *domain.Device {
+	return &domain.Device{
+		ID:             d.ID,
+		TenantID:       d.TenantID,
+		ProjectID:      d.ProjectID,
+		PackageID:      d.PackageID,
+		ClientAccessID: d.ClientAccessID,
+		DeviceID:       d.DeviceID,
+		CurrentVersion: d.CurrentVersion,
+		Channel:        d.Channel,
+		OS:             d.Os,
+		Arch:           d.Arch,
+		OSVersion:      d.OsVersion,
+		Model:          d.Model,
+		Region:         d.Region,
+		Hostname:       d.Hostname,
+		Labels:         d.Labels,
+		ClientInfo:     d.ClientInfo,
+		LastIP:         d.LastIP,
+		CheckCount:     d.CheckCount,
+		FirstSeenAt:    d.FirstSeenAt,
+		LastSeenAt:     d.LastSeenAt,
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/linuxpackage"
+)
+
+type entLinuxPackageRepository struct {
+	client *ent.Client
+}
+
+func NewLinuxPackageRepository(client *ent.Client) domain.LinuxPackageRepository {
+	return &entLinuxPackageRepository{
+		client: client,
+	}
+}
+
+func (r *entLinuxPackageRepository) Create(c context.Context, p *domain.LinuxPackage) error {
+	created, err := r.client.LinuxPackage.
+		Create().
+		SetProjectID(p.ProjectID).
+		SetPackageID(p.PackageID).
+		SetReleaseID(p.ReleaseID).
+		SetFormat(p.Format).
+		SetName(p.Name).
+		SetVersion(p.Version).
+		SetArchitecture(p.Architecture).
+		SetFilePath(p.FilePath).
+		SetFileName(p.FileName).
+		SetFileSize(p.FileSize).
+		SetSha256(p.SHA256).
+		SetSha512(p.SHA512).
+		SetMetadata(p.Metadata).
+		SetError(p.Error).
+		Save(c)
+	if ent.IsConstraintError(err) {
+		created, err = r.client.LinuxPackage.
+			Query().
+			Where(
+				linuxpackage.ReleaseID(p.ReleaseID),
+				linuxpackage.FilePath(p.FilePath),
+			).
+			Only(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	*p = *convertLinuxPackageToDomain(created)
+	return nil
+}
+
+func (r *entLinuxPackageRepository) GetByID(c context.Context, id string) (*domain.LinuxPackage, error) {
+	p, err := r.client.LinuxPackage.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertLinuxPackageToDomain(p), nil
+}
+
+func (r *entLinuxPackageRepository) ListByPackageIDs(c context.Context, packageIDs []string, format string) ([]*domain.LinuxPackage, error) {
+	if len(packageIDs) == 0 {
+		return nil, nil
+	}
+	entPackages, err := r.client.LinuxPackage.
+		Query().
+		Where(
+			linuxpackage.PackageIDIn(packageIDs...),
+			linuxpackage.Format(format),
+		).
+		Order(ent.Asc(linuxpackage.FieldName), ent.Asc(linuxpackage.FieldCreatedAt)).
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.LinuxPackage, len(entPackages))
+	for i, p := range entPackages {
+		result[i] = convertLinuxPackageToDomain(p)
+	}
+	return result, nil
+}
+
+func convertLinuxPackageToDomain(p *ent.LinuxPackage) *domain.LinuxPackage {
+	return &domain.LinuxPackage{
+		ID:           p.ID,
+		ProjectID:    p.ProjectID,
+		PackageID:    p.PackageID,
+		ReleaseID:    p.ReleaseID,
+		Format:       p.Format,
+		Name:         p.Name,
+		Version:      p.Version,
+		Architecture: p.Architecture,
+		FilePath:     p.FilePath,
+		FileName:     p.FileName,
+		FileSize:     p.FileSize,
+		SHA256:       p.Sha256,
+		SHA512:       p.Sha512,
+		Metadata:     p.Metadata,
+		Error:        p.Error,
+		CreatedAt:    p.CreatedAt,
+	}
+}
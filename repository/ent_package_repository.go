@@ -23,13 +23,14 @@ func (pr *entPackageRepository) GetByID(c context.Context, id string) (*domain.P
 	}
 
 	return &domain.Package{
-		ID:          p.ID,
-		ProjectID:   p.ProjectID,
-		Name:        p.Name,
-		Description: p.Description,
-		Type:        string(p.Type),
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:            p.ID,
+		ProjectID:     p.ProjectID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Type:          string(p.Type),
+		VersionScheme: p.VersionScheme.String(),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
 	}, nil
 }
 
@@ -46,13 +47,14 @@ func (pr *entPackageRepository) GetByProjectID(c context.Context, projectID stri
 	var result []*domain.Package
 	for _, p := range packages {
 		result = append(result, &domain.Package{
-			ID:          p.ID,
-			ProjectID:   p.ProjectID,
-			Name:        p.Name,
-			Description: p.Description,
-			Type:        string(p.Type),
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   p.UpdatedAt,
+			ID:            p.ID,
+			ProjectID:     p.ProjectID,
+			Name:          p.Name,
+			Description:   p.Description,
+			Type:          string(p.Type),
+			VersionScheme: p.VersionScheme.String(),
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
 		})
 	}
 
@@ -100,6 +102,7 @@ func (pr *entPackageRepository) FetchAll(c context.Context, page, pageSize int)
 			Name:           p.Name,
 			Description:    p.Description,
 			Type:           string(p.Type),
+			VersionScheme:  p.VersionScheme.String(),
 			CreatedBy:      p.CreatedBy,
 			CreatedAt:      p.CreatedAt,
 			UpdatedAt:      p.UpdatedAt,
@@ -154,6 +157,7 @@ func (pr *entPackageRepository) FetchByProject(c context.Context, projectID stri
 			Name:           p.Name,
 			Description:    p.Description,
 			Type:           string(p.Type),
+			VersionScheme:  p.VersionScheme.String(),
 			CreatedBy:      p.CreatedBy,
 			CreatedAt:      p.CreatedAt,
 			UpdatedAt:      p.UpdatedAt,
@@ -172,20 +176,26 @@ func NewPackageRepository(client *ent.Client) domain.PackageRepository {
 }
 
 func (pr *entPackageRepository) Create(c context.Context, p *domain.Package) error {
-	created, err := pr.client.Packages.
+	createBuilder := pr.client.Packages.
 		Create().
 		SetProjectID(p.ProjectID).
 		SetName(p.Name).
 		SetDescription(p.Description).
 		SetCreatedBy(p.CreatedBy).
-		SetType(packages.Type(p.Type)).
-		Save(c)
+		SetType(packages.Type(p.Type))
+
+	if p.VersionScheme != "" {
+		createBuilder = createBuilder.SetVersionScheme(packages.VersionScheme(p.VersionScheme))
+	}
+
+	created, err := createBuilder.Save(c)
 
 	if err != nil {
 		return err
 	}
 
 	p.ID = created.ID
+	p.VersionScheme = created.VersionScheme.String()
 	p.CreatedAt = created.CreatedAt
 	p.UpdatedAt = created.UpdatedAt
 	return nil
@@ -214,13 +224,14 @@ func (pr *entPackageRepository) Fetch(c context.Context, page, pageSize int) ([]
 	var result []domain.Package
 	for _, p := range packages {
 		result = append(result, domain.Package{
-			ID:          p.ID,
-			ProjectID:   p.ProjectID,
-			Name:        p.Name,
-			Description: p.Description,
-			Type:        string(p.Type),
-			CreatedAt:   p.CreatedAt,
-			UpdatedAt:   p.UpdatedAt,
+			ID:            p.ID,
+			ProjectID:     p.ProjectID,
+			Name:          p.Name,
+			Description:   p.Description,
+			Type:          string(p.Type),
+			VersionScheme: p.VersionScheme.String(),
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
 		})
 	}
 
@@ -228,12 +239,17 @@ func (pr *entPackageRepository) Fetch(c context.Context, page, pageSize int) ([]
 }
 
 func (pr *entPackageRepository) Update(c context.Context, p *domain.Package) error {
-	_, err := pr.client.Packages.
+	updateBuilder := pr.client.Packages.
 		UpdateOneID(p.ID).
 		SetName(p.Name).
 		SetDescription(p.Description).
-		SetType(packages.Type(p.Type)).
-		Save(c)
+		SetType(packages.Type(p.Type))
+
+	if p.VersionScheme != "" {
+		updateBuilder = updateBuilder.SetVersionScheme(packages.VersionScheme(p.VersionScheme))
+	}
+
+	_, err := updateBuilder.Save(c)
 
 	return err
 }
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/packages"
+	"pkms/ent/project"
+	"pkms/ent/release"
+	"pkms/ent/releaseasset"
+	"pkms/ent/releasepatch"
+)
+
+type entQuotaRepository struct {
+	client *ent.Client
+}
+
+func NewQuotaRepository(client *ent.Client) domain.QuotaRepository {
+	return &entQuotaRepository{
+		client: client,
+	}
+}
+
+func (qr *entQuotaRepository) GetQuota(c context.Context, tenantID string) (*domain.TenantQuota, error) {
+	t, err := qr.client.Tenant.Get(c, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return convertTenantQuota(t), nil
+}
+
+func (qr *entQuotaRepository) UpdateQuota(c context.Context, tenantID string, quota *domain.TenantQuota) error {
+	return qr.client.Tenant.
+		UpdateOneID(tenantID).
+		SetQuotaBytes(quota.QuotaBytes).
+		SetMaxFileSize(quota.MaxFileSize).
+		SetMaxReleasesPerPackage(quota.MaxReleasesPerPackage).
+		Exec(c)
+}
+
+func (qr *entQuotaRepository) GetTenantUsage(c context.Context, tenantID string) (*domain.TenantUsage, error) {
+	t, err := qr.client.Tenant.Get(c, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &domain.TenantUsage{
+		TenantID:   t.ID,
+		TenantName: t.Name,
+		Quota:      *convertTenantQuota(t),
+	}
+
+	projects, err := qr.client.Project.
+		Query().
+		Where(project.TenantID(tenantID)).
+		WithPackages().
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+
+	packageUsages := make(map[string]*domain.PackageUsage)
+	var packageIDs []string
+	for _, p := range projects {
+		for _, pkg := range p.Edges.Packages {
+			packageUsages[pkg.ID] = &domain.PackageUsage{PackageID: pkg.ID, PackageName: pkg.Name}
+			packageIDs = append(packageIDs, pkg.ID)
+		}
+	}
+
+	if len(packageIDs) > 0 {
+		// 同一对象可能同时被版本主文件和构件引用，按路径去重
+		counted := make(map[string]bool)
+		add := func(packageID, filePath string, size int64) {
+			pu := packageUsages[packageID]
+			if pu == nil || filePath == "" || counted[filePath] {
+				return
+			}
+			counted[filePath] = true
+			pu.Bytes += size
+		}
+
+		releases, err := qr.client.Release.
+			Query().
+			Where(release.PackageIDIn(packageIDs...)).
+			Select(release.FieldID, release.FieldPackageID, release.FieldFilePath, release.FieldFileSize).
+			All(c)
+		if err != nil {
+			return nil, err
+		}
+		releasePackages := make(map[string]string, len(releases))
+		releaseIDs := make([]string, 0, len(releases))
+		for _, r := range releases {
+			releasePackages[r.ID] = r.PackageID
+			releaseIDs = append(releaseIDs, r.ID)
+			packageUsages[r.PackageID].Releases++
+			add(r.PackageID, r.FilePath, r.FileSize)
+		}
+
+		if len(releaseIDs) > 0 {
+			assets, err := qr.client.ReleaseAsset.
+				Query().
+				Where(releaseasset.ReleaseIDIn(releaseIDs...)).
+				Select(releaseasset.FieldReleaseID, releaseasset.FieldFilePath, releaseasset.FieldFileSize).
+				All(c)
+			if err != nil {
+				return nil, err
+			}
+			for _, asset := range assets {
+				add(releasePackages[asset.ReleaseID], asset.FilePath, asset.FileSize)
+			}
+		}
+
+		patches, err := qr.client.ReleasePatch.
+			Query().
+			Where(
+				releasepatch.PackageIDIn(packageIDs...),
+				releasepatch.StatusEQ(releasepatch.StatusReady),
+			).
+			Select(releasepatch.FieldPackageID, releasepatch.FieldFilePath, releasepatch.FieldFileSize).
+			All(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, patch := range patches {
+			add(patch.PackageID, patch.FilePath, patch.FileSize)
+		}
+	}
+
+	for _, p := range projects {
+		pu := domain.ProjectUsage{ProjectID: p.ID, ProjectName: p.Name, Packages: []domain.PackageUsage{}}
+		for _, pkg := range p.Edges.Packages {
+			packageUsage := packageUsages[pkg.ID]
+			pu.Releases += packageUsage.Releases
+			pu.Bytes += packageUsage.Bytes
+			pu.Packages = append(pu.Packages, *packageUsage)
+		}
+		sort.Slice(pu.Packages, func(i, j int) bool {
+			return pu.Packages[i].Bytes > pu.Packages[j].Bytes
+		})
+		usage.Releases += pu.Releases
+		usage.UsedBytes += pu.Bytes
+		usage.Projects = append(usage.Projects, pu)
+	}
+	sort.Slice(usage.Projects, func(i, j int) bool {
+		return usage.Projects[i].Bytes > usage.Projects[j].Bytes
+	})
+
+	return usage, nil
+}
+
+func (qr *entQuotaRepository) ListTenantIDs(c context.Context) ([]string, error) {
+	return qr.client.Tenant.Query().IDs(c)
+}
+
+func (qr *entQuotaRepository) GetPackageTenantID(c context.Context, packageID string) (string, error) {
+	p, err := qr.client.Packages.
+		Query().
+		Where(packages.ID(packageID)).
+		QueryProject().
+		Only(c)
+	if err != nil {
+		return "", err
+	}
+	return p.TenantID, nil
+}
+
+func (qr *entQuotaRepository) CountReleases(c context.Context, packageID string) (int, error) {
+	return qr.client.Release.
+		Query().
+		Where(release.PackageID(packageID)).
+		Count(c)
+}
+
+func convertTenantQuota(t *ent.Tenant) *domain.TenantQuota {
+	return &domain.TenantQuota{
+		QuotaBytes:            t.QuotaBytes,
+		MaxFileSize:           t.MaxFileSize,
+		MaxReleasesPerPackage: t.MaxReleasesPerPackage,
+	}
+}
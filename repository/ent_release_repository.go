@@ -2,14 +2,20 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"pkms/domain"
 	"pkms/ent"
+	"pkms/ent/linuxpackage"
 	"pkms/ent/packages"
 	"pkms/ent/project"
 	"pkms/ent/release"
+	"pkms/ent/releaseasset"
+	"pkms/ent/releasepatch"
 	"pkms/ent/share"
 	"pkms/ent/upgrade"
+	"pkms/ent/upgradereport"
 )
 
 type entReleaseRepository struct {
@@ -33,23 +39,49 @@ func (rr *entReleaseRepository) Create(c context.Context, r *domain.Release) err
 		SetFileName(r.FileName).
 		SetFileSize(r.FileSize).
 		SetDownloadCount(r.DownloadCount).
+		SetIsDraft(r.IsDraft).
+		SetIsPrerelease(r.IsPrerelease).
+		SetIsLatest(r.IsLatest).
 		SetCreatedBy(r.CreatedBy)
 
+	// 非草稿版本创建即发布
+	if !r.IsDraft {
+		createBuilder = createBuilder.SetPublishedAt(time.Now())
+	}
+
 	// 可选字段
+	if r.ID != "" {
+		createBuilder = createBuilder.SetID(r.ID)
+	}
 	if r.TagName != "" {
 		createBuilder = createBuilder.SetTagName(r.TagName)
 	}
 	if r.FileHash != "" {
 		createBuilder = createBuilder.SetFileHash(r.FileHash)
 	}
+	if r.SHA256 != "" {
+		createBuilder = createBuilder.SetSha256(r.SHA256)
+	}
+	if r.SHA512 != "" {
+		createBuilder = createBuilder.SetSha512(r.SHA512)
+	}
+	if r.Signature != "" {
+		createBuilder = createBuilder.SetSignature(r.Signature).SetSignatureKeyID(r.SignatureKeyID)
+	}
 
 	created, err := createBuilder.Save(c)
+	if ent.IsConstraintError(err) {
+		return domain.ErrReleaseExists
+	}
 	if err != nil {
 		return err
 	}
 
 	r.ID = created.ID
 	r.CreatedAt = created.CreatedAt
+	if !created.PublishedAt.IsZero() {
+		r.PublishedAt = &created.PublishedAt
+	}
 	return nil
 }
 
@@ -57,6 +89,7 @@ func (rr *entReleaseRepository) GetByID(c context.Context, id string) (*domain.R
 	entRelease, err := rr.client.Release.
 		Query().
 		Where(release.ID(id)).
+		WithAssets().
 		Only(c)
 
 	if err != nil {
@@ -70,6 +103,7 @@ func (rr *entReleaseRepository) GetByPackageID(c context.Context, packageID stri
 	entReleases, err := rr.client.Release.
 		Query().
 		Where(release.PackageID(packageID)).
+		WithAssets().
 		All(c)
 
 	if err != nil {
@@ -89,8 +123,12 @@ func (rr *entReleaseRepository) GetLatestByPackageID(c context.Context, packageI
 		Query().
 		Where(
 			release.PackageID(packageID),
+			release.IsLatest(true),
+			release.IsDraft(false),
 		).
-		Only(c)
+		WithAssets().
+		Order(ent.Desc(release.FieldCreatedAt)).
+		First(c)
 
 	if err != nil {
 		return nil, err
@@ -136,6 +174,33 @@ func (rr *entReleaseRepository) Delete(c context.Context, id string) error {
 		return tx.Rollback()
 	}
 
+	// 删除版本下的所有构件记录
+	_, err = tx.ReleaseAsset.Delete().Where(releaseasset.ReleaseID(id)).Exec(c)
+	if err != nil {
+		return tx.Rollback()
+	}
+
+	// 删除以该版本为起点或目标的差分补丁记录
+	_, err = tx.ReleasePatch.Delete().Where(releasepatch.Or(
+		releasepatch.FromReleaseID(id),
+		releasepatch.ToReleaseID(id),
+	)).Exec(c)
+	if err != nil {
+		return tx.Rollback()
+	}
+
+	// 删除该版本的升级结果上报
+	_, err = tx.UpgradeReport.Delete().Where(upgradereport.ReleaseID(id)).Exec(c)
+	if err != nil {
+		return tx.Rollback()
+	}
+
+	// 删除从该版本文件中提取的 deb/rpm 元数据
+	_, err = tx.LinuxPackage.Delete().Where(linuxpackage.ReleaseID(id)).Exec(c)
+	if err != nil {
+		return tx.Rollback()
+	}
+
 	// 最后删除 release 记录
 	err = tx.Release.DeleteOneID(id).Exec(c)
 	if err != nil {
@@ -154,21 +219,44 @@ func (rr *entReleaseRepository) IncrementDownloadCount(c context.Context, id str
 }
 
 func (rr *entReleaseRepository) SetAsLatest(c context.Context, packageID, releaseID string) error {
+	tx, err := rr.client.Tx(c)
+	if err != nil {
+		return err
+	}
+
 	// 先将该包的所有版本设置为非最新
-	_, err := rr.client.Release.
+	_, err = tx.Release.
 		Update().
-		Where(release.PackageID(packageID)).
+		Where(
+			release.PackageID(packageID),
+			release.IsLatest(true),
+		).
+		SetIsLatest(false).
 		Save(c)
-
 	if err != nil {
+		_ = tx.Rollback()
 		return err
 	}
 
 	// 再将指定版本设置为最新
-	_, err = rr.client.Release.
+	_, err = tx.Release.
 		UpdateOneID(releaseID).
+		SetIsLatest(true).
 		Save(c)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 
+	return tx.Commit()
+}
+
+func (rr *entReleaseRepository) Publish(c context.Context, id string) error {
+	_, err := rr.client.Release.
+		UpdateOneID(id).
+		SetIsDraft(false).
+		SetPublishedAt(time.Now()).
+		Save(c)
 	return err
 }
 
@@ -198,20 +286,351 @@ func (rr *entReleaseRepository) GetTotalDownloadsByTenant(c context.Context, ten
 	return totalDownloads, nil
 }
 
+func (rr *entReleaseRepository) CreateAsset(c context.Context, asset *domain.ReleaseAsset) error {
+	createBuilder := rr.client.ReleaseAsset.
+		Create().
+		SetReleaseID(asset.ReleaseID).
+		SetOs(asset.OS).
+		SetArch(asset.Arch).
+		SetKind(asset.Kind).
+		SetFilePath(asset.FilePath).
+		SetFileName(asset.FileName).
+		SetFileSize(asset.FileSize)
+
+	// 可选字段
+	if asset.ID != "" {
+		createBuilder = createBuilder.SetID(asset.ID)
+	}
+	if asset.Artifact != "" {
+		createBuilder = createBuilder.SetArtifact(asset.Artifact)
+	}
+	if asset.FileHash != "" {
+		createBuilder = createBuilder.SetFileHash(asset.FileHash)
+	}
+	if asset.SHA256 != "" {
+		createBuilder = createBuilder.SetSha256(asset.SHA256)
+	}
+	if asset.SHA512 != "" {
+		createBuilder = createBuilder.SetSha512(asset.SHA512)
+	}
+	if asset.Signature != "" {
+		createBuilder = createBuilder.SetSignature(asset.Signature).SetSignatureKeyID(asset.SignatureKeyID)
+	}
+
+	created, err := createBuilder.Save(c)
+	if err != nil {
+		return err
+	}
+
+	asset.ID = created.ID
+	asset.CreatedAt = created.CreatedAt
+	return nil
+}
+
+func (rr *entReleaseRepository) GetAssetsByReleaseID(c context.Context, releaseID string) ([]*domain.ReleaseAsset, error) {
+	entAssets, err := rr.client.ReleaseAsset.
+		Query().
+		Where(releaseasset.ReleaseID(releaseID)).
+		Order(ent.Asc(releaseasset.FieldCreatedAt)).
+		All(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]*domain.ReleaseAsset, len(entAssets))
+	for i, entAsset := range entAssets {
+		assets[i] = convertAssetToDomain(entAsset)
+	}
+
+	return assets, nil
+}
+
+func (rr *entReleaseRepository) DeleteAsset(c context.Context, id string) error {
+	return rr.client.ReleaseAsset.DeleteOneID(id).Exec(c)
+}
+
+func (rr *entReleaseRepository) CreatePatch(c context.Context, patch *domain.ReleasePatch) error {
+	created, err := rr.client.ReleasePatch.
+		Create().
+		SetPackageID(patch.PackageID).
+		SetFromReleaseID(patch.FromReleaseID).
+		SetToReleaseID(patch.ToReleaseID).
+		SetToAssetID(patch.ToAssetID).
+		SetFormat(patch.Format).
+		SetStatus(releasepatch.Status(patch.Status)).
+		Save(c)
+	if err != nil {
+		return err
+	}
+
+	patch.ID = created.ID
+	patch.CreatedAt = created.CreatedAt
+	patch.UpdatedAt = created.UpdatedAt
+	return nil
+}
+
+func (rr *entReleaseRepository) UpdatePatch(c context.Context, id string, updates map[string]interface{}) error {
+	query := rr.client.ReleasePatch.UpdateOneID(id)
+
+	if status, ok := updates["status"].(string); ok {
+		query = query.SetStatus(releasepatch.Status(status))
+	}
+	if errMsg, ok := updates["error"].(string); ok {
+		query = query.SetError(errMsg)
+	}
+	if format, ok := updates["format"].(string); ok {
+		query = query.SetFormat(format)
+	}
+	if filePath, ok := updates["file_path"].(string); ok {
+		query = query.SetFilePath(filePath)
+	}
+	if fileSize, ok := updates["file_size"].(int64); ok {
+		query = query.SetFileSize(fileSize)
+	}
+	if targetHash, ok := updates["target_hash"].(string); ok {
+		query = query.SetTargetHash(targetHash)
+	}
+	if targetSize, ok := updates["target_size"].(int64); ok {
+		query = query.SetTargetSize(targetSize)
+	}
+
+	_, err := query.Save(c)
+	return err
+}
+
+func (rr *entReleaseRepository) GetPatchByID(c context.Context, id string) (*domain.ReleasePatch, error) {
+	entPatch, err := rr.client.ReleasePatch.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertPatchToDomain(entPatch), nil
+}
+
+func (rr *entReleaseRepository) GetPatch(c context.Context, fromReleaseID, toReleaseID, toAssetID string) (*domain.ReleasePatch, error) {
+	entPatch, err := rr.client.ReleasePatch.
+		Query().
+		Where(
+			releasepatch.FromReleaseID(fromReleaseID),
+			releasepatch.ToReleaseID(toReleaseID),
+			releasepatch.ToAssetID(toAssetID),
+		).
+		Only(c)
+	if err != nil {
+		return nil, err
+	}
+	return convertPatchToDomain(entPatch), nil
+}
+
+func (rr *entReleaseRepository) GetPatchesByReleaseID(c context.Context, releaseID string) ([]*domain.ReleasePatch, error) {
+	entPatches, err := rr.client.ReleasePatch.
+		Query().
+		Where(releasepatch.Or(
+			releasepatch.FromReleaseID(releaseID),
+			releasepatch.ToReleaseID(releaseID),
+		)).
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+
+	patches := make([]*domain.ReleasePatch, len(entPatches))
+	for i, entPatch := range entPatches {
+		patches[i] = convertPatchToDomain(entPatch)
+	}
+	return patches, nil
+}
+
+func convertPatchToDomain(entPatch *ent.ReleasePatch) *domain.ReleasePatch {
+	return &domain.ReleasePatch{
+		ID:            entPatch.ID,
+		PackageID:     entPatch.PackageID,
+		FromReleaseID: entPatch.FromReleaseID,
+		ToReleaseID:   entPatch.ToReleaseID,
+		ToAssetID:     entPatch.ToAssetID,
+		Format:        entPatch.Format,
+		Status:        entPatch.Status.String(),
+		Error:         entPatch.Error,
+		FilePath:      entPatch.FilePath,
+		FileSize:      entPatch.FileSize,
+		TargetHash:    entPatch.TargetHash,
+		TargetSize:    entPatch.TargetSize,
+		CreatedAt:     entPatch.CreatedAt,
+		UpdatedAt:     entPatch.UpdatedAt,
+	}
+}
+
+func convertAssetToDomain(entAsset *ent.ReleaseAsset) *domain.ReleaseAsset {
+	return &domain.ReleaseAsset{
+		ID:             entAsset.ID,
+		ReleaseID:      entAsset.ReleaseID,
+		OS:             entAsset.Os,
+		Arch:           entAsset.Arch,
+		Kind:           entAsset.Kind,
+		Artifact:       entAsset.Artifact,
+		FilePath:       entAsset.FilePath,
+		FileName:       entAsset.FileName,
+		FileSize:       entAsset.FileSize,
+		FileHash:       entAsset.FileHash,
+		SHA256:         entAsset.Sha256,
+		SHA512:         entAsset.Sha512,
+		Signature:      entAsset.Signature,
+		SignatureKeyID: entAsset.SignatureKeyID,
+		DownloadCount:  entAsset.DownloadCount,
+		CreatedAt:      entAsset.CreatedAt,
+	}
+}
+
 func (rr *entReleaseRepository) convertToDomain(entRelease *ent.Release) *domain.Release {
+	var assets []*domain.ReleaseAsset
+	for _, entAsset := range entRelease.Edges.Assets {
+		assets = append(assets, convertAssetToDomain(entAsset))
+	}
+
+	var publishedAt *time.Time
+	if !entRelease.PublishedAt.IsZero() {
+		publishedAt = &entRelease.PublishedAt
+	}
+
 	return &domain.Release{
-		ID:            entRelease.ID,
-		PackageID:     entRelease.PackageID,
-		VersionCode:   entRelease.VersionCode,
-		TagName:       entRelease.TagName,
-		VersionName:   entRelease.VersionName,
-		ChangeLog:     entRelease.Changelog,
-		FilePath:      entRelease.FilePath,
-		FileName:      entRelease.FileName,
-		FileSize:      entRelease.FileSize,
-		FileHash:      entRelease.FileHash,
-		DownloadCount: entRelease.DownloadCount,
-		CreatedBy:     entRelease.CreatedBy,
-		CreatedAt:     entRelease.CreatedAt,
+		ID:             entRelease.ID,
+		PackageID:      entRelease.PackageID,
+		VersionCode:    entRelease.VersionCode,
+		TagName:        entRelease.TagName,
+		VersionName:    entRelease.VersionName,
+		ChangeLog:      entRelease.Changelog,
+		FilePath:       entRelease.FilePath,
+		FileName:       entRelease.FileName,
+		FileSize:       entRelease.FileSize,
+		FileHash:       entRelease.FileHash,
+		SHA256:         entRelease.Sha256,
+		SHA512:         entRelease.Sha512,
+		Signature:      entRelease.Signature,
+		SignatureKeyID: entRelease.SignatureKeyID,
+		DownloadCount:  entRelease.DownloadCount,
+		CreatedBy:      entRelease.CreatedBy,
+		CreatedAt:      entRelease.CreatedAt,
+		IsDraft:        entRelease.IsDraft,
+		IsPrerelease:   entRelease.IsPrerelease,
+		IsLatest:       entRelease.IsLatest,
+		PublishedAt:    publishedAt,
+		Assets:         assets,
+
+		IntegrityStatus:    entRelease.IntegrityStatus,
+		IntegrityCheckedAt: entRelease.IntegrityCheckedAt,
 	}
 }
+
+func (rr *entReleaseRepository) ListStorageObjects(c context.Context) ([]domain.StorageObject, error) {
+	objects := make(map[string]domain.StorageObject)
+	add := func(object domain.StorageObject) {
+		if object.Path == "" {
+			return
+		}
+		if _, exists := objects[object.Path]; !exists {
+			objects[object.Path] = object
+		}
+	}
+
+	releases, err := rr.client.Release.Query().All(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		add(domain.StorageObject{Path: r.FilePath, Size: r.FileSize, SHA256: r.Sha256, ReleaseID: r.ID})
+	}
+
+	assets, err := rr.client.ReleaseAsset.Query().All(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		add(domain.StorageObject{Path: asset.FilePath, Size: asset.FileSize, SHA256: asset.Sha256, ReleaseID: asset.ReleaseID, AssetID: asset.ID})
+	}
+
+	patches, err := rr.client.ReleasePatch.
+		Query().
+		Where(releasepatch.StatusEQ(releasepatch.StatusReady)).
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, patch := range patches {
+		add(domain.StorageObject{Path: patch.FilePath, Size: patch.FileSize, ReleaseID: patch.ToReleaseID, PatchID: patch.ID})
+	}
+
+	result := make([]domain.StorageObject, 0, len(objects))
+	for _, object := range objects {
+		result = append(result, object)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result, nil
+}
+
+func (rr *entReleaseRepository) UpdateIntegrity(c context.Context, statuses map[string]string, checkedAt time.Time) error {
+	tx, err := rr.client.Tx(c)
+	if err != nil {
+		return err
+	}
+
+	flagged := make([]string, 0, len(statuses))
+	for id, status := range statuses {
+		flagged = append(flagged, id)
+		if err := tx.Release.UpdateOneID(id).
+			SetIntegrityStatus(status).
+			SetIntegrityCheckedAt(checkedAt).
+			Exec(c); err != nil && !ent.IsNotFound(err) {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Release.Update().
+		Where(release.IDNotIn(flagged...)).
+		SetIntegrityStatus(domain.IntegrityStatusOK).
+		SetIntegrityCheckedAt(checkedAt).
+		Exec(c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (rr *entReleaseRepository) ReplaceFilePath(c context.Context, oldPath, newPath string) error {
+	tx, err := rr.client.Tx(c)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Release.Update().
+		Where(release.FilePath(oldPath)).
+		SetFilePath(newPath).
+		Exec(c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.ReleaseAsset.Update().
+		Where(releaseasset.FilePath(oldPath)).
+		SetFilePath(newPath).
+		Exec(c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.ReleasePatch.Update().
+		Where(releasepatch.FilePath(oldPath)).
+		SetFilePath(newPath).
+		Exec(c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.LinuxPackage.Update().
+		Where(linuxpackage.FilePath(oldPath)).
+		SetFilePath(newPath).
+		Exec(c); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/release"
+	"pkms/ent/retentionpolicy"
+	"pkms/ent/share"
+	"pkms/ent/upgrade"
+	"pkms/ent/upgradereport"
+)
+
+type entRetentionPolicyRepository struct {
+	client *ent.Client
+}
+
+func NewRetentionPolicyRepository(client *ent.Client) domain.RetentionPolicyRepository {
+	return &entRetentionPolicyRepository{
+		client: client,
+	}
+}
+
+func (r *entRetentionPolicyRepository) Create(c context.Context, policy *domain.RetentionPolicy) error {
+	created, err := r.client.RetentionPolicy.
+		Create().
+		SetTenantID(policy.TenantID).
+		SetProjectID(policy.ProjectID).
+		SetPackageID(policy.PackageID).
+		SetKeepLast(policy.KeepLast).
+		SetPrereleaseMaxAgeDays(policy.PrereleaseMaxAgeDays).
+		SetEnabled(policy.Enabled).
+		SetCreatedBy(policy.CreatedBy).
+		Save(c)
+	if err != nil {
+		return err
+	}
+
+	*policy = *convertRetentionPolicyToDomain(created)
+	return nil
+}
+
+func (r *entRetentionPolicyRepository) GetByID(c context.Context, id string) (*domain.RetentionPolicy, error) {
+	p, err := r.client.RetentionPolicy.Get(c, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertRetentionPolicyToDomain(p), nil
+}
+
+func (r *entRetentionPolicyRepository) ListByTenant(c context.Context, tenantID, projectID string) ([]*domain.RetentionPolicy, error) {
+	query := r.client.RetentionPolicy.
+		Query().
+		Where(retentionpolicy.TenantID(tenantID))
+	if projectID != "" {
+		query = query.Where(retentionpolicy.ProjectID(projectID))
+	}
+
+	policies, err := query.
+		Order(ent.Asc(retentionpolicy.FieldProjectID), ent.Asc(retentionpolicy.FieldPackageID)).
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+	return convertRetentionPolicies(policies), nil
+}
+
+func (r *entRetentionPolicyRepository) ListEnabled(c context.Context) ([]*domain.RetentionPolicy, error) {
+	policies, err := r.client.RetentionPolicy.
+		Query().
+		Where(retentionpolicy.Enabled(true)).
+		All(c)
+	if err != nil {
+		return nil, err
+	}
+	return convertRetentionPolicies(policies), nil
+}
+
+func (r *entRetentionPolicyRepository) Update(c context.Context, id string, updates map[string]interface{}) error {
+	update := r.client.RetentionPolicy.UpdateOneID(id)
+	if v, ok := updates["keep_last"].(int); ok {
+		update = update.SetKeepLast(v)
+	}
+	if v, ok := updates["prerelease_max_age_days"].(int); ok {
+		update = update.SetPrereleaseMaxAgeDays(v)
+	}
+	if v, ok := updates["enabled"].(bool); ok {
+		update = update.SetEnabled(v)
+	}
+	return update.Exec(c)
+}
+
+func (r *entRetentionPolicyRepository) Delete(c context.Context, id string) error {
+	return r.client.RetentionPolicy.DeleteOneID(id).Exec(c)
+}
+
+func (r *entRetentionPolicyRepository) GetProtectedReleaseIDs(c context.Context, packageID string, now time.Time) (map[string]bool, error) {
+	protected := make(map[string]bool)
+
+	// 当前或曾经的升级目标（升级任务停用后记录仍保留）
+	upgradeTargets, err := r.client.Upgrade.
+		Query().
+		Where(upgrade.PackageID(packageID)).
+		Select(upgrade.FieldReleaseID).
+		Strings(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// 升级任务被删除后，设备上报记录中仍保留曾经升级到的版本
+	reportedTargets, err := r.client.UpgradeReport.
+		Query().
+		Where(upgradereport.PackageID(packageID)).
+		Unique(true).
+		Select(upgradereport.FieldReleaseID).
+		Strings(c)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedReleases, err := r.client.Share.
+		Query().
+		Where(
+			share.HasReleaseWith(release.PackageID(packageID)),
+			share.Or(share.ExpiredAtIsNil(), share.ExpiredAtGT(now)),
+		).
+		Select(share.FieldReleaseID).
+		Strings(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ids := range [][]string{upgradeTargets, reportedTargets, sharedReleases} {
+		for _, id := range ids {
+			if id != "" {
+				protected[id] = true
+			}
+		}
+	}
+	return protected, nil
+}
+
+func convertRetentionPolicies(policies []*ent.RetentionPolicy) []*domain.RetentionPolicy {
+	result := make([]*domain.RetentionPolicy, len(policies))
+	for i, p := range policies {
+		result[i] = convertRetentionPolicyToDomain(p)
+	}
+	return result
+}
+
+func convertRetentionPolicyToDomain(p *ent.RetentionPolicy) *domain.RetentionPolicy {
+	return &domain.RetentionPolicy{
+		ID:                   p.ID,
+		TenantID:             p.TenantID,
+		ProjectID:            p.ProjectID,
+		PackageID:            p.PackageID,
+		KeepLast:             p.KeepLast,
+		PrereleaseMaxAgeDays: p.PrereleaseMaxAgeDays,
+		Enabled:              p.Enabled,
+		CreatedBy:            p.CreatedBy,
+		CreatedAt:            p.CreatedAt,
+		UpdatedAt:            p.UpdatedAt,
+	}
+}
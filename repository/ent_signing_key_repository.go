@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/signingkey"
+)
+
+type entSigningKeyRepository struct {
+	client *ent.Client
+}
+
+func NewSigningKeyRepository(client *ent.Client) domain.SigningKeyRepository {
+	return &entSigningKeyRepository{
+		client: client,
+	}
+}
+
+func (r *entSigningKeyRepository) Create(ctx context.Context, key *domain.SigningKey) error {
+	created, err := r.client.SigningKey.
+		Create().
+		SetTenantID(key.TenantID).
+		SetKeyID(key.KeyID).
+		SetAlgorithm(key.Algorithm).
+		SetPublicKey(key.PublicKey).
+		SetPrivateKey(key.PrivateKey).
+		SetStatus(domain.SigningKeyStatusActive).
+		Save(ctx)
+	if ent.IsConstraintError(err) {
+		return domain.ErrActiveSigningKeyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	*key = *convertSigningKeyToDomain(created)
+	return nil
+}
+
+func (r *entSigningKeyRepository) GetActiveByTenant(ctx context.Context, tenantID string) (*domain.SigningKey, error) {
+	key, err := r.client.SigningKey.
+		Query().
+		Where(
+			signingkey.TenantID(tenantID),
+			signingkey.Status(domain.SigningKeyStatusActive),
+		).
+		Only(ctx)
+	if ent.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convertSigningKeyToDomain(key), nil
+}
+
+func (r *entSigningKeyRepository) ListByTenant(ctx context.Context, tenantID string) ([]*domain.SigningKey, error) {
+	keys, err := r.client.SigningKey.
+		Query().
+		Where(signingkey.TenantID(tenantID)).
+		Order(ent.Desc(signingkey.FieldCreatedAt)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.SigningKey, len(keys))
+	for i, key := range keys {
+		result[i] = convertSigningKeyToDomain(key)
+	}
+	return result, nil
+}
+
+func (r *entSigningKeyRepository) Rotate(ctx context.Context, key *domain.SigningKey, retiredAt time.Time) error {
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.SigningKey.
+		Update().
+		Where(
+			signingkey.TenantID(key.TenantID),
+			signingkey.Status(domain.SigningKeyStatusActive),
+		).
+		SetStatus(domain.SigningKeyStatusRetired).
+		SetRetiredAt(retiredAt).
+		Exec(ctx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	created, err := tx.SigningKey.
+		Create().
+		SetTenantID(key.TenantID).
+		SetKeyID(key.KeyID).
+		SetAlgorithm(key.Algorithm).
+		SetPublicKey(key.PublicKey).
+		SetPrivateKey(key.PrivateKey).
+		SetStatus(domain.SigningKeyStatusActive).
+		Save(ctx)
+	if err != nil {
+		_ = tx.Rollback()
+		if ent.IsConstraintError(err) {
+			return domain.ErrActiveSigningKeyExists
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		if ent.IsConstraintError(err) {
+			return domain.ErrActiveSigningKeyExists
+		}
+		return err
+	}
+
+	*key = *convertSigningKeyToDomain(created)
+	return nil
+}
+
+func convertSigningKeyToDomain(key *ent.SigningKey) *domain.SigningKey {
+	result := &domain.SigningKey{
+		ID:         key.ID,
+		TenantID:   key.TenantID,
+		KeyID:      key.KeyID,
+		Algorithm:  key.Algorithm,
+		PublicKey:  key.PublicKey,
+		PrivateKey: key.PrivateKey,
+		Status:     key.Status,
+		CreatedAt:  key.CreatedAt,
+	}
+	if !key.RetiredAt.IsZero() {
+		result.RetiredAt = &key.RetiredAt
+	}
+	return result
+}
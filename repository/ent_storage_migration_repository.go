@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/storagemigration"
+)
+
+type entStorageMigrationRepository struct {
+	client *ent.Client
+}
+
+func NewStorageMigrationRepository(client *ent.Client) domain.StorageMigrationRepository {
+	return &entStorageMigrationRepository{
+		client: client,
+	}
+}
+
+func (r *entStorageMigrationRepository) Create(ctx context.Context, m *domain.StorageMigration) error {
+	created, err := r.client.StorageMigration.
+		Create().
+		SetSource(m.Source).
+		SetTarget(m.Target).
+		SetStatus(domain.StorageMigrationRunning).
+		SetCreatedBy(m.CreatedBy).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	*m = *convertStorageMigrationToDomain(created)
+	return nil
+}
+
+func (r *entStorageMigrationRepository) GetByID(ctx context.Context, id string) (*domain.StorageMigration, error) {
+	m, err := r.client.StorageMigration.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertStorageMigrationToDomain(m), nil
+}
+
+func (r *entStorageMigrationRepository) List(ctx context.Context, limit int) ([]*domain.StorageMigration, error) {
+	migrations, err := r.client.StorageMigration.
+		Query().
+		Order(ent.Desc(storagemigration.FieldStartedAt)).
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.StorageMigration, len(migrations))
+	for i, m := range migrations {
+		result[i] = convertStorageMigrationToDomain(m)
+	}
+	return result, nil
+}
+
+func (r *entStorageMigrationRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	update := r.client.StorageMigration.UpdateOneID(id)
+
+	if status, ok := updates["status"].(string); ok {
+		update = update.SetStatus(status)
+	}
+	if total, ok := updates["total"].(int); ok {
+		update = update.SetTotal(total)
+	}
+	if processed, ok := updates["processed"].(int); ok {
+		update = update.SetProcessed(processed)
+	}
+	if copied, ok := updates["copied"].(int); ok {
+		update = update.SetCopied(copied)
+	}
+	if skipped, ok := updates["skipped"].(int); ok {
+		update = update.SetSkipped(skipped)
+	}
+	if failed, ok := updates["failed"].(int); ok {
+		update = update.SetFailed(failed)
+	}
+	if bytes, ok := updates["bytes"].(int64); ok {
+		update = update.SetBytes(bytes)
+	}
+	if cursor, ok := updates["cursor"].(string); ok {
+		update = update.SetCursor(cursor)
+	}
+	if failures, ok := updates["failures"].([]string); ok {
+		update = update.SetFailures(failures)
+	}
+	if errMsg, ok := updates["error"].(string); ok {
+		update = update.SetError(errMsg)
+	}
+	if finishedAt, ok := updates["finished_at"].(time.Time); ok {
+		update = update.SetFinishedAt(finishedAt)
+	}
+
+	return update.Exec(ctx)
+}
+
+func (r *entStorageMigrationRepository) GetResumable(ctx context.Context, source, target string) (*domain.StorageMigration, error) {
+	m, err := r.client.StorageMigration.
+		Query().
+		Where(
+			storagemigration.Source(source),
+			storagemigration.Target(target),
+			storagemigration.StatusNEQ(domain.StorageMigrationCompleted),
+		).
+		Order(ent.Desc(storagemigration.FieldStartedAt)).
+		First(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return convertStorageMigrationToDomain(m), nil
+}
+
+func (r *entStorageMigrationRepository) MarkRunningInterrupted(ctx context.Context) error {
+	return r.client.StorageMigration.
+		Update().
+		Where(storagemigration.Status(domain.StorageMigrationRunning)).
+		SetStatus(domain.StorageMigrationInterrupted).
+		Exec(ctx)
+}
+
+func convertStorageMigrationToDomain(m *ent.StorageMigration) *domain.StorageMigration {
+	return &domain.StorageMigration{
+		ID:         m.ID,
+		Source:     m.Source,
+		Target:     m.Target,
+		Status:     m.Status,
+		Total:      m.Total,
+		Processed:  m.Processed,
+		Copied:     m.Copied,
+		Skipped:    m.Skipped,
+		Failed:     m.Failed,
+		Bytes:      m.Bytes,
+		Cursor:     m.Cursor,
+		Failures:   m.Failures,
+		Error:      m.Error,
+		CreatedBy:  m.CreatedBy,
+		StartedAt:  m.StartedAt,
+		FinishedAt: m.FinishedAt,
+		UpdatedAt:  m.UpdatedAt,
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/schema"
+	"pkms/ent/storagescan"
+)
+
+type entStorageScanRepository struct {
+	client *ent.Client
+}
+
+func NewStorageScanRepository(client *ent.Client) domain.StorageScanRepository {
+	return &entStorageScanRepository{
+		client: client,
+	}
+}
+
+func (r *entStorageScanRepository) Create(ctx context.Context, scan *domain.StorageScan) error {
+	created, err := r.client.StorageScan.
+		Create().
+		SetStatus(domain.StorageScanRunning).
+		SetTrigger(scan.Trigger).
+		SetDeleteOrphans(scan.DeleteOrphans).
+		SetVerifyHashes(scan.VerifyHashes).
+		SetCreatedBy(scan.CreatedBy).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	*scan = *convertStorageScanToDomain(created)
+	return nil
+}
+
+func (r *entStorageScanRepository) GetByID(ctx context.Context, id string) (*domain.StorageScan, error) {
+	scan, err := r.client.StorageScan.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertStorageScanToDomain(scan), nil
+}
+
+func (r *entStorageScanRepository) List(ctx context.Context, limit int) ([]*domain.StorageScan, error) {
+	scans, err := r.client.StorageScan.
+		Query().
+		Order(ent.Desc(storagescan.FieldStartedAt)).
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.StorageScan, len(scans))
+	for i, scan := range scans {
+		result[i] = convertStorageScanToDomain(scan)
+		// 列表中不返回问题明细
+		result[i].Issues = nil
+	}
+	return result, nil
+}
+
+func (r *entStorageScanRepository) Save(ctx context.Context, scan *domain.StorageScan) error {
+	issues := make([]schema.StorageIssue, len(scan.Issues))
+	for i, issue := range scan.Issues {
+		issues[i] = schema.StorageIssue(issue)
+	}
+
+	update := r.client.StorageScan.
+		UpdateOneID(scan.ID).
+		SetStatus(scan.Status).
+		SetTotalObjects(scan.TotalObjects).
+		SetReferencedObjects(scan.ReferencedObjects).
+		SetOrphanCount(scan.OrphanCount).
+		SetOrphanBytes(scan.OrphanBytes).
+		SetDeletedCount(scan.DeletedCount).
+		SetMissingCount(scan.MissingCount).
+		SetCorruptCount(scan.CorruptCount).
+		SetIssues(issues).
+		SetError(scan.Error)
+	if scan.FinishedAt != nil {
+		update = update.SetFinishedAt(*scan.FinishedAt)
+	}
+	return update.Exec(ctx)
+}
+
+func (r *entStorageScanRepository) MarkRunningFailed(ctx context.Context) error {
+	return r.client.StorageScan.
+		Update().
+		Where(storagescan.Status(domain.StorageScanRunning)).
+		SetStatus(domain.StorageScanFailed).
+		SetError("interrupted").
+		Exec(ctx)
+}
+
+func convertStorageScanToDomain(s *ent.StorageScan) *domain.StorageScan {
+	issues := make([]domain.StorageIssue, len(s.Issues))
+	for i, issue := range s.Issues {
+		issues[i] = domain.StorageIssue(issue)
+	}
+
+	return &domain.StorageScan{
+		ID:                s.ID,
+		Status:            s.Status,
+		Trigger:           s.Trigger,
+		DeleteOrphans:     s.DeleteOrphans,
+		VerifyHashes:      s.VerifyHashes,
+		TotalObjects:      s.TotalObjects,
+		ReferencedObjects: s.ReferencedObjects,
+		OrphanCount:       s.OrphanCount,
+		OrphanBytes:       s.OrphanBytes,
+		DeletedCount:      s.DeletedCount,
+		MissingCount:      s.MissingCount,
+		CorruptCount:      s.CorruptCount,
+		Issues:            issues,
+		Error:             s.Error,
+		CreatedBy:         s.CreatedBy,
+		StartedAt:         s.StartedAt,
+		FinishedAt:        s.FinishedAt,
+	}
+}
@@ -23,6 +23,7 @@ func (tr *entTenantRepository) Create(c context.Context, t *domain.Tenant) error
 	created, err := tr.client.Tenant.
 		Create().
 		SetName(t.Name).
+		SetTimezone(domain.NormalizeTimezone(t.Timezone)).
 		Save(c)
 
 	if err != nil {
@@ -30,6 +31,7 @@ func (tr *entTenantRepository) Create(c context.Context, t *domain.Tenant) error
 	}
 
 	t.ID = created.ID
+	t.Timezone = created.Timezone
 	t.CreatedAt = created.CreatedAt
 	t.UpdatedAt = created.UpdatedAt
 	return nil
@@ -39,7 +41,7 @@ func (tr *entTenantRepository) Fetch(c context.Context) ([]*domain.Tenant, error
 	// 查询所有租户，排除 admin 租户
 	tenants, err := tr.client.Tenant.
 		Query().
-		Select(tenant.FieldID, tenant.FieldName, tenant.FieldCreatedAt, tenant.FieldUpdatedAt).
+		Select(tenant.FieldID, tenant.FieldName, tenant.FieldTimezone, tenant.FieldCreatedAt, tenant.FieldUpdatedAt).
 		Where(tenant.Not(tenant.Name("admin"))).
 		All(c)
 
@@ -52,6 +54,7 @@ func (tr *entTenantRepository) Fetch(c context.Context) ([]*domain.Tenant, error
 		result = append(result, &domain.Tenant{
 			ID:        t.ID,
 			Name:      t.Name,
+			Timezone:  t.Timezone,
 			CreatedAt: t.CreatedAt,
 			UpdatedAt: t.UpdatedAt,
 		})
@@ -74,7 +77,7 @@ func (tr *entTenantRepository) FetchPaged(c context.Context, params domain.Query
 
 	// 应用分页
 	tenants, err := query.
-		Select(tenant.FieldID, tenant.FieldName, tenant.FieldCreatedAt, tenant.FieldUpdatedAt).
+		Select(tenant.FieldID, tenant.FieldName, tenant.FieldTimezone, tenant.FieldCreatedAt, tenant.FieldUpdatedAt).
 		Offset((params.Page - 1) * params.PageSize).
 		Limit(params.PageSize).
 		All(c)
@@ -89,6 +92,7 @@ func (tr *entTenantRepository) FetchPaged(c context.Context, params domain.Query
 		result = append(result, &domain.Tenant{
 			ID:        t.ID,
 			Name:      t.Name,
+			Timezone:  t.Timezone,
 			CreatedAt: t.CreatedAt,
 			UpdatedAt: t.UpdatedAt,
 		})
@@ -110,21 +114,26 @@ func (tr *entTenantRepository) GetByID(c context.Context, id string) (*domain.Te
 	return &domain.Tenant{
 		ID:        t.ID,
 		Name:      t.Name,
+		Timezone:  t.Timezone,
 		CreatedAt: t.CreatedAt,
 		UpdatedAt: t.UpdatedAt,
 	}, nil
 }
 
 func (tr *entTenantRepository) Update(c context.Context, t *domain.Tenant) error {
-	updated, err := tr.client.Tenant.
+	update := tr.client.Tenant.
 		UpdateOneID(t.ID).
-		SetName(t.Name).
-		Save(c)
+		SetName(t.Name)
+	if t.Timezone != "" {
+		update = update.SetTimezone(t.Timezone)
+	}
 
+	updated, err := update.Save(c)
 	if err != nil {
 		return err
 	}
 
+	t.Timezone = updated.Timezone
 	t.UpdatedAt = updated.UpdatedAt
 	return nil
 }
@@ -151,6 +160,7 @@ func (tr *entTenantRepository) GetTenantsByUserID(c context.Context, userID stri
 		result = append(result, &domain.Tenant{
 			ID:        t.ID,
 			Name:      t.Name,
+			Timezone:  t.Timezone,
 			CreatedAt: t.CreatedAt,
 			UpdatedAt: t.UpdatedAt,
 		})
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/upgradereport"
+)
+
+type entUpgradeReportRepository struct {
+	client *ent.Client
+}
+
+func NewUpgradeReportRepository(client *ent.Client) domain.UpgradeReportRepository {
+	return &entUpgradeReportRepository{
+		client: client,
+	}
+}
+
+func (r *entUpgradeReportRepository) Upsert(ctx context.Context, report *domain.UpgradeReport) error {
+	existing, err := r.client.UpgradeReport.
+		Query().
+		Where(
+			upgradereport.ReleaseID(report.ReleaseID),
+			upgradereport.ClientID(report.ClientID),
+			upgradereport.StageEQ(upgradereport.Stage(report.Stage)),
+		).
+		Only(ctx)
+
+	if err != nil && !ent.IsNotFound(err) {
+		return err
+	}
+
+	if existing != nil {
+		updated, err := existing.Update().
+			SetUpgradeID(report.UpgradeID).
+			SetClientAccessID(report.ClientAccessID).
+			SetFromVersion(report.FromVersion).
+			SetStatus(upgradereport.Status(report.Status)).
+			SetMessage(report.Message).
+			SetClientIP(report.ClientIP).
+			Save(ctx)
+		if err != nil {
+			return err
+		}
+		report.ID = updated.ID
+		report.CreatedAt = updated.CreatedAt
+		report.UpdatedAt = updated.UpdatedAt
+		return nil
+	}
+
+	created, err := r.client.UpgradeReport.
+		Create().
+		SetTenantID(report.TenantID).
+		SetPackageID(report.PackageID).
+		SetReleaseID(report.ReleaseID).
+		SetUpgradeID(report.UpgradeID).
+		SetClientAccessID(report.ClientAccessID).
+		SetClientID(report.ClientID).
+		SetFromVersion(report.FromVersion).
+		SetStage(upgradereport.Stage(report.Stage)).
+		SetStatus(upgradereport.Status(report.Status)).
+		SetMessage(report.Message).
+		SetClientIP(report.ClientIP).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	report.ID = created.ID
+	report.CreatedAt = created.CreatedAt
+	report.UpdatedAt = created.UpdatedAt
+	return nil
+}
+
+func (r *entUpgradeReportRepository) GetStatsByUpgradeID(ctx context.Context, upgradeID string) (*domain.UpgradeTargetStats, error) {
+	var rows []struct {
+		Stage  string `json:"stage"`
+		Status string `json:"status"`
+		Count  int    `json:"count"`
+	}
+	err := r.client.UpgradeReport.
+		Query().
+		Where(upgradereport.UpgradeID(upgradeID)).
+		GroupBy(upgradereport.FieldStage, upgradereport.FieldStatus).
+		Aggregate(ent.Count()).
+		Scan(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := r.client.UpgradeReport.
+		Query().
+		Where(upgradereport.UpgradeID(upgradeID)).
+		Unique(true).
+		Select(upgradereport.FieldClientID).
+		Strings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.UpgradeTargetStats{
+		UpgradeID: upgradeID,
+		Clients:   len(clients),
+		Stages: map[string]domain.StageStats{
+			domain.ReportStageDownload: {},
+			domain.ReportStageInstall:  {},
+			domain.ReportStageStart:    {},
+		},
+	}
+	for _, row := range rows {
+		s := stats.Stages[row.Stage]
+		if row.Status == domain.ReportStatusSuccess {
+			s.Success += row.Count
+		} else {
+			s.Failure += row.Count
+		}
+		stats.Stages[row.Stage] = s
+	}
+
+	// 下载失败多为网络原因，失败比例只统计安装与启动阶段
+	var success, failure int
+	for _, stage := range []string{domain.ReportStageInstall, domain.ReportStageStart} {
+		success += stats.Stages[stage].Success
+		failure += stats.Stages[stage].Failure
+	}
+	stats.Reports = success + failure
+	if success+failure > 0 {
+		stats.FailureRatio = float64(failure) / float64(success+failure)
+	}
+
+	return stats, nil
+}
@@ -2,10 +2,11 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"pkms/domain"
 	"pkms/ent"
+	"pkms/ent/schema"
 	"pkms/ent/upgrade"
 )
 
@@ -19,18 +20,182 @@ func NewUpgradeRepository(client *ent.Client) domain.UpgradeRepository {
 	}
 }
 
+// convertUpgradeToDomain 转换升级目标实体，并填充项目、包、版本的关联信息
+func convertUpgradeToDomain(u *ent.Upgrade, downloadPrefix string) *domain.UpgradeTarget {
+	target := &domain.UpgradeTarget{
+		ID:          u.ID,
+		TenantID:    u.TenantID,
+		ProjectID:   u.ProjectID,
+		PackageID:   u.PackageID,
+		ReleaseID:   u.ReleaseID,
+		Channel:     u.Channel,
+		Name:        u.Name,
+		Description: u.Description,
+		IsActive:    u.IsActive,
+		IsRollback:  u.IsRollback,
+		CreatedBy:   u.CreatedBy,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+
+		IsMandatory:         u.IsMandatory,
+		MinSupportedVersion: u.MinSupportedVersion,
+
+		RolloutPercentage: u.RolloutPercentage,
+		RolloutSchedule:   convertRolloutScheduleToDomain(u.RolloutSchedule),
+		RolloutStatus:     u.RolloutStatus.String(),
+		PauseReason:       u.PauseReason,
+
+		TargetingRules: convertTargetingRulesToDomain(u.TargetingRules),
+
+		MaintenanceWindows: convertMaintenanceWindowsToDomain(u.MaintenanceWindows),
+
+		PromotedBy:   u.PromotedBy,
+		PromotedFrom: u.PromotedFrom,
+	}
+	if !u.PromotedAt.IsZero() {
+		target.PromotedAt = &u.PromotedAt
+	}
+	if !u.StartsAt.IsZero() {
+		target.StartsAt = &u.StartsAt
+	}
+	if !u.EndsAt.IsZero() {
+		target.EndsAt = &u.EndsAt
+	}
+
+	// 填充关联信息
+	if u.Edges.Tenant != nil {
+		target.Timezone = u.Edges.Tenant.Timezone
+	}
+	if u.Edges.Project != nil {
+		target.ProjectName = u.Edges.Project.Name
+	}
+	if u.Edges.Package != nil {
+		target.PackageName = u.Edges.Package.Name
+		target.PackageType = u.Edges.Package.Type.String()
+		target.VersionScheme = u.Edges.Package.VersionScheme.String()
+	}
+	if u.Edges.Release != nil {
+		target.Version = domain.ReleaseVersion(u.Edges.Release.VersionCode, u.Edges.Release.TagName, u.Edges.Release.VersionName)
+		target.FileName = u.Edges.Release.FileName
+		target.FileSize = u.Edges.Release.FileSize
+		target.FileHash = u.Edges.Release.FileHash
+		target.SHA256 = u.Edges.Release.Sha256
+		target.SHA512 = u.Edges.Release.Sha512
+		target.Signature = u.Edges.Release.Signature
+		target.SignatureKeyID = u.Edges.Release.SignatureKeyID
+		// 构造下载URL，这里需要根据实际文件存储方案调整
+		target.DownloadURL = downloadPrefix + u.Edges.Release.ID
+	}
+
+	return target
+}
+
+func convertRolloutScheduleToDomain(steps []schema.RolloutStep) []domain.RolloutStep {
+	if len(steps) == 0 {
+		return nil
+	}
+	result := make([]domain.RolloutStep, len(steps))
+	for i, step := range steps {
+		result[i] = domain.RolloutStep{At: step.At, Percentage: step.Percentage}
+	}
+	return result
+}
+
+func convertRolloutScheduleToEnt(steps []domain.RolloutStep) []schema.RolloutStep {
+	result := make([]schema.RolloutStep, len(steps))
+	for i, step := range steps {
+		result[i] = schema.RolloutStep{At: step.At, Percentage: step.Percentage}
+	}
+	return result
+}
+
+func convertTargetingRulesToDomain(rules schema.TargetingRules) *domain.TargetingRules {
+	result := &domain.TargetingRules{
+		OS:           rules.OS,
+		OSVersionMin: rules.OSVersionMin,
+		OSVersionMax: rules.OSVersionMax,
+		Regions:      rules.Regions,
+		Models:       rules.Models,
+		DeviceIDs:    rules.DeviceIDs,
+	}
+	if result.IsEmpty() {
+		return nil
+	}
+	return result
+}
+
+func convertTargetingRulesToEnt(rules *domain.TargetingRules) schema.TargetingRules {
+	if rules == nil {
+		return schema.TargetingRules{}
+	}
+	return schema.TargetingRules{
+		OS:           rules.OS,
+		OSVersionMin: rules.OSVersionMin,
+		OSVersionMax: rules.OSVersionMax,
+		Regions:      rules.Regions,
+		Models:       rules.Models,
+		DeviceIDs:    rules.DeviceIDs,
+	}
+}
+
+func convertMaintenanceWindowsToDomain(windows []schema.MaintenanceWindow) []domain.MaintenanceWindow {
+	if len(windows) == 0 {
+		return nil
+	}
+	result := make([]domain.MaintenanceWindow, len(windows))
+	for i, w := range windows {
+		result[i] = domain.MaintenanceWindow{Days: w.Days, Start: w.Start, End: w.End}
+	}
+	return result
+}
+
+func convertMaintenanceWindowsToEnt(windows []domain.MaintenanceWindow) []schema.MaintenanceWindow {
+	result := make([]schema.MaintenanceWindow, len(windows))
+	for i, w := range windows {
+		result[i] = schema.MaintenanceWindow{Days: w.Days, Start: w.Start, End: w.End}
+	}
+	return result
+}
+
 func (r *entUpgradeRepository) CreateUpgradeTarget(ctx context.Context, upgradeTarget *domain.UpgradeTarget) error {
-	created, err := r.client.Upgrade.
+	builder := r.client.Upgrade.
 		Create().
 		SetTenantID(upgradeTarget.TenantID).
 		SetProjectID(upgradeTarget.ProjectID).
 		SetPackageID(upgradeTarget.PackageID).
 		SetReleaseID(upgradeTarget.ReleaseID).
+		SetChannel(domain.NormalizeChannel(upgradeTarget.Channel)).
 		SetName(upgradeTarget.Name).
 		SetDescription(upgradeTarget.Description).
 		SetIsActive(upgradeTarget.IsActive).
-		SetCreatedBy(upgradeTarget.CreatedBy).
-		Save(ctx)
+		SetIsRollback(upgradeTarget.IsRollback).
+		SetIsMandatory(upgradeTarget.IsMandatory).
+		SetMinSupportedVersion(upgradeTarget.MinSupportedVersion).
+		SetRolloutPercentage(upgradeTarget.RolloutPercentage).
+		SetRolloutSchedule(convertRolloutScheduleToEnt(upgradeTarget.RolloutSchedule)).
+		SetRolloutStatus(upgrade.RolloutStatus(upgradeTarget.RolloutStatus)).
+		SetTargetingRules(convertTargetingRulesToEnt(upgradeTarget.TargetingRules)).
+		SetMaintenanceWindows(convertMaintenanceWindowsToEnt(upgradeTarget.MaintenanceWindows)).
+		SetCreatedBy(upgradeTarget.CreatedBy)
+
+	if upgradeTarget.StartsAt != nil {
+		builder = builder.SetStartsAt(*upgradeTarget.StartsAt)
+	}
+	if upgradeTarget.EndsAt != nil {
+		builder = builder.SetEndsAt(*upgradeTarget.EndsAt)
+	}
+
+	// 晋级产生的升级目标记录来源
+	if upgradeTarget.PromotedBy != "" {
+		builder = builder.
+			SetPromotedBy(upgradeTarget.PromotedBy).
+			SetPromotedFrom(upgradeTarget.PromotedFrom)
+	}
+	if upgradeTarget.PromotedAt != nil {
+		builder = builder.SetPromotedAt(*upgradeTarget.PromotedAt)
+	}
+
+	created, err := builder.Save(ctx)
 
 	if err != nil {
 		return err
@@ -46,6 +211,7 @@ func (r *entUpgradeRepository) GetUpgradeTargetByID(ctx context.Context, id stri
 	u, err := r.client.Upgrade.
 		Query().
 		Where(upgrade.ID(id)).
+		WithTenant().
 		WithProject().
 		WithPackage().
 		WithRelease().
@@ -55,38 +221,7 @@ func (r *entUpgradeRepository) GetUpgradeTargetByID(ctx context.Context, id stri
 		return nil, err
 	}
 
-	target := &domain.UpgradeTarget{
-		ID:          u.ID,
-		TenantID:    u.TenantID,
-		ProjectID:   u.ProjectID,
-		PackageID:   u.PackageID,
-		ReleaseID:   u.ReleaseID,
-		Name:        u.Name,
-		Description: u.Description,
-		IsActive:    u.IsActive,
-		CreatedBy:   u.CreatedBy,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
-	}
-
-	// 填充关联信息
-	if u.Edges.Project != nil {
-		target.ProjectName = u.Edges.Project.Name
-	}
-	if u.Edges.Package != nil {
-		target.PackageName = u.Edges.Package.Name
-		target.PackageType = u.Edges.Package.Type.String()
-	}
-	if u.Edges.Release != nil {
-		target.Version = u.Edges.Release.VersionCode
-		target.FileName = u.Edges.Release.FileName
-		target.FileSize = u.Edges.Release.FileSize
-		target.FileHash = u.Edges.Release.FileHash
-		// 构造下载URL，这里需要根据实际文件存储方案调整
-		target.DownloadURL = fmt.Sprintf("/api/files/download/%s", u.Edges.Release.ID)
-	}
-
-	return target, nil
+	return convertUpgradeToDomain(u, "/api/files/download/"), nil
 }
 
 func (r *entUpgradeRepository) GetUpgradeTargets(ctx context.Context, tenantID string, filters map[string]interface{}) ([]*domain.UpgradeTarget, error) {
@@ -105,6 +240,9 @@ func (r *entUpgradeRepository) GetUpgradeTargets(ctx context.Context, tenantID s
 	if packageID, ok := filters["package_id"].(string); ok && packageID != "" {
 		query = query.Where(upgrade.PackageID(packageID))
 	}
+	if channel, ok := filters["channel"].(string); ok && channel != "" {
+		query = query.Where(upgrade.Channel(channel))
+	}
 	if isActive, ok := filters["is_active"].(bool); ok {
 		query = query.Where(upgrade.IsActive(isActive))
 	}
@@ -116,37 +254,7 @@ func (r *entUpgradeRepository) GetUpgradeTargets(ctx context.Context, tenantID s
 
 	var result []*domain.UpgradeTarget
 	for _, u := range upgrades {
-		target := &domain.UpgradeTarget{
-			ID:          u.ID,
-			TenantID:    u.TenantID,
-			ProjectID:   u.ProjectID,
-			PackageID:   u.PackageID,
-			ReleaseID:   u.ReleaseID,
-			Name:        u.Name,
-			Description: u.Description,
-			IsActive:    u.IsActive,
-			CreatedBy:   u.CreatedBy,
-			CreatedAt:   u.CreatedAt,
-			UpdatedAt:   u.UpdatedAt,
-		}
-
-		// 填充关联信息
-		if u.Edges.Project != nil {
-			target.ProjectName = u.Edges.Project.Name
-		}
-		if u.Edges.Package != nil {
-			target.PackageName = u.Edges.Package.Name
-			target.PackageType = u.Edges.Package.Type.String()
-		}
-		if u.Edges.Release != nil {
-			target.Version = u.Edges.Release.VersionCode
-			target.FileName = u.Edges.Release.FileName
-			target.FileSize = u.Edges.Release.FileSize
-			target.FileHash = u.Edges.Release.FileHash
-			target.DownloadURL = fmt.Sprintf("/api/files/download/%s", u.Edges.Release.ID)
-		}
-
-		result = append(result, target)
+		result = append(result, convertUpgradeToDomain(u, "/api/files/download/"))
 	}
 
 	return result, nil
@@ -168,6 +276,9 @@ func (r *entUpgradeRepository) GetUpgradeTargetsPaged(ctx context.Context, tenan
 	if packageID, ok := filters["package_id"].(string); ok && packageID != "" {
 		query = query.Where(upgrade.PackageID(packageID))
 	}
+	if channel, ok := filters["channel"].(string); ok && channel != "" {
+		query = query.Where(upgrade.Channel(channel))
+	}
 	if isActive, ok := filters["is_active"].(bool); ok {
 		query = query.Where(upgrade.IsActive(isActive))
 	}
@@ -187,37 +298,7 @@ func (r *entUpgradeRepository) GetUpgradeTargetsPaged(ctx context.Context, tenan
 
 	var result []*domain.UpgradeTarget
 	for _, u := range upgrades {
-		target := &domain.UpgradeTarget{
-			ID:          u.ID,
-			TenantID:    u.TenantID,
-			ProjectID:   u.ProjectID,
-			PackageID:   u.PackageID,
-			ReleaseID:   u.ReleaseID,
-			Name:        u.Name,
-			Description: u.Description,
-			IsActive:    u.IsActive,
-			CreatedBy:   u.CreatedBy,
-			CreatedAt:   u.CreatedAt,
-			UpdatedAt:   u.UpdatedAt,
-		}
-
-		// 填充关联信息
-		if u.Edges.Project != nil {
-			target.ProjectName = u.Edges.Project.Name
-		}
-		if u.Edges.Package != nil {
-			target.PackageName = u.Edges.Package.Name
-			target.PackageType = u.Edges.Package.Type.String()
-		}
-		if u.Edges.Release != nil {
-			target.Version = u.Edges.Release.VersionCode
-			target.FileName = u.Edges.Release.FileName
-			target.FileSize = u.Edges.Release.FileSize
-			target.FileHash = u.Edges.Release.FileHash
-			target.DownloadURL = fmt.Sprintf("/api/files/download/%s", u.Edges.Release.ID)
-		}
-
-		result = append(result, target)
+		result = append(result, convertUpgradeToDomain(u, "/api/files/download/"))
 	}
 
 	return domain.NewPagedResult(result, total, params.Page, params.PageSize), nil
@@ -226,6 +307,9 @@ func (r *entUpgradeRepository) GetUpgradeTargetsPaged(ctx context.Context, tenan
 func (r *entUpgradeRepository) UpdateUpgradeTarget(ctx context.Context, id string, updates map[string]interface{}) error {
 	query := r.client.Upgrade.UpdateOneID(id)
 
+	if releaseID, ok := updates["release_id"].(string); ok {
+		query = query.SetReleaseID(releaseID)
+	}
 	if name, ok := updates["name"].(string); ok {
 		query = query.SetName(name)
 	}
@@ -235,6 +319,64 @@ func (r *entUpgradeRepository) UpdateUpgradeTarget(ctx context.Context, id strin
 	if isActive, ok := updates["is_active"].(bool); ok {
 		query = query.SetIsActive(isActive)
 	}
+	if isRollback, ok := updates["is_rollback"].(bool); ok {
+		query = query.SetIsRollback(isRollback)
+	}
+	if isMandatory, ok := updates["is_mandatory"].(bool); ok {
+		query = query.SetIsMandatory(isMandatory)
+	}
+	if minVersion, ok := updates["min_supported_version"].(string); ok {
+		if minVersion != "" {
+			query = query.SetMinSupportedVersion(minVersion)
+		} else {
+			query = query.ClearMinSupportedVersion()
+		}
+	}
+	if percentage, ok := updates["rollout_percentage"].(int); ok {
+		query = query.SetRolloutPercentage(percentage)
+	}
+	if schedule, ok := updates["rollout_schedule"].([]domain.RolloutStep); ok {
+		query = query.SetRolloutSchedule(convertRolloutScheduleToEnt(schedule))
+	}
+	if status, ok := updates["rollout_status"].(string); ok {
+		query = query.SetRolloutStatus(upgrade.RolloutStatus(status))
+	}
+	if reason, ok := updates["pause_reason"].(string); ok {
+		if reason != "" {
+			query = query.SetPauseReason(reason)
+		} else {
+			query = query.ClearPauseReason()
+		}
+	}
+	if rules, ok := updates["targeting_rules"].(*domain.TargetingRules); ok {
+		query = query.SetTargetingRules(convertTargetingRulesToEnt(rules))
+	}
+	if startsAt, ok := updates["starts_at"].(*time.Time); ok {
+		if startsAt != nil {
+			query = query.SetStartsAt(*startsAt)
+		} else {
+			query = query.ClearStartsAt()
+		}
+	}
+	if endsAt, ok := updates["ends_at"].(*time.Time); ok {
+		if endsAt != nil {
+			query = query.SetEndsAt(*endsAt)
+		} else {
+			query = query.ClearEndsAt()
+		}
+	}
+	if windows, ok := updates["maintenance_windows"].([]domain.MaintenanceWindow); ok {
+		query = query.SetMaintenanceWindows(convertMaintenanceWindowsToEnt(windows))
+	}
+	if promotedBy, ok := updates["promoted_by"].(string); ok {
+		query = query.SetPromotedBy(promotedBy)
+	}
+	if promotedAt, ok := updates["promoted_at"].(time.Time); ok {
+		query = query.SetPromotedAt(promotedAt)
+	}
+	if promotedFrom, ok := updates["promoted_from"].(string); ok {
+		query = query.SetPromotedFrom(promotedFrom)
+	}
 
 	_, err := query.Save(ctx)
 	return err
@@ -246,53 +388,44 @@ func (r *entUpgradeRepository) DeleteUpgradeTarget(ctx context.Context, id strin
 		Exec(ctx)
 }
 
-func (r *entUpgradeRepository) GetActiveUpgradeTargetByPackageID(ctx context.Context, packageID string) (*domain.UpgradeTarget, error) {
-	u, err := r.client.Upgrade.
+func (r *entUpgradeRepository) GetActiveUpgradeTargetByPackageID(ctx context.Context, packageID, channel string, attrs *domain.TargetingAttributes) (*domain.UpgradeTarget, error) {
+	targets, err := r.GetActiveUpgradeTargetsByPackageID(ctx, packageID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	// 定向规则存储为 JSON，在内存中评估
+	target := domain.SelectUpgradeTarget(targets, attrs, time.Now())
+	if target == nil {
+		return nil, domain.ErrNoMatchingUpgradeTarget
+	}
+	return target, nil
+}
+
+func (r *entUpgradeRepository) GetActiveUpgradeTargetsByPackageID(ctx context.Context, packageID, channel string) ([]*domain.UpgradeTarget, error) {
+	upgrades, err := r.client.Upgrade.
 		Query().
 		Where(
 			upgrade.PackageID(packageID),
+			upgrade.Channel(domain.NormalizeChannel(channel)),
 			upgrade.IsActive(true),
 		).
+		WithTenant().
 		WithProject().
 		WithPackage().
 		WithRelease().
-		First(ctx)
+		Order(ent.Desc(upgrade.FieldCreatedAt)).
+		All(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	target := &domain.UpgradeTarget{
-		ID:          u.ID,
-		TenantID:    u.TenantID,
-		ProjectID:   u.ProjectID,
-		PackageID:   u.PackageID,
-		ReleaseID:   u.ReleaseID,
-		Name:        u.Name,
-		Description: u.Description,
-		IsActive:    u.IsActive,
-		CreatedBy:   u.CreatedBy,
-		CreatedAt:   u.CreatedAt,
-		UpdatedAt:   u.UpdatedAt,
-	}
-
-	// 填充关联信息
-	if u.Edges.Project != nil {
-		target.ProjectName = u.Edges.Project.Name
-	}
-	if u.Edges.Package != nil {
-		target.PackageName = u.Edges.Package.Name
-		target.PackageType = u.Edges.Package.Type.String()
-	}
-	if u.Edges.Release != nil {
-		target.Version = u.Edges.Release.VersionCode
-		target.FileName = u.Edges.Release.FileName
-		target.FileSize = u.Edges.Release.FileSize
-		target.FileHash = u.Edges.Release.FileHash
-		target.DownloadURL = fmt.Sprintf("/client-access/download/%s", u.Edges.Release.ID)
+	result := make([]*domain.UpgradeTarget, 0, len(upgrades))
+	for _, u := range upgrades {
+		result = append(result, convertUpgradeToDomain(u, "/client-access/download/"))
 	}
-
-	return target, nil
+	return result, nil
 }
 
 func (r *entUpgradeRepository) CheckProjectUpgradeTargets(ctx context.Context, projectID string, packageIDs []string) (map[string]*domain.UpgradeTarget, error) {
@@ -312,39 +445,10 @@ func (r *entUpgradeRepository) CheckProjectUpgradeTargets(ctx context.Context, p
 		return nil, err
 	}
 
+	// 一个包可能同时有多个激活目标（不同渠道或定向规则），按升级目标ID索引
 	result := make(map[string]*domain.UpgradeTarget)
 	for _, u := range upgrades {
-		target := &domain.UpgradeTarget{
-			ID:          u.ID,
-			TenantID:    u.TenantID,
-			ProjectID:   u.ProjectID,
-			PackageID:   u.PackageID,
-			ReleaseID:   u.ReleaseID,
-			Name:        u.Name,
-			Description: u.Description,
-			IsActive:    u.IsActive,
-			CreatedBy:   u.CreatedBy,
-			CreatedAt:   u.CreatedAt,
-			UpdatedAt:   u.UpdatedAt,
-		}
-
-		// 填充关联信息
-		if u.Edges.Project != nil {
-			target.ProjectName = u.Edges.Project.Name
-		}
-		if u.Edges.Package != nil {
-			target.PackageName = u.Edges.Package.Name
-			target.PackageType = u.Edges.Package.Type.String()
-		}
-		if u.Edges.Release != nil {
-			target.Version = u.Edges.Release.VersionCode
-			target.FileName = u.Edges.Release.FileName
-			target.FileSize = u.Edges.Release.FileSize
-			target.FileHash = u.Edges.Release.FileHash
-			target.DownloadURL = fmt.Sprintf("/api/files/download/%s", u.Edges.Release.ID)
-		}
-
-		result[u.PackageID] = target
+		result[u.ID] = convertUpgradeToDomain(u, "/api/files/download/")
 	}
 
 	return result, nil
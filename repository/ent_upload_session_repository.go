@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pkms/domain"
+	"pkms/ent"
+	"pkms/ent/uploadsession"
+)
+
+type entUploadSessionRepository struct {
+	client *ent.Client
+}
+
+func NewUploadSessionRepository(client *ent.Client) domain.UploadSessionRepository {
+	return &entUploadSessionRepository{
+		client: client,
+	}
+}
+
+func (r *entUploadSessionRepository) Create(ctx context.Context, s *domain.UploadSession) error {
+	create := r.client.UploadSession.
+		Create().
+		SetTenantID(s.TenantID).
+		SetCreatedBy(s.CreatedBy).
+		SetFileName(s.FileName).
+		SetSize(s.Size).
+		SetExpiresAt(s.ExpiresAt)
+
+	if s.PackageID != "" {
+		create = create.SetPackageID(s.PackageID)
+	}
+	if s.ClientAccessID != "" {
+		create = create.SetClientAccessID(s.ClientAccessID)
+	}
+	if s.ContentType != "" {
+		create = create.SetContentType(s.ContentType)
+	}
+	if s.Metadata != nil {
+		create = create.SetMetadata(s.Metadata)
+	}
+
+	created, err := create.Save(ctx)
+	if err != nil {
+		return err
+	}
+
+	*s = *convertUploadSessionToDomain(created)
+	return nil
+}
+
+func (r *entUploadSessionRepository) GetByID(ctx context.Context, id string) (*domain.UploadSession, error) {
+	s, err := r.client.UploadSession.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertUploadSessionToDomain(s), nil
+}
+
+func (r *entUploadSessionRepository) Update(ctx context.Context, id string, updates map[string]interface{}) error {
+	update := r.client.UploadSession.UpdateOneID(id)
+
+	if offset, ok := updates["offset"].(int64); ok {
+		update = update.SetOffset(offset)
+	}
+	if status, ok := updates["status"].(string); ok {
+		update = update.SetStatus(status)
+	}
+	if expiresAt, ok := updates["expires_at"].(time.Time); ok {
+		update = update.SetExpiresAt(expiresAt)
+	}
+	if releaseID, ok := updates["release_id"].(string); ok {
+		update = update.SetReleaseID(releaseID)
+	}
+
+	return update.Exec(ctx)
+}
+
+func (r *entUploadSessionRepository) UpdateStatus(ctx context.Context, id, from, to string) (bool, error) {
+	n, err := r.client.UploadSession.
+		Update().
+		Where(
+			uploadsession.ID(id),
+			uploadsession.Status(from),
+		).
+		SetStatus(to).
+		Save(ctx)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *entUploadSessionRepository) Delete(ctx context.Context, id string) error {
+	return r.client.UploadSession.DeleteOneID(id).Exec(ctx)
+}
+
+func (r *entUploadSessionRepository) GetExpired(ctx context.Context, before time.Time) ([]*domain.UploadSession, error) {
+	sessions, err := r.client.UploadSession.
+		Query().
+		Where(
+			uploadsession.ExpiresAtLT(before),
+			uploadsession.StatusNEQ(domain.UploadSessionStatusFinalized),
+		).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*domain.UploadSession, len(sessions))
+	for i, s := range sessions {
+		result[i] = convertUploadSessionToDomain(s)
+	}
+	return result, nil
+}
+
+func convertUploadSessionToDomain(s *ent.UploadSession) *domain.UploadSession {
+	return &domain.UploadSession{
+		ID:             s.ID,
+		TenantID:       s.TenantID,
+		PackageID:      s.PackageID,
+		ClientAccessID: s.ClientAccessID,
+		CreatedBy:      s.CreatedBy,
+		FileName:       s.FileName,
+		ContentType:    s.ContentType,
+		Size:           s.Size,
+		Offset:         s.Offset,
+		Metadata:       s.Metadata,
+		Status:         s.Status,
+		ReleaseID:      s.ReleaseID,
+		ExpiresAt:      s.ExpiresAt,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
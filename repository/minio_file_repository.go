@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"io"
+	"net/url"
 	"path"
 	"strings"
 
@@ -10,18 +11,48 @@ import (
 	"pkms/pkg"
 
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 )
 
+// S3Options S3 存储在连接参数之外的选项
+type S3Options struct {
+	Region string // 自动创建存储桶时使用的区域
+	// Prefix 存储桶内的路径前缀，所有对象都存放在该前缀下；数据库中记录的路径不包含前缀，
+	// 因此更换前缀或与其他存储之间迁移时路径保持不变
+	Prefix     string
+	Encryption encrypt.ServerSide // 服务端加密，nil 表示使用存储桶的默认设置
+}
+
 type minioFileRepository struct {
-	client *minio.Client
+	client     *minio.Client
+	region     string
+	prefix     string
+	encryption encrypt.ServerSide
 }
 
-func NewFileRepository(client *minio.Client) domain.FileRepository {
+func NewFileRepository(client *minio.Client, options S3Options) domain.FileRepository {
+	prefix := strings.Trim(options.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
 	return &minioFileRepository{
-		client: client,
+		client:     client,
+		region:     options.Region,
+		prefix:     prefix,
+		encryption: options.Encryption,
 	}
 }
 
+// key 将对象路径转换为存储桶中的实际键
+func (fr *minioFileRepository) key(objectName string) string {
+	return fr.prefix + objectName
+}
+
+// objectPath 将存储桶中的键转换为不含前缀的对象路径
+func (fr *minioFileRepository) objectPath(key string) string {
+	return strings.TrimPrefix(key, fr.prefix)
+}
+
 func (fr *minioFileRepository) Upload(c context.Context, req *domain.UploadRequest) (*domain.UploadResult, error) {
 	// 构建对象名称
 	objectName := req.ObjectName
@@ -34,14 +65,15 @@ func (fr *minioFileRepository) Upload(c context.Context, req *domain.UploadReque
 		return nil, err
 	}
 	if !bucketExists {
-		err = fr.client.MakeBucket(c, req.Bucket, minio.MakeBucketOptions{})
+		err = fr.client.MakeBucket(c, req.Bucket, minio.MakeBucketOptions{Region: fr.region})
 		if err != nil {
 			return nil, err
 		}
 	}
 	// 上传对象到 MinIO
-	info, err := fr.client.PutObject(c, req.Bucket, objectName, req.Reader, req.Size, minio.PutObjectOptions{
-		ContentType: req.ContentType,
+	info, err := fr.client.PutObject(c, req.Bucket, fr.key(objectName), req.Reader, req.Size, minio.PutObjectOptions{
+		ContentType:          req.ContentType,
+		ServerSideEncryption: fr.encryption,
 	})
 	if err != nil {
 		return nil, err
@@ -49,7 +81,7 @@ func (fr *minioFileRepository) Upload(c context.Context, req *domain.UploadReque
 
 	return &domain.UploadResult{
 		Bucket:     info.Bucket,
-		Key:        info.Key,
+		Key:        fr.objectPath(info.Key),
 		ETag:       info.ETag,
 		Size:       info.Size,
 		ObjectName: objectName,
@@ -58,19 +90,65 @@ func (fr *minioFileRepository) Upload(c context.Context, req *domain.UploadReque
 
 func (fr *minioFileRepository) Download(c context.Context, req *domain.DownloadRequest) (io.ReadCloser, error) {
 	options := minio.GetObjectOptions{}
-	object, err := fr.client.GetObject(c, req.Bucket, req.ObjectName, options)
+	object, err := fr.client.GetObject(c, req.Bucket, fr.key(req.ObjectName), options)
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (fr *minioFileRepository) DownloadRange(c context.Context, req *domain.DownloadRequest, offset, length int64) (io.ReadCloser, error) {
+	options := minio.GetObjectOptions{}
+	if offset > 0 || length >= 0 {
+		end := int64(0) // 0 表示读到末尾
+		if length >= 0 {
+			if length == 0 {
+				return io.NopCloser(strings.NewReader("")), nil
+			}
+			end = offset + length - 1
+		}
+		if err := options.SetRange(offset, end); err != nil {
+			return nil, err
+		}
+	}
+	object, err := fr.client.GetObject(c, req.Bucket, fr.key(req.ObjectName), options)
 	if err != nil {
 		return nil, err
 	}
 	return object, nil
 }
 
+func (fr *minioFileRepository) PresignDownload(c context.Context, req *domain.DownloadRequest, opts *domain.PresignOptions) (string, error) {
+	params := url.Values{}
+	if opts.FileName != "" {
+		params.Set("response-content-disposition", "attachment; filename=\""+opts.FileName+"\"")
+	}
+	presigned, err := fr.client.PresignedGetObject(c, req.Bucket, fr.key(req.ObjectName), opts.Expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return presigned.String(), nil
+}
+
 func (fr *minioFileRepository) Delete(c context.Context, bucket, objectName string) error {
-	return fr.client.RemoveObject(c, bucket, objectName, minio.RemoveObjectOptions{})
+	return fr.client.RemoveObject(c, bucket, fr.key(objectName), minio.RemoveObjectOptions{})
+}
+
+// Move S3 没有重命名操作，服务端复制后删除源对象；ComposeObject 支持超过 5GB 的对象
+func (fr *minioFileRepository) Move(c context.Context, bucket, source, target string) error {
+	_, err := fr.client.ComposeObject(c,
+		minio.CopyDestOptions{Bucket: bucket, Object: fr.key(target), Encryption: fr.encryption},
+		minio.CopySrcOptions{Bucket: bucket, Object: fr.key(source)},
+	)
+	if err != nil {
+		return err
+	}
+	return fr.client.RemoveObject(c, bucket, fr.key(source), minio.RemoveObjectOptions{})
 }
 
 func (fr *minioFileRepository) List(c context.Context, bucket, prefix string) ([]domain.FileInfo, error) {
-	options := minio.ListObjectsOptions{Prefix: prefix}
+	// 与磁盘存储一致，递归列出前缀下的全部对象
+	options := minio.ListObjectsOptions{Prefix: fr.key(prefix), Recursive: true}
 	objectsCh := fr.client.ListObjects(c, bucket, options)
 
 	var files []domain.FileInfo
@@ -80,27 +158,28 @@ func (fr *minioFileRepository) List(c context.Context, bucket, prefix string) ([
 		}
 
 		// 如果对象大小为 0 且关键字以 "/" 结尾，则认为是伪目录，直接跳过
-		if object.Size == 0 && strings.HasSuffix(object.Key, "/") {
-			if prefix == object.Key {
+		key := fr.objectPath(object.Key)
+		if object.Size == 0 && strings.HasSuffix(key, "/") {
+			if prefix == key {
 				continue
 			}
 		}
 
-		isFolder := object.Size == 0 && strings.HasSuffix(object.Key, "/")
+		isFolder := object.Size == 0 && strings.HasSuffix(key, "/")
 		var fileType string
 		if isFolder {
 			fileType = "folder"
 		} else {
-			fileType = pkg.GetFileType(object.Key)
+			fileType = pkg.GetFileType(key)
 		}
 
 		fileInfo := domain.FileInfo{
-			Key:          object.Key,
-			Name:         path.Base(object.Key),
+			Key:          key,
+			Name:         path.Base(key),
 			LastModified: object.LastModified,
 			Size:         object.Size,
 			Type:         fileType,
-			Path:         object.Key,
+			Path:         key,
 			IsFolder:     isFolder,
 			ETag:         object.ETag,
 		}
@@ -111,18 +190,19 @@ func (fr *minioFileRepository) List(c context.Context, bucket, prefix string) ([
 }
 
 func (fr *minioFileRepository) GetObjectStat(c context.Context, bucket, objectName string) (*domain.FileInfo, error) {
-	stat, err := fr.client.StatObject(c, bucket, objectName, minio.StatObjectOptions{})
+	stat, err := fr.client.StatObject(c, bucket, fr.key(objectName), minio.StatObjectOptions{})
 	if err != nil {
 		return nil, err
 	}
 
+	key := fr.objectPath(stat.Key)
 	return &domain.FileInfo{
-		Key:          stat.Key,
-		Name:         path.Base(stat.Key),
+		Key:          key,
+		Name:         path.Base(key),
 		LastModified: stat.LastModified,
 		Size:         stat.Size,
-		Type:         pkg.GetFileType(stat.Key),
-		Path:         stat.Key,
+		Type:         pkg.GetFileType(key),
+		Path:         key,
 		IsFolder:     false,
 		ETag:         stat.ETag,
 		ContentType:  stat.ContentType,
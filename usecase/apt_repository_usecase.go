@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pkms/domain"
+	"pkms/internal/deb"
+	"pkms/internal/pgpsign"
+)
+
+// 只有 Architecture: all 的软件包时，Release 中声明的架构
+var defaultAptArchitectures = []string{"amd64", "arm64"}
+
+const aptComponent = "main"
+
+type aptRepositoryUsecase struct {
+	indexer        *linuxPackageIndexer
+	signer         *pgpsign.Signer
+	contextTimeout time.Duration
+	// Release 的 Date 只在索引内容变化时更新，避免每次请求生成不同的 InRelease
+	mu           sync.Mutex
+	releaseDates map[string]aptReleaseDate
+}
+
+type aptReleaseDate struct {
+	digest string
+	date   time.Time
+}
+
+// NewAptRepositoryUsecase signer 为空时不提供 InRelease/Release.gpg，客户端需要以 [trusted=yes] 方式添加仓库
+func NewAptRepositoryUsecase(packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, linuxPackageRepository domain.LinuxPackageRepository, fileRepository domain.FileRepository, signer *pgpsign.Signer, bucket string, timeout time.Duration) domain.AptRepositoryUsecase {
+	return &aptRepositoryUsecase{
+		indexer:        newLinuxPackageIndexer(domain.LinuxPackageFormatDeb, packageRepository, releaseRepository, linuxPackageRepository, fileRepository, bucket),
+		signer:         signer,
+		contextTimeout: timeout,
+		releaseDates:   make(map[string]aptReleaseDate),
+	}
+}
+
+func extractDebPackage(r io.Reader) (*domain.LinuxPackage, error) {
+	control, err := deb.ReadControl(r)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LinuxPackage{
+		Name:         control.Get("Package"),
+		Version:      control.Get("Version"),
+		Architecture: control.Get("Architecture"),
+		Metadata:     control.String(),
+	}, nil
+}
+
+func (au *aptRepositoryUsecase) GetIndexFile(ctx context.Context, scope domain.RepositoryScope, path string) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+
+	// dists/{suite}/{InRelease|Release|Release.gpg} 或 dists/{suite}/main/binary-{arch}/{Packages|Packages.gz}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "dists" || !isRepositoryChannel(parts[1]) {
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+	suite := parts[1]
+
+	entries, err := au.indexer.collect(c, scope)
+	if err != nil {
+		return nil, err
+	}
+	entries = filterRepositoryChannel(entries, suite)
+
+	switch {
+	case len(parts) == 3:
+		release := au.buildRelease(scope, suite, entries)
+		switch parts[2] {
+		case "Release":
+			return release, nil
+		case "InRelease":
+			if au.signer == nil {
+				return nil, domain.ErrRepositoryFileNotFound
+			}
+			return au.signer.ClearSign(release)
+		case "Release.gpg":
+			if au.signer == nil {
+				return nil, domain.ErrRepositoryFileNotFound
+			}
+			return au.signer.DetachSign(release)
+		}
+	case len(parts) == 5 && parts[2] == aptComponent && strings.HasPrefix(parts[3], "binary-"):
+		packages := buildAptPackages(entries, strings.TrimPrefix(parts[3], "binary-"))
+		switch parts[4] {
+		case "Packages":
+			return packages, nil
+		case "Packages.gz":
+			return gzipBytes(packages)
+		}
+	}
+	return nil, domain.ErrRepositoryFileNotFound
+}
+
+func (au *aptRepositoryUsecase) GetPoolPackage(ctx context.Context, scope domain.RepositoryScope, id string) (*domain.LinuxPackage, error) {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	return au.indexer.poolPackage(c, scope, id)
+}
+
+func (au *aptRepositoryUsecase) PublicKey() ([]byte, error) {
+	if au.signer == nil {
+		return nil, errors.New("仓库签名密钥未配置")
+	}
+	return au.signer.PublicKey()
+}
+
+// aptArchitectures 软件包中出现的架构，all 的软件包写入每个架构的索引
+func aptArchitectures(entries []linuxPackageEntry) []string {
+	seen := make(map[string]bool)
+	var architectures []string
+	for _, entry := range entries {
+		if entry.Architecture == "all" || seen[entry.Architecture] {
+			continue
+		}
+		seen[entry.Architecture] = true
+		architectures = append(architectures, entry.Architecture)
+	}
+	if len(architectures) == 0 {
+		return defaultAptArchitectures
+	}
+	sort.Strings(architectures)
+	return architectures
+}
+
+// aptPoolFileName pool/ 下的文件路径，文件名按 Debian 惯例由包名、版本（不含 epoch）和架构组成
+func aptPoolFileName(lp *domain.LinuxPackage) string {
+	version := lp.Version
+	if _, after, ok := strings.Cut(version, ":"); ok {
+		version = after
+	}
+	return fmt.Sprintf("pool/%s/%s_%s_%s.deb", lp.ID, lp.Name, version, lp.Architecture)
+}
+
+// buildAptPackages 生成某个架构的 Packages 索引：control 段落加上文件位置、大小和摘要
+func buildAptPackages(entries []linuxPackageEntry, architecture string) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if entry.Architecture != architecture && entry.Architecture != "all" {
+			continue
+		}
+		control, err := deb.ParseControl(entry.Metadata)
+		if err != nil {
+			continue
+		}
+		for _, name := range []string{"Filename", "Size", "MD5sum", "SHA1", "SHA256", "SHA512"} {
+			control.Del(name)
+		}
+		control.Set("Filename", aptPoolFileName(entry.LinuxPackage))
+		control.Set("Size", strconv.FormatInt(entry.FileSize, 10))
+		control.Set("SHA256", entry.SHA256)
+		if entry.SHA512 != "" {
+			control.Set("SHA512", entry.SHA512)
+		}
+		buf.WriteString(control.String())
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// buildRelease 生成 Release 文件，列出各架构索引文件的大小和摘要
+func (au *aptRepositoryUsecase) buildRelease(scope domain.RepositoryScope, suite string, entries []linuxPackageEntry) []byte {
+	architectures := aptArchitectures(entries)
+
+	var sha256Lines, sha512Lines strings.Builder
+	for _, architecture := range architectures {
+		packages := buildAptPackages(entries, architecture)
+		compressed, _ := gzipBytes(packages)
+		for _, file := range []struct {
+			name string
+			data []byte
+		}{
+			{aptComponent + "/binary-" + architecture + "/Packages", packages},
+			{aptComponent + "/binary-" + architecture + "/Packages.gz", compressed},
+		} {
+			sum256 := sha256.Sum256(file.data)
+			sum512 := sha512.Sum512(file.data)
+			fmt.Fprintf(&sha256Lines, " %s %d %s\n", hex.EncodeToString(sum256[:]), len(file.data), file.name)
+			fmt.Fprintf(&sha512Lines, " %s %d %s\n", hex.EncodeToString(sum512[:]), len(file.data), file.name)
+		}
+	}
+
+	digest := sha256.Sum256([]byte(sha256Lines.String()))
+	date := au.releaseDate(scope.Type+"/"+scope.ID+"/"+suite, hex.EncodeToString(digest[:]))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Origin: pkms\n")
+	fmt.Fprintf(&buf, "Label: pkms\n")
+	fmt.Fprintf(&buf, "Suite: %s\n", suite)
+	fmt.Fprintf(&buf, "Codename: %s\n", suite)
+	fmt.Fprintf(&buf, "Date: %s\n", date.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&buf, "Architectures: %s\n", strings.Join(architectures, " "))
+	fmt.Fprintf(&buf, "Components: %s\n", aptComponent)
+	fmt.Fprintf(&buf, "Description: pkms %s/%s\n", scope.Type, scope.ID)
+	fmt.Fprintf(&buf, "No-Support-for-Architecture-all: Packages\n")
+	buf.WriteString("SHA256:\n")
+	buf.WriteString(sha256Lines.String())
+	buf.WriteString("SHA512:\n")
+	buf.WriteString(sha512Lines.String())
+	return buf.Bytes()
+}
+
+// releaseDate 索引内容不变时沿用上次的时间
+func (au *aptRepositoryUsecase) releaseDate(key, digest string) time.Time {
+	au.mu.Lock()
+	defer au.mu.Unlock()
+	if cached, ok := au.releaseDates[key]; ok && cached.digest == digest {
+		return cached.date
+	}
+	now := time.Now().Truncate(time.Second)
+	au.releaseDates[key] = aptReleaseDate{digest: digest, date: now}
+	return now
+}
+
+// gzipBytes 压缩结果只取决于输入内容（不写入文件名和修改时间）
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -49,11 +49,16 @@ func (u *clientAccessUsecase) Create(ctx context.Context, request *domain.Create
 		return nil, fmt.Errorf("软件包不存在: %w", err)
 	}
 
+	if request.Channel != "" && !domain.IsValidChannel(request.Channel) {
+		return nil, fmt.Errorf("无效的渠道名称: %s", request.Channel)
+	}
+
 	// 创建客户端接入凭证
 	access := &domain.ClientAccess{
 		TenantID:    tenantID,
 		ProjectID:   request.ProjectID,
 		PackageID:   request.PackageID,
+		Channel:     request.Channel,
 		AccessToken: pkg.GenerateAccessToken(),
 		Name:        request.Name,
 		Description: request.Description,
@@ -115,6 +120,12 @@ func (u *clientAccessUsecase) Update(ctx context.Context, id string, request *do
 	if request.ExpiresAt != nil {
 		updates["expires_at"] = request.ExpiresAt
 	}
+	if request.Channel != nil {
+		if *request.Channel != "" && !domain.IsValidChannel(*request.Channel) {
+			return fmt.Errorf("无效的渠道名称: %s", *request.Channel)
+		}
+		updates["channel"] = *request.Channel
+	}
 
 	return u.clientAccessRepository.Update(c, id, updates)
 }
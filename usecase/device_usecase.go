@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pkms/domain"
+)
+
+type deviceUsecase struct {
+	deviceRepository domain.DeviceRepository
+	contextTimeout   time.Duration
+}
+
+func NewDeviceUsecase(deviceRepository domain.DeviceRepository, timeout time.Duration) domain.DeviceUsecase {
+	return &deviceUsecase{
+		deviceRepository: deviceRepository,
+		contextTimeout:   timeout,
+	}
+}
+
+func (du *deviceUsecase) GetDevicesPaged(ctx context.Context, tenantID string, filters map[string]interface{}, params domain.QueryParams) (*domain.DevicePagedResult, error) {
+	c, cancel := context.WithTimeout(ctx, du.contextTimeout)
+	defer cancel()
+
+	return du.deviceRepository.GetDevicesPaged(c, tenantID, filters, params)
+}
+
+func (du *deviceUsecase) GetDeviceByID(ctx context.Context, id string) (*domain.Device, error) {
+	c, cancel := context.WithTimeout(ctx, du.contextTimeout)
+	defer cancel()
+
+	return du.deviceRepository.GetByID(c, id)
+}
+
+func (du *deviceUsecase) GetVersionDistribution(ctx context.Context, tenantID, packageID string, activeSince *time.Time) ([]*domain.DeviceVersionCount, error) {
+	c, cancel := context.WithTimeout(ctx, du.contextTimeout)
+	defer cancel()
+
+	if packageID == "" {
+		return nil, errors.New("package_id 不能为空")
+	}
+	return du.deviceRepository.CountByVersion(c, tenantID, packageID, activeSince)
+}
+
+func (du *deviceUsecase) DeleteDevice(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, du.contextTimeout)
+	defer cancel()
+
+	return du.deviceRepository.Delete(c, id)
+}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"io"
 	"time"
 
@@ -23,7 +26,24 @@ func NewFileUsecase(fileRepository domain.FileRepository, timeout time.Duration)
 func (fu *fileUsecase) Upload(c context.Context, req *domain.UploadRequest) (*domain.UploadResult, error) {
 	ctx, cancel := context.WithTimeout(c, fu.contextTimeout)
 	defer cancel()
-	return fu.fileRepository.Upload(ctx, req)
+
+	if req.Reader == nil {
+		return fu.fileRepository.Upload(ctx, req)
+	}
+
+	// 在写入存储的同时计算 SHA-256/SHA-512，避免上传后再次读取文件
+	sha256Hash := sha256.New()
+	sha512Hash := sha512.New()
+	upload := *req
+	upload.Reader = io.TeeReader(req.Reader, io.MultiWriter(sha256Hash, sha512Hash))
+
+	result, err := fu.fileRepository.Upload(ctx, &upload)
+	if err != nil {
+		return nil, err
+	}
+	result.SHA256 = hex.EncodeToString(sha256Hash.Sum(nil))
+	result.SHA512 = hex.EncodeToString(sha512Hash.Sum(nil))
+	return result, nil
 }
 
 func (fu *fileUsecase) Download(c context.Context, req *domain.DownloadRequest) (io.ReadCloser, error) {
@@ -32,6 +52,17 @@ func (fu *fileUsecase) Download(c context.Context, req *domain.DownloadRequest)
 	return fu.fileRepository.Download(ctx, req)
 }
 
+// DownloadRange 返回的流在请求处理期间持续读取，不能套用 contextTimeout（取消后 MinIO 的流会中断）
+func (fu *fileUsecase) DownloadRange(c context.Context, req *domain.DownloadRequest, offset, length int64) (io.ReadCloser, error) {
+	return fu.fileRepository.DownloadRange(c, req, offset, length)
+}
+
+func (fu *fileUsecase) PresignDownload(c context.Context, req *domain.DownloadRequest, opts *domain.PresignOptions) (string, error) {
+	ctx, cancel := context.WithTimeout(c, fu.contextTimeout)
+	defer cancel()
+	return fu.fileRepository.PresignDownload(ctx, req, opts)
+}
+
 func (fu *fileUsecase) Delete(c context.Context, bucket, objectName string) error {
 	ctx, cancel := context.WithTimeout(c, fu.contextTimeout)
 	defer cancel()
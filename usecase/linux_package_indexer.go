@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"pkms/domain"
+	"pkms/pkg"
+)
+
+// linuxPackageEntry 仓库中的一个软件包文件
+type linuxPackageEntry struct {
+	*domain.LinuxPackage
+	Stable bool // 所属版本是否为稳定版本
+}
+
+// linuxPackageExtractor 从软件包文件中读取名称、版本、架构和元数据
+type linuxPackageExtractor func(r io.Reader) (*domain.LinuxPackage, error)
+
+// linuxPackageIndexer 收集仓库范围内已发布版本中的软件包文件
+// 元数据在版本文件写入时提取并记录（见 IndexRelease），早于此的文件在首次访问时补充记录；无法解析的文件同样记录，不再重复读取
+type linuxPackageIndexer struct {
+	packageRepository      domain.PackageRepository
+	releaseRepository      domain.ReleaseRepository
+	linuxPackageRepository domain.LinuxPackageRepository
+	fileRepository         domain.FileRepository
+	bucket                 string
+	format                 string
+	extract                linuxPackageExtractor
+}
+
+func newLinuxPackageIndexer(format string, packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, linuxPackageRepository domain.LinuxPackageRepository, fileRepository domain.FileRepository, bucket string) *linuxPackageIndexer {
+	extract := extractDebPackage
+	if format == domain.LinuxPackageFormatRPM {
+		extract = extractRPMPackage
+	}
+	return &linuxPackageIndexer{
+		packageRepository:      packageRepository,
+		releaseRepository:      releaseRepository,
+		linuxPackageRepository: linuxPackageRepository,
+		fileRepository:         fileRepository,
+		bucket:                 bucket,
+		format:                 format,
+		extract:                extract,
+	}
+}
+
+// scopePackages 仓库范围内的 linux 类型包
+func (ix *linuxPackageIndexer) scopePackages(c context.Context, scope domain.RepositoryScope) ([]*domain.Package, error) {
+	switch scope.Type {
+	case domain.RepositoryScopeProject:
+		all, err := ix.packageRepository.GetByProjectID(c, scope.ID)
+		if err != nil {
+			return nil, err
+		}
+		var packages []*domain.Package
+		for _, p := range all {
+			if p.Type == domain.PackageTypeLinux {
+				packages = append(packages, p)
+			}
+		}
+		return packages, nil
+	case domain.RepositoryScopePackage:
+		p, err := ix.packageRepository.GetByID(c, scope.ID)
+		if err != nil || p.Type != domain.PackageTypeLinux {
+			return nil, domain.ErrRepositoryFileNotFound
+		}
+		return []*domain.Package{p}, nil
+	default:
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+}
+
+// collect 返回仓库范围内所有已发布版本中的软件包文件，按名称和创建时间排序
+func (ix *linuxPackageIndexer) collect(c context.Context, scope domain.RepositoryScope) ([]linuxPackageEntry, error) {
+	packages, err := ix.scopePackages(c, scope)
+	if err != nil {
+		return nil, err
+	}
+	packageIDs := make([]string, len(packages))
+	for i, p := range packages {
+		packageIDs[i] = p.ID
+	}
+
+	indexed, err := ix.linuxPackageRepository.ListByPackageIDs(c, packageIDs, ix.format)
+	if err != nil {
+		return nil, err
+	}
+	byFile := make(map[string]*domain.LinuxPackage, len(indexed))
+	for _, p := range indexed {
+		byFile[p.ReleaseID+"/"+p.FilePath] = p
+	}
+
+	var entries []linuxPackageEntry
+	for _, p := range packages {
+		releases, err := ix.releaseRepository.GetByPackageID(c, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, release := range releases {
+			if release.IsDraft {
+				continue
+			}
+			for _, file := range ix.releaseFiles(release) {
+				lp := byFile[release.ID+"/"+file.FilePath]
+				if lp == nil {
+					if lp, err = ix.index(c, p, release, file); err != nil {
+						pkg.Log.Printf("Failed to read %s package %s: %v", ix.format, file.FilePath, err)
+					}
+				}
+				if lp == nil || lp.Error != "" {
+					continue
+				}
+				entries = append(entries, linuxPackageEntry{LinuxPackage: lp, Stable: release.IsStable()})
+			}
+		}
+	}
+
+	sortLinuxPackageEntries(entries)
+	return entries, nil
+}
+
+// releaseFiles 版本主文件和构件中扩展名匹配的文件，路径相同的只保留一个
+func (ix *linuxPackageIndexer) releaseFiles(release *domain.Release) []*domain.LinuxPackage {
+	suffix := "." + ix.format
+	seen := make(map[string]bool)
+	var files []*domain.LinuxPackage
+	add := func(filePath, fileName string, fileSize int64, sha256, sha512 string) {
+		if filePath == "" || seen[filePath] || !strings.HasSuffix(strings.ToLower(fileName), suffix) {
+			return
+		}
+		seen[filePath] = true
+		files = append(files, &domain.LinuxPackage{
+			FilePath: filePath,
+			FileName: fileName,
+			FileSize: fileSize,
+			SHA256:   sha256,
+			SHA512:   sha512,
+		})
+	}
+	add(release.FilePath, release.FileName, release.FileSize, release.SHA256, release.SHA512)
+	for _, asset := range release.Assets {
+		add(asset.FilePath, asset.FileName, asset.FileSize, asset.SHA256, asset.SHA512)
+	}
+	return files
+}
+
+// indexRelease 记录版本中尚未提取元数据的软件包文件，返回无法解析或记录失败的文件错误
+func (ix *linuxPackageIndexer) indexRelease(c context.Context, p *domain.Package, release *domain.Release) error {
+	indexed, err := ix.linuxPackageRepository.ListByPackageIDs(c, []string{p.ID}, ix.format)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]bool, len(indexed))
+	for _, lp := range indexed {
+		if lp.ReleaseID == release.ID {
+			recorded[lp.FilePath] = true
+		}
+	}
+
+	var errs []error
+	for _, file := range ix.releaseFiles(release) {
+		if recorded[file.FilePath] {
+			continue
+		}
+		if _, err := ix.index(c, p, release, file); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.FileName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// index 读取文件提取元数据并记录；文件无法解析时记录错误信息，返回该记录和解析错误
+// 读取存储失败不做记录，下次访问时重试。并发提取同一个文件时由唯一索引保证只记录一次
+func (ix *linuxPackageIndexer) index(c context.Context, p *domain.Package, release *domain.Release, file *domain.LinuxPackage) (*domain.LinuxPackage, error) {
+	reader, err := ix.fileRepository.Download(c, &domain.DownloadRequest{Bucket: ix.bucket, ObjectName: file.FilePath})
+	if err != nil {
+		return nil, err
+	}
+	extracted, err := ix.extract(reader)
+	reader.Close()
+	if err == nil && file.SHA256 == "" {
+		err = fmt.Errorf("missing SHA-256 for %s", file.FilePath)
+	}
+
+	lp := &domain.LinuxPackage{
+		ProjectID: p.ProjectID,
+		PackageID: p.ID,
+		ReleaseID: release.ID,
+		Format:    ix.format,
+		FilePath:  file.FilePath,
+		FileName:  file.FileName,
+		FileSize:  file.FileSize,
+		SHA256:    file.SHA256,
+		SHA512:    file.SHA512,
+	}
+	if err != nil {
+		lp.Error = err.Error()
+	} else {
+		lp.Name = extracted.Name
+		lp.Version = extracted.Version
+		lp.Architecture = extracted.Architecture
+		lp.Metadata = extracted.Metadata
+	}
+	if err := ix.linuxPackageRepository.Create(c, lp); err != nil {
+		return nil, err
+	}
+	if lp.Error != "" {
+		return lp, errors.New(lp.Error)
+	}
+	return lp, nil
+}
+
+// poolPackage 获取仓库范围内的软件包文件
+func (ix *linuxPackageIndexer) poolPackage(c context.Context, scope domain.RepositoryScope, id string) (*domain.LinuxPackage, error) {
+	lp, err := ix.linuxPackageRepository.GetByID(c, id)
+	if err != nil || lp.Format != ix.format {
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+	switch {
+	case scope.Type == domain.RepositoryScopeProject && lp.ProjectID == scope.ID:
+	case scope.Type == domain.RepositoryScopePackage && lp.PackageID == scope.ID:
+	default:
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+
+	// 版本被删除或改为草稿后不再提供
+	release, err := ix.releaseRepository.GetByID(c, lp.ReleaseID)
+	if err != nil || release.IsDraft {
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+	return lp, nil
+}
+
+func isRepositoryChannel(channel string) bool {
+	return channel == domain.RepositoryChannelStable || channel == domain.RepositoryChannelTesting
+}
+
+// filterRepositoryChannel stable 只包含稳定版本，testing 包含所有已发布版本
+func filterRepositoryChannel(entries []linuxPackageEntry, channel string) []linuxPackageEntry {
+	if channel != domain.RepositoryChannelStable {
+		return entries
+	}
+	var filtered []linuxPackageEntry
+	for _, entry := range entries {
+		if entry.Stable {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func sortLinuxPackageEntries(entries []linuxPackageEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+}
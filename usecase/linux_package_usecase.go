@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pkms/domain"
+)
+
+type linuxPackageUsecase struct {
+	packageRepository domain.PackageRepository
+	releaseRepository domain.ReleaseRepository
+	indexers          []*linuxPackageIndexer
+	contextTimeout    time.Duration
+}
+
+// NewLinuxPackageUsecase formats 为需要提取的文件格式（已启用的 APT/YUM 仓库）
+func NewLinuxPackageUsecase(packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, linuxPackageRepository domain.LinuxPackageRepository, fileRepository domain.FileRepository, bucket string, formats []string, timeout time.Duration) domain.LinuxPackageUsecase {
+	lu := &linuxPackageUsecase{
+		packageRepository: packageRepository,
+		releaseRepository: releaseRepository,
+		contextTimeout:    timeout,
+	}
+	for _, format := range formats {
+		lu.indexers = append(lu.indexers, newLinuxPackageIndexer(format, packageRepository, releaseRepository, linuxPackageRepository, fileRepository, bucket))
+	}
+	return lu
+}
+
+func (lu *linuxPackageUsecase) IndexRelease(c context.Context, releaseID string) error {
+	ctx, cancel := context.WithTimeout(c, lu.contextTimeout)
+	defer cancel()
+
+	release, err := lu.releaseRepository.GetByID(ctx, releaseID)
+	if err != nil {
+		return err
+	}
+	p, err := lu.packageRepository.GetByID(ctx, release.PackageID)
+	if err != nil {
+		return err
+	}
+	if p.Type != domain.PackageTypeLinux {
+		return nil
+	}
+
+	var errs []error
+	for _, ix := range lu.indexers {
+		if err := ix.indexRelease(ctx, p, release); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
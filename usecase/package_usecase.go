@@ -3,9 +3,11 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"pkms/domain"
+	"pkms/internal/versioning"
 )
 
 type packageUsecase struct {
@@ -30,6 +32,9 @@ func NewPackageUsecase(
 func (pu *packageUsecase) CreatePackage(c context.Context, pkg *domain.Package) error {
 	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
 	defer cancel()
+	if pkg.VersionScheme != "" && !versioning.IsValidScheme(pkg.VersionScheme) {
+		return fmt.Errorf("不支持的版本号方案: %s", pkg.VersionScheme)
+	}
 	return pu.packageRepository.Create(ctx, pkg)
 }
 
@@ -54,6 +59,9 @@ func (pu *packageUsecase) GetPackagesByProject(c context.Context, projectID stri
 func (pu *packageUsecase) UpdatePackage(c context.Context, pkg *domain.Package) error {
 	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
 	defer cancel()
+	if pkg.VersionScheme != "" && !versioning.IsValidScheme(pkg.VersionScheme) {
+		return fmt.Errorf("不支持的版本号方案: %s", pkg.VersionScheme)
+	}
 	return pu.packageRepository.Update(ctx, pkg)
 }
 
@@ -78,7 +86,7 @@ func (pu *packageUsecase) DeletePackage(c context.Context, id string) error {
 func (pu *packageUsecase) GetLatestRelease(c context.Context, packageID string) (*domain.Release, error) {
 	ctx, cancel := context.WithTimeout(c, pu.contextTimeout)
 	defer cancel()
-	return pu.releaseRepository.GetLatestByPackageID(ctx, packageID)
+	return resolveLatestRelease(ctx, pu.packageRepository, pu.releaseRepository, packageID)
 }
 
 func (pu *packageUsecase) IncrementDownloadCount(c context.Context, releaseID string) error {
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/delta"
+	"pkms/pkg"
+)
+
+// patchFile 参与差分的一个文件（版本主文件或某个平台构件）
+type patchFile struct {
+	assetID  string
+	os       string
+	arch     string
+	kind     string
+	filePath string
+	fileSize int64
+}
+
+type patchUsecase struct {
+	releaseRepository domain.ReleaseRepository
+	fileRepository    domain.FileRepository
+	env               *bootstrap.Env
+	contextTimeout    time.Duration
+	// 差分计算占用大量内存，同一时间只生成一个补丁
+	mu sync.Mutex
+}
+
+func NewPatchUsecase(
+	releaseRepository domain.ReleaseRepository,
+	fileRepository domain.FileRepository,
+	env *bootstrap.Env,
+	timeout time.Duration,
+) domain.PatchUsecase {
+	return &patchUsecase{
+		releaseRepository: releaseRepository,
+		fileRepository:    fileRepository,
+		env:               env,
+		contextTimeout:    timeout,
+	}
+}
+
+func (pu *patchUsecase) GeneratePatches(ctx context.Context, toReleaseID string) error {
+	if !pu.env.DeltaPatchEnabled {
+		return nil
+	}
+
+	target, err := pu.releaseRepository.GetByID(ctx, toReleaseID)
+	if err != nil {
+		return fmt.Errorf("版本不存在: %w", err)
+	}
+
+	// 取目标版本之前最近的若干个旧版本
+	releases, err := pu.releaseRepository.GetByPackageID(ctx, target.PackageID)
+	if err != nil {
+		return fmt.Errorf("获取版本列表失败: %w", err)
+	}
+	var previous []*domain.Release
+	for _, r := range releases {
+		if r.ID != target.ID && !r.IsDraft && r.CreatedAt.Before(target.CreatedAt) {
+			previous = append(previous, r)
+		}
+	}
+	sort.Slice(previous, func(i, j int) bool {
+		return previous[i].CreatedAt.After(previous[j].CreatedAt)
+	})
+	if limit := pu.env.DeltaPatchPreviousReleases; limit >= 0 && len(previous) > limit {
+		previous = previous[:limit]
+	}
+
+	targetFiles := releasePatchFiles(target)
+	for _, from := range previous {
+		fromFiles := releasePatchFiles(from)
+		for _, tf := range targetFiles {
+			ff := matchPatchFile(fromFiles, tf)
+			if ff == nil {
+				continue
+			}
+			if err := pu.generatePatch(ctx, target, from, ff, tf); err != nil {
+				pkg.Log.Errorf("生成差分补丁失败 %s -> %s: %v", from.ID, target.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pu *patchUsecase) FindPatch(ctx context.Context, fromReleaseID, toReleaseID, toAssetID string) (*domain.ReleasePatch, error) {
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	patch, err := pu.releaseRepository.GetPatch(c, fromReleaseID, toReleaseID, toAssetID)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Status != domain.PatchStatusReady {
+		return nil, errors.New("补丁尚未生成")
+	}
+	return patch, nil
+}
+
+func (pu *patchUsecase) GetPatchByID(ctx context.Context, id string) (*domain.ReleasePatch, error) {
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+
+	return pu.releaseRepository.GetPatchByID(c, id)
+}
+
+// generatePatch 生成单个补丁：记录状态 -> 计算差分 -> 上传补丁文件 -> 更新为可用
+func (pu *patchUsecase) generatePatch(ctx context.Context, target, from *domain.Release, ff, tf *patchFile) error {
+	maxSize := pu.env.DeltaPatchMaxSizeMB << 20
+	if ff.fileSize > maxSize || tf.fileSize > maxSize {
+		return nil
+	}
+
+	existing, err := pu.releaseRepository.GetPatch(ctx, from.ID, target.ID, tf.assetID)
+	if err == nil && (existing.Status == domain.PatchStatusReady || (existing.Status == domain.PatchStatusPending && !pu.isStalePending(existing))) {
+		// 已生成或正在生成
+		return nil
+	}
+
+	patch := existing
+	if patch == nil {
+		patch = &domain.ReleasePatch{
+			PackageID:     target.PackageID,
+			FromReleaseID: from.ID,
+			ToReleaseID:   target.ID,
+			ToAssetID:     tf.assetID,
+			Format:        delta.Format,
+			Status:        domain.PatchStatusPending,
+		}
+		if err := pu.releaseRepository.CreatePatch(ctx, patch); err != nil {
+			return err
+		}
+	} else if err := pu.releaseRepository.UpdatePatch(ctx, patch.ID, map[string]interface{}{
+		"status": domain.PatchStatusPending,
+		"error":  "",
+	}); err != nil {
+		return err
+	}
+
+	updates, err := pu.buildPatch(ctx, patch, ff, tf)
+	if err != nil {
+		updates = map[string]interface{}{
+			"status": domain.PatchStatusFailed,
+			"error":  err.Error(),
+		}
+	}
+	if updateErr := pu.releaseRepository.UpdatePatch(ctx, patch.ID, updates); updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+func (pu *patchUsecase) buildPatch(ctx context.Context, patch *domain.ReleasePatch, ff, tf *patchFile) (map[string]interface{}, error) {
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+
+	oldData, err := pu.readFile(ctx, ff.filePath, ff.fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("读取旧版本文件失败: %w", err)
+	}
+	newData, err := pu.readFile(ctx, tf.filePath, tf.fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标版本文件失败: %w", err)
+	}
+
+	// 补丁写入临时文件，避免与新旧文件、后缀数组同时占用内存
+	patchFile, err := os.CreateTemp("", "pkms-patch-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(patchFile.Name())
+	defer patchFile.Close()
+
+	if err := delta.Diff(oldData, newData, patchFile); err != nil {
+		return nil, fmt.Errorf("计算差分失败: %w", err)
+	}
+	sum := sha256.Sum256(newData)
+	targetSize := int64(len(newData))
+	oldData, newData = nil, nil
+
+	patchSize, err := patchFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	// 补丁不比完整文件小时没有意义
+	if patchSize >= targetSize {
+		return nil, errors.New("补丁不小于完整文件，跳过")
+	}
+	if _, err := patchFile.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	uploadResult, err := pu.fileRepository.Upload(ctx, &domain.UploadRequest{
+		Bucket:      pu.env.S3Bucket,
+		ObjectName:  fmt.Sprintf("%s_%s.patch", patch.FromReleaseID, patch.ID),
+		Prefix:      path.Join(path.Dir(tf.filePath), "patches"),
+		Reader:      patchFile,
+		Size:        patchSize,
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("上传补丁文件失败: %w", err)
+	}
+
+	return map[string]interface{}{
+		"status":      domain.PatchStatusReady,
+		"error":       "",
+		"file_path":   uploadResult.Key,
+		"file_size":   patchSize,
+		"target_hash": hex.EncodeToString(sum[:]),
+		"target_size": targetSize,
+	}, nil
+}
+
+// readFile 按记录的文件大小一次分配内存读取文件，避免 io.ReadAll 扩容时的额外占用
+func (pu *patchUsecase) readFile(ctx context.Context, filePath string, size int64) ([]byte, error) {
+	reader, err := pu.fileRepository.Download(ctx, &domain.DownloadRequest{
+		Bucket:     pu.env.S3Bucket,
+		ObjectName: filePath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, fmt.Errorf("文件大小与记录不一致: %w", err)
+	}
+	if n, _ := reader.Read(make([]byte, 1)); n > 0 {
+		return nil, errors.New("文件大小与记录不一致")
+	}
+	return data, nil
+}
+
+// isStalePending 补丁处于生成中超过超时时间，视为生成进程已中断
+func (pu *patchUsecase) isStalePending(patch *domain.ReleasePatch) bool {
+	timeout := time.Duration(pu.env.DeltaPatchPendingTimeout) * time.Minute
+	return timeout > 0 && time.Since(patch.UpdatedAt) > timeout
+}
+
+// releasePatchFiles 列出版本中可生成补丁的文件，校验和文件除外。
+// 主文件同时登记为构件时（如 GoReleaser 发布的第一个平台）按该构件记录，补丁以构件 ID 关联
+func releasePatchFiles(release *domain.Release) []*patchFile {
+	var files []*patchFile
+	primaryIsAsset := false
+	for _, asset := range release.Assets {
+		if asset.Kind == "checksum" || asset.FilePath == "" {
+			continue
+		}
+		if asset.FilePath == release.FilePath {
+			if primaryIsAsset {
+				continue
+			}
+			primaryIsAsset = true
+		}
+		files = append(files, &patchFile{
+			assetID:  asset.ID,
+			os:       asset.OS,
+			arch:     asset.Arch,
+			kind:     asset.Kind,
+			filePath: asset.FilePath,
+			fileSize: asset.FileSize,
+		})
+	}
+	if release.FilePath != "" && !primaryIsAsset {
+		files = append([]*patchFile{{filePath: release.FilePath, fileSize: release.FileSize}}, files...)
+	}
+	return files
+}
+
+// matchPatchFile 在旧版本中找到与目标文件对应的文件，主文件与构件一样要求 os/arch/kind 精确匹配
+func matchPatchFile(files []*patchFile, target *patchFile) *patchFile {
+	for _, f := range files {
+		if f.os == target.os && f.arch == target.arch && f.kind == target.kind {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"pkms/bootstrap"
+	"pkms/domain"
+	"pkms/internal/delta"
+	"pkms/repository"
+	"pkms/usecase"
+)
+
+const patchBucket = "pkms"
+
+// memoryPatchRepository 只实现生成补丁所需方法的版本仓库
+type memoryPatchRepository struct {
+	domain.ReleaseRepository
+	releases []*domain.Release
+	patches  []*domain.ReleasePatch
+}
+
+func (r *memoryPatchRepository) GetByID(_ context.Context, id string) (*domain.Release, error) {
+	for _, release := range r.releases {
+		if release.ID == id {
+			return release, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *memoryPatchRepository) GetByPackageID(context.Context, string) ([]*domain.Release, error) {
+	return r.releases, nil
+}
+
+func (r *memoryPatchRepository) GetPatch(_ context.Context, fromReleaseID, toReleaseID, toAssetID string) (*domain.ReleasePatch, error) {
+	for _, p := range r.patches {
+		if p.FromReleaseID == fromReleaseID && p.ToReleaseID == toReleaseID && p.ToAssetID == toAssetID {
+			return p, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *memoryPatchRepository) CreatePatch(_ context.Context, patch *domain.ReleasePatch) error {
+	patch.ID = "patch" + string(rune('0'+len(r.patches)+1))
+	r.patches = append(r.patches, patch)
+	return nil
+}
+
+func (r *memoryPatchRepository) UpdatePatch(_ context.Context, id string, updates map[string]interface{}) error {
+	for _, p := range r.patches {
+		if p.ID != id {
+			continue
+		}
+		for key, value := range updates {
+			switch key {
+			case "status":
+				p.Status = value.(string)
+			case "error":
+				p.Error = value.(string)
+			case "file_path":
+				p.FilePath = value.(string)
+			case "target_hash":
+				p.TargetHash = value.(string)
+			}
+		}
+	}
+	return nil
+}
+
+type patchFixture struct {
+	storage  domain.FileRepository
+	releases *memoryPatchRepository
+	usecase  domain.PatchUsecase
+	contents map[string][]byte
+}
+
+func newPatchFixture(t *testing.T) *patchFixture {
+	t.Helper()
+	f := &patchFixture{
+		storage:  repository.NewDiskFileRepository(t.TempDir(), "secret"),
+		releases: &memoryPatchRepository{},
+		contents: make(map[string][]byte),
+	}
+	env := &bootstrap.Env{
+		S3Bucket:                   patchBucket,
+		DeltaPatchEnabled:          true,
+		DeltaPatchPreviousReleases: 3,
+		DeltaPatchMaxSizeMB:        1,
+	}
+	f.usecase = usecase.NewPatchUsecase(f.releases, f.storage, env, time.Minute)
+	return f
+}
+
+// asset 写入构件文件并返回构件记录
+func (f *patchFixture) asset(t *testing.T, id, goos, arch, filePath string, data []byte) *domain.ReleaseAsset {
+	t.Helper()
+	if _, err := f.storage.Upload(context.Background(), &domain.UploadRequest{
+		Bucket:     patchBucket,
+		ObjectName: filePath,
+		Reader:     bytes.NewReader(data),
+		Size:       int64(len(data)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f.contents[filePath] = data
+	return &domain.ReleaseAsset{ID: id, OS: goos, Arch: arch, Kind: "archive", FilePath: filePath, FileSize: int64(len(data))}
+}
+
+// release 登记版本，主文件为第一个构件，与 GoReleaser 发布的版本一致
+func (f *patchFixture) release(id string, createdAt time.Time, assets ...*domain.ReleaseAsset) *domain.Release {
+	release := &domain.Release{
+		ID:        id,
+		PackageID: "pkg",
+		FilePath:  assets[0].FilePath,
+		FileSize:  assets[0].FileSize,
+		CreatedAt: createdAt,
+		Assets:    assets,
+	}
+	f.releases.releases = append(f.releases.releases, release)
+	return release
+}
+
+// applyPatch 将补丁应用到旧文件，返回结果的 SHA-256
+func (f *patchFixture) applyPatch(t *testing.T, patch *domain.ReleasePatch, oldPath string) string {
+	t.Helper()
+	result, err := delta.Patch(f.contents[oldPath], strings.NewReader(readObject(t, f.storage, patch.FilePath)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha256Hex(result)
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	data := make([]byte, n)
+	r.Read(data)
+	return data
+}
+
+// edited 复制数据并改动其中几个字节
+func edited(data []byte) []byte {
+	out := append([]byte(nil), data...)
+	for i := 100; i < len(out); i += len(out) / 4 {
+		out[i] ^= 0xff
+	}
+	return out
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestGeneratePatchesKeysPrimaryAssetByAssetID(t *testing.T) {
+	f := newPatchFixture(t)
+	r := rand.New(rand.NewSource(1))
+	linux, windows := randomBytes(r, 32<<10), randomBytes(r, 32<<10)
+	now := time.Now()
+
+	f.release("old", now.Add(-time.Hour),
+		f.asset(t, "old-linux", "linux", "amd64", "p/1.0/app_linux_amd64.tar.gz", linux),
+		f.asset(t, "old-windows", "windows", "amd64", "p/1.0/app_windows_amd64.zip", windows),
+	)
+	f.release("new", now,
+		f.asset(t, "new-linux", "linux", "amd64", "p/1.1/app_linux_amd64.tar.gz", edited(linux)),
+		f.asset(t, "new-windows", "windows", "amd64", "p/1.1/app_windows_amd64.zip", edited(windows)),
+	)
+
+	if err := f.usecase.GeneratePatches(context.Background(), "new"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.releases.patches) != 2 {
+		t.Fatalf("expected 2 patches, got %d", len(f.releases.patches))
+	}
+	for _, assetID := range []string{"new-linux", "new-windows"} {
+		patch, err := f.usecase.FindPatch(context.Background(), "old", "new", assetID)
+		if err != nil {
+			t.Fatalf("%s: %v", assetID, err)
+		}
+		if patch.TargetHash == "" {
+			t.Fatalf("%s: missing target hash", assetID)
+		}
+	}
+	if _, err := f.usecase.FindPatch(context.Background(), "old", "new", ""); err == nil {
+		t.Fatal("primary file shared with an asset should not get an anonymous patch")
+	}
+}
+
+func TestGeneratePatchesMatchesPrimaryByPlatform(t *testing.T) {
+	f := newPatchFixture(t)
+	r := rand.New(rand.NewSource(2))
+	linux, windows := randomBytes(r, 32<<10), randomBytes(r, 32<<10)
+	now := time.Now()
+
+	// 两个版本首个上传的平台不同，主文件不能直接配对
+	f.release("old", now.Add(-time.Hour),
+		f.asset(t, "old-windows", "windows", "amd64", "p/1.0/app_windows_amd64.zip", windows),
+		f.asset(t, "old-linux", "linux", "amd64", "p/1.0/app_linux_amd64.tar.gz", linux),
+	)
+	f.release("new", now,
+		f.asset(t, "new-linux", "linux", "amd64", "p/1.1/app_linux_amd64.tar.gz", edited(linux)),
+		f.asset(t, "new-windows", "windows", "amd64", "p/1.1/app_windows_amd64.zip", edited(windows)),
+	)
+
+	if err := f.usecase.GeneratePatches(context.Background(), "new"); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		oldPath string
+		want    []byte
+	}{
+		"new-linux":   {"p/1.0/app_linux_amd64.tar.gz", edited(linux)},
+		"new-windows": {"p/1.0/app_windows_amd64.zip", edited(windows)},
+	}
+	for assetID, tt := range tests {
+		patch, err := f.usecase.FindPatch(context.Background(), "old", "new", assetID)
+		if err != nil {
+			t.Fatalf("%s: %v", assetID, err)
+		}
+		if got := f.applyPatch(t, patch, tt.oldPath); got != sha256Hex(tt.want) || got != patch.TargetHash {
+			t.Fatalf("%s: patched hash %s, want %s", assetID, got, sha256Hex(tt.want))
+		}
+	}
+}
+
+func TestGeneratedPatchAppliesToStoredFile(t *testing.T) {
+	f := newPatchFixture(t)
+	r := rand.New(rand.NewSource(3))
+	data := randomBytes(r, 48<<10)
+	now := time.Now()
+
+	// 只有主文件、没有构件的版本
+	primary := func(id, filePath string, data []byte, createdAt time.Time) {
+		asset := f.asset(t, "", "", "", filePath, data)
+		f.releases.releases = append(f.releases.releases, &domain.Release{
+			ID: id, PackageID: "pkg", FilePath: asset.FilePath, FileSize: asset.FileSize, CreatedAt: createdAt,
+		})
+	}
+	primary("old", "p/1.0/app.bin", data, now.Add(-time.Hour))
+	primary("new", "p/1.1/app.bin", append(edited(data), randomBytes(r, 512)...), now)
+
+	if err := f.usecase.GeneratePatches(context.Background(), "new"); err != nil {
+		t.Fatal(err)
+	}
+	patch, err := f.usecase.FindPatch(context.Background(), "old", "new", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from := readObject(t, f.storage, "p/1.0/app.bin")
+	result, err := delta.Patch([]byte(from), strings.NewReader(readObject(t, f.storage, patch.FilePath)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sha256Hex([]byte(readObject(t, f.storage, "p/1.1/app.bin")))
+	if got := sha256Hex(result); got != want || patch.TargetHash != want {
+		t.Fatalf("patched hash %s, target hash %s, want %s", got, patch.TargetHash, want)
+	}
+}
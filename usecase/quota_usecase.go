@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"pkms/domain"
+)
+
+type quotaUsecase struct {
+	quotaRepository domain.QuotaRepository
+	warningPercent  float64 // 用量达到该百分比时标记为接近上限
+	contextTimeout  time.Duration
+}
+
+func NewQuotaUsecase(quotaRepository domain.QuotaRepository, warningPercent float64, timeout time.Duration) domain.QuotaUsecase {
+	return &quotaUsecase{
+		quotaRepository: quotaRepository,
+		warningPercent:  warningPercent,
+		contextTimeout:  timeout,
+	}
+}
+
+// CheckUpload 并发上传之间不加锁，极端情况下可能略微超出存储总量上限
+func (qu *quotaUsecase) CheckUpload(c context.Context, packageID string, fileSize int64, newRelease bool) error {
+	ctx, cancel := context.WithTimeout(c, qu.contextTimeout)
+	defer cancel()
+
+	tenantID, err := qu.quotaRepository.GetPackageTenantID(ctx, packageID)
+	if err != nil {
+		return fmt.Errorf("获取包所属租户失败: %w", err)
+	}
+	quota, err := qu.quotaRepository.GetQuota(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("获取租户配额失败: %w", err)
+	}
+
+	if quota.MaxFileSize > 0 && fileSize > quota.MaxFileSize {
+		return fmt.Errorf("%w: 文件大小 %d 字节超过单个文件上限 %d 字节", domain.ErrFileTooLarge, fileSize, quota.MaxFileSize)
+	}
+
+	if newRelease && quota.MaxReleasesPerPackage > 0 {
+		count, err := qu.quotaRepository.CountReleases(ctx, packageID)
+		if err != nil {
+			return fmt.Errorf("统计包的版本数失败: %w", err)
+		}
+		if count >= quota.MaxReleasesPerPackage {
+			return fmt.Errorf("%w: 包的版本数已达上限 %d", domain.ErrQuotaExceeded, quota.MaxReleasesPerPackage)
+		}
+	}
+
+	if quota.QuotaBytes > 0 {
+		usage, err := qu.quotaRepository.GetTenantUsage(ctx, tenantID)
+		if err != nil {
+			return fmt.Errorf("统计租户存储用量失败: %w", err)
+		}
+		if usage.UsedBytes+fileSize > quota.QuotaBytes {
+			return fmt.Errorf("%w: 已用 %d 字节，上传 %d 字节后将超过存储上限 %d 字节",
+				domain.ErrQuotaExceeded, usage.UsedBytes, fileSize, quota.QuotaBytes)
+		}
+	}
+
+	return nil
+}
+
+func (qu *quotaUsecase) GetQuota(c context.Context, tenantID string) (*domain.TenantQuota, error) {
+	ctx, cancel := context.WithTimeout(c, qu.contextTimeout)
+	defer cancel()
+	return qu.quotaRepository.GetQuota(ctx, tenantID)
+}
+
+func (qu *quotaUsecase) UpdateQuota(c context.Context, tenantID string, quota *domain.TenantQuota) error {
+	ctx, cancel := context.WithTimeout(c, qu.contextTimeout)
+	defer cancel()
+
+	if quota.QuotaBytes < 0 || quota.MaxFileSize < 0 || quota.MaxReleasesPerPackage < 0 {
+		return fmt.Errorf("配额不能为负数")
+	}
+	return qu.quotaRepository.UpdateQuota(ctx, tenantID, quota)
+}
+
+func (qu *quotaUsecase) GetUsage(c context.Context, tenantID string) (*domain.TenantUsage, error) {
+	ctx, cancel := context.WithTimeout(c, qu.contextTimeout)
+	defer cancel()
+
+	usage, err := qu.quotaRepository.GetTenantUsage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	qu.fillPercent(usage)
+	return usage, nil
+}
+
+func (qu *quotaUsecase) ListUsage(c context.Context) ([]*domain.TenantUsage, error) {
+	ctx, cancel := context.WithTimeout(c, qu.contextTimeout)
+	defer cancel()
+
+	tenantIDs, err := qu.quotaRepository.ListTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]*domain.TenantUsage, 0, len(tenantIDs))
+	for _, tenantID := range tenantIDs {
+		usage, err := qu.quotaRepository.GetTenantUsage(ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("统计租户 %s 存储用量失败: %w", tenantID, err)
+		}
+		qu.fillPercent(usage)
+		usage.Projects = nil
+		usages = append(usages, usage)
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].UsagePercent != usages[j].UsagePercent {
+			return usages[i].UsagePercent > usages[j].UsagePercent
+		}
+		return usages[i].UsedBytes > usages[j].UsedBytes
+	})
+	return usages, nil
+}
+
+func (qu *quotaUsecase) fillPercent(usage *domain.TenantUsage) {
+	if usage.Quota.QuotaBytes <= 0 {
+		return
+	}
+	usage.UsagePercent = float64(usage.UsedBytes) * 100 / float64(usage.Quota.QuotaBytes)
+	usage.NearLimit = qu.warningPercent > 0 && usage.UsagePercent >= qu.warningPercent
+}
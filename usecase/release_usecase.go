@@ -2,7 +2,10 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"pkms/bootstrap"
+	"pkms/internal/versioning"
 	"pkms/pkg"
 	"time"
 
@@ -36,7 +39,16 @@ func NewReleaseUsecase(
 func (ru *releaseUsecase) CreateRelease(c context.Context, release *domain.Release) error {
 	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
 	defer cancel()
-	return ru.releaseRepository.Create(ctx, release)
+
+	if err := ru.releaseRepository.Create(ctx, release); err != nil {
+		return err
+	}
+
+	// 已发布且标记为最新的版本需要清除其他版本的标记；草稿在发布时处理
+	if release.IsLatest && !release.IsDraft {
+		return ru.releaseRepository.SetAsLatest(ctx, release.PackageID, release.ID)
+	}
+	return nil
 }
 
 func (ru *releaseUsecase) GetReleaseByID(c context.Context, id string) (*domain.Release, error) {
@@ -54,7 +66,70 @@ func (ru *releaseUsecase) GetReleasesByPackage(c context.Context, packageID stri
 func (ru *releaseUsecase) GetLatestRelease(c context.Context, packageID string) (*domain.Release, error) {
 	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
 	defer cancel()
-	return ru.releaseRepository.GetLatestByPackageID(ctx, packageID)
+	return resolveLatestRelease(ctx, ru.packageRepository, ru.releaseRepository, packageID)
+}
+
+func (ru *releaseUsecase) PublishRelease(c context.Context, id string) (*domain.Release, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	release, err := ru.releaseRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("版本不存在: %w", err)
+	}
+	if !release.IsDraft {
+		return nil, errors.New("版本已发布")
+	}
+
+	if err := ru.releaseRepository.Publish(ctx, id); err != nil {
+		return nil, fmt.Errorf("发布版本失败: %w", err)
+	}
+	// 草稿创建时要求标记为最新的，发布后生效
+	if release.IsLatest {
+		if err := ru.releaseRepository.SetAsLatest(ctx, release.PackageID, id); err != nil {
+			return nil, fmt.Errorf("标记最新版本失败: %w", err)
+		}
+	}
+	return ru.releaseRepository.GetByID(ctx, id)
+}
+
+func (ru *releaseUsecase) SetLatestRelease(c context.Context, id string) (*domain.Release, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	release, err := ru.releaseRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("版本不存在: %w", err)
+	}
+	if release.IsDraft {
+		return nil, errors.New("草稿版本不能标记为最新版本，请先发布")
+	}
+	if !release.IsStable() {
+		return nil, errors.New("预发布版本不能标记为最新版本")
+	}
+
+	if err := ru.releaseRepository.SetAsLatest(ctx, release.PackageID, id); err != nil {
+		return nil, fmt.Errorf("标记最新版本失败: %w", err)
+	}
+	return ru.releaseRepository.GetByID(ctx, id)
+}
+
+// resolveLatestRelease 按包的版本方案计算最新稳定版本
+func resolveLatestRelease(ctx context.Context, packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, packageID string) (*domain.Release, error) {
+	scheme := versioning.SchemeAuto
+	if p, err := packageRepository.GetByID(ctx, packageID); err == nil && p.VersionScheme != "" {
+		scheme = p.VersionScheme
+	}
+
+	releases, err := releaseRepository.GetByPackageID(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	latest := domain.ResolveLatestStable(releases, scheme)
+	if latest == nil {
+		return nil, errors.New("没有已发布的稳定版本")
+	}
+	return latest, nil
 }
 
 func (ru *releaseUsecase) DeleteRelease(c context.Context, id string) error {
@@ -67,6 +142,28 @@ func (ru *releaseUsecase) DeleteRelease(c context.Context, id string) error {
 		return err
 	}
 
+	// 删除版本下各平台构件的文件（与主文件相同的路径跳过）
+	for _, asset := range release.Assets {
+		if asset.FilePath == "" || asset.FilePath == release.FilePath {
+			continue
+		}
+		if err := ru.fileRepository.Delete(ctx, ru.env.S3Bucket, asset.FilePath); err != nil {
+			pkg.Log.Printf("Failed to delete asset file %s: %v", asset.FilePath, err)
+		}
+	}
+
+	// 删除与该版本相关的差分补丁文件
+	if patches, err := ru.releaseRepository.GetPatchesByReleaseID(ctx, id); err == nil {
+		for _, patch := range patches {
+			if patch.FilePath == "" {
+				continue
+			}
+			if err := ru.fileRepository.Delete(ctx, ru.env.S3Bucket, patch.FilePath); err != nil {
+				pkg.Log.Printf("Failed to delete patch file %s: %v", patch.FilePath, err)
+			}
+		}
+	}
+
 	// 删除存储中的文件
 	if release.FilePath != "" {
 		if err := ru.fileRepository.Delete(ctx, ru.env.S3Bucket, release.FilePath); err != nil {
@@ -80,6 +177,24 @@ func (ru *releaseUsecase) DeleteRelease(c context.Context, id string) error {
 	return ru.releaseRepository.Delete(ctx, id)
 }
 
+func (ru *releaseUsecase) AddAsset(c context.Context, asset *domain.ReleaseAsset) error {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	// 同一版本下每个平台/类型只允许一个构件
+	assets, err := ru.releaseRepository.GetAssetsByReleaseID(ctx, asset.ReleaseID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range assets {
+		if existing.OS == asset.OS && existing.Arch == asset.Arch && existing.Kind == asset.Kind {
+			return fmt.Errorf("构件已存在: os=%s arch=%s kind=%s", asset.OS, asset.Arch, asset.Kind)
+		}
+	}
+
+	return ru.releaseRepository.CreateAsset(ctx, asset)
+}
+
 func (ru *releaseUsecase) IncrementDownloadCount(c context.Context, releaseID string) error {
 	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
 	defer cancel()
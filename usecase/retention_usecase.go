@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"pkms/domain"
+	"pkms/internal/versioning"
+	"pkms/pkg"
+)
+
+type retentionUsecase struct {
+	retentionPolicyRepository domain.RetentionPolicyRepository
+	projectRepository         domain.ProjectRepository
+	packageRepository         domain.PackageRepository
+	releaseUsecase            domain.ReleaseUsecase
+	contextTimeout            time.Duration
+}
+
+func NewRetentionUsecase(
+	retentionPolicyRepository domain.RetentionPolicyRepository,
+	projectRepository domain.ProjectRepository,
+	packageRepository domain.PackageRepository,
+	releaseUsecase domain.ReleaseUsecase,
+	timeout time.Duration,
+) domain.RetentionUsecase {
+	return &retentionUsecase{
+		retentionPolicyRepository: retentionPolicyRepository,
+		projectRepository:         projectRepository,
+		packageRepository:         packageRepository,
+		releaseUsecase:            releaseUsecase,
+		contextTimeout:            timeout,
+	}
+}
+
+func (ru *retentionUsecase) CreatePolicy(c context.Context, tenantID, createdBy string, request *domain.CreateRetentionPolicyRequest) (*domain.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	project, err := ru.projectRepository.GetByID(ctx, request.ProjectID)
+	if err != nil || project.TenantID != tenantID {
+		return nil, errors.New("项目不存在")
+	}
+	if request.PackageID != "" {
+		p, err := ru.packageRepository.GetByID(ctx, request.PackageID)
+		if err != nil || p.ProjectID != request.ProjectID {
+			return nil, errors.New("包不存在或不属于该项目")
+		}
+	}
+	if request.KeepLast == 0 && request.PrereleaseMaxAgeDays == 0 {
+		return nil, errors.New("至少需要设置 keep_last 或 prerelease_max_age_days")
+	}
+
+	policy := &domain.RetentionPolicy{
+		TenantID:             tenantID,
+		ProjectID:            request.ProjectID,
+		PackageID:            request.PackageID,
+		KeepLast:             request.KeepLast,
+		PrereleaseMaxAgeDays: request.PrereleaseMaxAgeDays,
+		Enabled:              request.Enabled == nil || *request.Enabled,
+		CreatedBy:            createdBy,
+	}
+	if err := ru.retentionPolicyRepository.Create(ctx, policy); err != nil {
+		return nil, fmt.Errorf("创建保留策略失败（同一项目或包只能有一条策略）: %w", err)
+	}
+	return policy, nil
+}
+
+func (ru *retentionUsecase) ListPolicies(c context.Context, tenantID, projectID string) ([]*domain.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+	return ru.retentionPolicyRepository.ListByTenant(ctx, tenantID, projectID)
+}
+
+func (ru *retentionUsecase) UpdatePolicy(c context.Context, tenantID, id string, request *domain.UpdateRetentionPolicyRequest) (*domain.RetentionPolicy, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	policy, err := ru.getTenantPolicy(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if request.KeepLast != nil {
+		updates["keep_last"] = *request.KeepLast
+		policy.KeepLast = *request.KeepLast
+	}
+	if request.PrereleaseMaxAgeDays != nil {
+		updates["prerelease_max_age_days"] = *request.PrereleaseMaxAgeDays
+		policy.PrereleaseMaxAgeDays = *request.PrereleaseMaxAgeDays
+	}
+	if request.Enabled != nil {
+		updates["enabled"] = *request.Enabled
+	}
+	if policy.KeepLast == 0 && policy.PrereleaseMaxAgeDays == 0 {
+		return nil, errors.New("至少需要设置 keep_last 或 prerelease_max_age_days")
+	}
+
+	if err := ru.retentionPolicyRepository.Update(ctx, id, updates); err != nil {
+		return nil, err
+	}
+	return ru.retentionPolicyRepository.GetByID(ctx, id)
+}
+
+func (ru *retentionUsecase) DeletePolicy(c context.Context, tenantID, id string) error {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	if _, err := ru.getTenantPolicy(ctx, tenantID, id); err != nil {
+		return err
+	}
+	return ru.retentionPolicyRepository.Delete(ctx, id)
+}
+
+func (ru *retentionUsecase) getTenantPolicy(ctx context.Context, tenantID, id string) (*domain.RetentionPolicy, error) {
+	policy, err := ru.retentionPolicyRepository.GetByID(ctx, id)
+	if err != nil || policy.TenantID != tenantID {
+		return nil, errors.New("保留策略不存在")
+	}
+	return policy, nil
+}
+
+func (ru *retentionUsecase) Evaluate(c context.Context, tenantID, projectID string, dryRun bool) (*domain.RetentionResult, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	policies, err := ru.retentionPolicyRepository.ListByTenant(ctx, tenantID, projectID)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []*domain.RetentionPolicy
+	for _, policy := range policies {
+		if policy.Enabled {
+			enabled = append(enabled, policy)
+		}
+	}
+	return ru.apply(c, enabled, dryRun), nil
+}
+
+func (ru *retentionUsecase) ApplyAll(c context.Context) (*domain.RetentionResult, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	policies, err := ru.retentionPolicyRepository.ListEnabled(ctx)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return ru.apply(c, policies, false), nil
+}
+
+// apply 为每个包选出生效的策略（包级优先于项目级）并计算待删除版本，非 dry-run 时逐个删除
+func (ru *retentionUsecase) apply(c context.Context, policies []*domain.RetentionPolicy, dryRun bool) *domain.RetentionResult {
+	result := &domain.RetentionResult{DryRun: dryRun, Candidates: []domain.RetentionCandidate{}}
+
+	packagePolicies := make(map[string]*domain.RetentionPolicy)
+	for _, policy := range policies {
+		if policy.PackageID != "" {
+			packagePolicies[policy.PackageID] = policy
+		}
+	}
+
+	evaluated := make(map[string]bool)
+	evaluate := func(p *domain.Package, policy *domain.RetentionPolicy) {
+		if evaluated[p.ID] {
+			return
+		}
+		evaluated[p.ID] = true
+
+		candidates, err := ru.candidates(c, p, policy)
+		if err != nil {
+			pkg.Log.Errorf("评估包 %s 的保留策略失败: %v", p.ID, err)
+			return
+		}
+		for _, candidate := range candidates {
+			if !dryRun {
+				if err := ru.releaseUsecase.DeleteRelease(c, candidate.ReleaseID); err != nil {
+					pkg.Log.Errorf("按保留策略删除版本 %s 失败: %v", candidate.ReleaseID, err)
+					result.Failed++
+					continue
+				}
+				pkg.Log.Printf("按保留策略删除版本 %s (%s %s, %s)", candidate.ReleaseID, p.Name, candidate.VersionCode, candidate.Reason)
+				result.Deleted++
+			}
+			result.FreedBytes += candidate.FileSize
+			result.Candidates = append(result.Candidates, candidate)
+		}
+	}
+
+	for _, policy := range policies {
+		ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+		if policy.PackageID != "" {
+			p, err := ru.packageRepository.GetByID(ctx, policy.PackageID)
+			cancel()
+			if err != nil {
+				// 包已被删除，策略不再生效
+				continue
+			}
+			evaluate(p, policy)
+			continue
+		}
+
+		packages, err := ru.packageRepository.GetByProjectID(ctx, policy.ProjectID)
+		cancel()
+		if err != nil {
+			pkg.Log.Errorf("获取项目 %s 的包失败: %v", policy.ProjectID, err)
+			continue
+		}
+		for _, p := range packages {
+			if packagePolicy, ok := packagePolicies[p.ID]; ok {
+				evaluate(p, packagePolicy)
+			} else {
+				evaluate(p, policy)
+			}
+		}
+	}
+	return result
+}
+
+// candidates 按创建时间从新到旧排列，超出 keep_last 或超龄的预发布版本为候选；受保护的版本跳过但仍计入 keep_last
+func (ru *retentionUsecase) candidates(c context.Context, p *domain.Package, policy *domain.RetentionPolicy) ([]domain.RetentionCandidate, error) {
+	ctx, cancel := context.WithTimeout(c, ru.contextTimeout)
+	defer cancel()
+
+	releases, err := ru.releaseUsecase.GetReleasesByPackage(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	protected, err := ru.retentionPolicyRepository.GetProtectedReleaseIDs(ctx, p.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if latest, err := ru.releaseUsecase.GetLatestRelease(ctx, p.ID); err == nil && latest != nil {
+		protected[latest.ID] = true
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].CreatedAt.After(releases[j].CreatedAt)
+	})
+
+	prereleaseDeadline := now.AddDate(0, 0, -policy.PrereleaseMaxAgeDays)
+	var candidates []domain.RetentionCandidate
+	for i, r := range releases {
+		if r.IsLatest || protected[r.ID] {
+			continue
+		}
+
+		var reason string
+		switch {
+		case policy.KeepLast > 0 && i >= policy.KeepLast:
+			reason = domain.RetentionReasonKeepLast
+		case policy.PrereleaseMaxAgeDays > 0 && (r.IsPrerelease || versioning.IsPrerelease(r.Version())) && r.CreatedAt.Before(prereleaseDeadline):
+			reason = domain.RetentionReasonPrereleaseAge
+		default:
+			continue
+		}
+
+		candidates = append(candidates, domain.RetentionCandidate{
+			PolicyID:    policy.ID,
+			ProjectID:   p.ProjectID,
+			PackageID:   p.ID,
+			PackageName: p.Name,
+			ReleaseID:   r.ID,
+			VersionCode: r.VersionCode,
+			VersionName: r.VersionName,
+			FileSize:    r.FileSize,
+			CreatedAt:   r.CreatedAt,
+			Reason:      reason,
+		})
+	}
+	return candidates, nil
+}
+
+// StartRetentionScheduler 按固定间隔对所有租户执行保留策略
+func StartRetentionScheduler(retentionUsecase domain.RetentionUsecase, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			result, err := retentionUsecase.ApplyAll(context.Background())
+			if err != nil {
+				pkg.Log.Errorf("执行版本保留策略失败: %v", err)
+				continue
+			}
+			if result.Deleted > 0 || result.Failed > 0 {
+				pkg.Log.Printf("版本保留策略执行完成: 删除 %d 个版本，失败 %d 个，释放 %d 字节", result.Deleted, result.Failed, result.FreedBytes)
+			}
+		}
+	}()
+}
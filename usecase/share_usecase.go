@@ -34,6 +34,9 @@ func (su *shareUsecase) CreateShare(c context.Context, req *domain.CreateShareRe
 	if err != nil {
 		return nil, fmt.Errorf("release not found: %w", err)
 	}
+	if release.IsDraft {
+		return nil, fmt.Errorf("draft release cannot be shared, publish it first")
+	}
 
 	// Generate unique share code
 	var shareCode string
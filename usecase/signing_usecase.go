@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"pkms/domain"
+)
+
+type signingUsecase struct {
+	signingKeyRepository domain.SigningKeyRepository
+	// 用于加密私钥种子的 AES-256 密钥，由服务端配置的密钥派生
+	sealKey        []byte
+	contextTimeout time.Duration
+}
+
+func NewSigningUsecase(signingKeyRepository domain.SigningKeyRepository, secret string, timeout time.Duration) domain.SigningUsecase {
+	sealKey := sha256.Sum256([]byte(secret))
+	return &signingUsecase{
+		signingKeyRepository: signingKeyRepository,
+		sealKey:              sealKey[:],
+		contextTimeout:       timeout,
+	}
+}
+
+func (su *signingUsecase) ListKeys(ctx context.Context, tenantID string) ([]*domain.SigningKey, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	return su.signingKeyRepository.ListByTenant(c, tenantID)
+}
+
+func (su *signingUsecase) RotateKey(ctx context.Context, tenantID string) (*domain.SigningKey, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	key, err := su.generateKey(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 旧密钥保留公钥，客户端仍可用其校验轮换前的签名
+	err = su.signingKeyRepository.Rotate(c, key, time.Now())
+	if errors.Is(err, domain.ErrActiveSigningKeyExists) {
+		// 同时有其他请求完成了轮换，以其生成的密钥为准
+		return su.getActiveKey(c, tenantID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("轮换签名密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+func (su *signingUsecase) SignArtifact(ctx context.Context, tenantID, sha512Hex string) (*domain.ArtifactSignature, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	digest, err := hex.DecodeString(sha512Hex)
+	if err != nil || len(digest) != sha512.Size {
+		return nil, errors.New("无效的 SHA-512 摘要")
+	}
+
+	key, privateKey, err := su.activeKey(c, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	// Ed25519ph：客户端计算文件的 SHA-512 后用 ed25519.VerifyWithOptions(Hash: SHA512) 校验
+	signature, err := privateKey.Sign(nil, digest, &ed25519.Options{Hash: crypto.SHA512})
+	if err != nil {
+		return nil, fmt.Errorf("签名失败: %w", err)
+	}
+	return &domain.ArtifactSignature{
+		KeyID:     key.KeyID,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+func (su *signingUsecase) SignManifest(ctx context.Context, tenantID string, manifest interface{}) (*domain.ManifestSignature, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("序列化清单失败: %w", err)
+	}
+
+	key, privateKey, err := su.activeKey(c, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ManifestSignature{
+		KeyID:     key.KeyID,
+		Algorithm: domain.SignatureAlgorithmEd25519,
+		Payload:   base64.StdEncoding.EncodeToString(payload),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, payload)),
+	}, nil
+}
+
+func (su *signingUsecase) PublicKeysPEM(ctx context.Context, tenantID string) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	keys, err := su.signingKeyRepository.ListByTenant(c, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取签名密钥失败: %w", err)
+	}
+	// 租户还没有密钥时先生成，保证客户端可以提前内置公钥
+	if len(keys) == 0 {
+		key, err := su.createKey(c, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		keys = []*domain.SigningKey{key}
+	}
+
+	var out []byte
+	for _, key := range keys {
+		raw, err := base64.StdEncoding.DecodeString(key.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("解析公钥失败: %w", err)
+		}
+		der, err := x509.MarshalPKIXPublicKey(ed25519.PublicKey(raw))
+		if err != nil {
+			return nil, fmt.Errorf("编码公钥失败: %w", err)
+		}
+		out = append(out, pem.EncodeToMemory(&pem.Block{
+			Type:    "PUBLIC KEY",
+			Headers: map[string]string{"Key-Id": key.KeyID, "Status": key.Status},
+			Bytes:   der,
+		})...)
+	}
+	return out, nil
+}
+
+// activeKey 获取租户的 active 密钥并解密私钥，租户没有密钥时自动生成
+func (su *signingUsecase) activeKey(c context.Context, tenantID string) (*domain.SigningKey, ed25519.PrivateKey, error) {
+	key, err := su.signingKeyRepository.GetActiveByTenant(c, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取签名密钥失败: %w", err)
+	}
+	if key == nil {
+		if key, err = su.createKey(c, tenantID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	seed, err := su.open(key.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解密签名密钥失败: %w", err)
+	}
+	return key, ed25519.NewKeyFromSeed(seed), nil
+}
+
+// createKey 为还没有 active 密钥的租户生成密钥，并发生成时使用先保存的密钥
+func (su *signingUsecase) createKey(c context.Context, tenantID string) (*domain.SigningKey, error) {
+	key, err := su.generateKey(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	err = su.signingKeyRepository.Create(c, key)
+	if errors.Is(err, domain.ErrActiveSigningKeyExists) {
+		return su.getActiveKey(c, tenantID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("保存签名密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+func (su *signingUsecase) getActiveKey(c context.Context, tenantID string) (*domain.SigningKey, error) {
+	key, err := su.signingKeyRepository.GetActiveByTenant(c, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取签名密钥失败: %w", err)
+	}
+	if key == nil {
+		return nil, errors.New("签名密钥不存在")
+	}
+	return key, nil
+}
+
+// generateKey 生成密钥并加密私钥种子，不保存
+func (su *signingUsecase) generateKey(tenantID string) (*domain.SigningKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	sealed, err := su.seal(privateKey.Seed())
+	if err != nil {
+		return nil, fmt.Errorf("加密签名密钥失败: %w", err)
+	}
+
+	fingerprint := sha256.Sum256(publicKey)
+	return &domain.SigningKey{
+		TenantID:   tenantID,
+		KeyID:      hex.EncodeToString(fingerprint[:8]),
+		Algorithm:  domain.SignatureAlgorithmEd25519,
+		PublicKey:  base64.StdEncoding.EncodeToString(publicKey),
+		PrivateKey: sealed,
+	}, nil
+}
+
+// seal 使用 AES-256-GCM 加密私钥种子，结果为 Base64(nonce || ciphertext)
+func (su *signingUsecase) seal(plaintext []byte) (string, error) {
+	gcm, err := su.gcm()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (su *signingUsecase) open(sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := su.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("密文长度无效")
+	}
+	seed, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("私钥长度无效")
+	}
+	return seed, nil
+}
+
+func (su *signingUsecase) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(su.sealKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
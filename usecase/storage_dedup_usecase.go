@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pkms/domain"
+)
+
+type storageDedupUsecase struct {
+	releaseRepository domain.ReleaseRepository
+	blobRepository    domain.BlobRepository
+	fileStorage       domain.FileRepository
+	bucket            string
+	contextTimeout    time.Duration
+}
+
+// NewStorageDedupUsecase fileStorage 应为未经内容寻址包装的存储，迁移过程直接移动和删除对象
+func NewStorageDedupUsecase(releaseRepository domain.ReleaseRepository, blobRepository domain.BlobRepository, fileStorage domain.FileRepository, bucket string, timeout time.Duration) domain.StorageDedupUsecase {
+	return &storageDedupUsecase{
+		releaseRepository: releaseRepository,
+		blobRepository:    blobRepository,
+		fileStorage:       fileStorage,
+		bucket:            bucket,
+		contextTimeout:    timeout,
+	}
+}
+
+func (su *storageDedupUsecase) Dedupe(ctx context.Context) (*domain.StorageDedupResult, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	objects, err := su.releaseRepository.ListStorageObjects(c)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("获取存储对象失败: %w", err)
+	}
+
+	result := &domain.StorageDedupResult{}
+	// 版本与其第一个构件可能引用同一个文件，按路径只处理一次
+	seen := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		if seen[object.Path] {
+			continue
+		}
+		seen[object.Path] = true
+		result.Total++
+
+		if _, ok := domain.BlobHashFromPath(object.Path); ok {
+			result.Skipped++
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		deduplicated, err := su.dedupeObject(ctx, object)
+		if err != nil {
+			result.Failed++
+			if len(result.Failures) < maxMigrationFailures {
+				result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", object.Path, err))
+			}
+			continue
+		}
+		if deduplicated {
+			result.Deduplicated++
+			result.SavedBytes += object.Size
+		} else {
+			result.Moved++
+		}
+	}
+	return result, nil
+}
+
+// dedupeObject 将单个对象迁移到内容寻址路径，返回是否与已有对象重复
+func (su *storageDedupUsecase) dedupeObject(ctx context.Context, object domain.StorageObject) (bool, error) {
+	hash, _, err := hashObject(ctx, su.fileStorage, su.bucket, object.Path)
+	if err != nil {
+		return false, fmt.Errorf("读取失败: %v", err)
+	}
+	if object.SHA256 != "" && object.SHA256 != hash {
+		return false, fmt.Errorf("SHA-256 不一致，期望 %s，实际 %s", object.SHA256, hash)
+	}
+
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	existing, err := su.blobRepository.GetByHash(c, hash)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if _, err := su.blobRepository.AddRef(c, hash); err != nil {
+			return false, err
+		}
+		if err := su.releaseRepository.ReplaceFilePath(c, object.Path, existing.Path); err != nil {
+			_, _, _ = su.blobRepository.Release(c, hash)
+			return false, fmt.Errorf("更新引用失败: %v", err)
+		}
+		if err := su.fileStorage.Delete(c, su.bucket, object.Path); err != nil {
+			return false, fmt.Errorf("删除重复对象失败: %v", err)
+		}
+		return true, nil
+	}
+
+	blobPath := domain.BlobPath(hash)
+	if err := su.fileStorage.Move(c, su.bucket, object.Path, blobPath); err != nil {
+		return false, fmt.Errorf("移动失败: %v", err)
+	}
+	if err := su.blobRepository.Create(c, &domain.Blob{Hash: hash, Path: blobPath, Size: object.Size}); err != nil {
+		_ = su.fileStorage.Move(c, su.bucket, blobPath, object.Path)
+		return false, err
+	}
+	if err := su.releaseRepository.ReplaceFilePath(c, object.Path, blobPath); err != nil {
+		_, _, _ = su.blobRepository.Release(c, hash)
+		_ = su.fileStorage.Move(c, su.bucket, blobPath, object.Path)
+		return false, fmt.Errorf("更新引用失败: %v", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"pkms/domain"
+	"pkms/pkg"
+)
+
+// storageMigrationRunning 同一进程内同一时间只运行一个迁移任务
+var storageMigrationRunning atomic.Bool
+
+// maxMigrationFailures 任务记录中保留的失败对象数量上限
+const maxMigrationFailures = 100
+
+type storageMigrationUsecase struct {
+	storageMigrationRepository domain.StorageMigrationRepository
+	releaseRepository          domain.ReleaseRepository
+	source                     domain.FileRepository
+	sourceType                 string
+	bucket                     string
+	openStorage                func(storageType string) (domain.FileRepository, error)
+	contextTimeout             time.Duration
+}
+
+// NewStorageMigrationUsecase source 为当前使用的存储，openStorage 按类型创建目标存储
+func NewStorageMigrationUsecase(storageMigrationRepository domain.StorageMigrationRepository, releaseRepository domain.ReleaseRepository, source domain.FileRepository, sourceType, bucket string, openStorage func(storageType string) (domain.FileRepository, error), timeout time.Duration) domain.StorageMigrationUsecase {
+	return &storageMigrationUsecase{
+		storageMigrationRepository: storageMigrationRepository,
+		releaseRepository:          releaseRepository,
+		source:                     source,
+		sourceType:                 sourceType,
+		bucket:                     bucket,
+		openStorage:                openStorage,
+		contextTimeout:             timeout,
+	}
+}
+
+func (su *storageMigrationUsecase) Prepare(ctx context.Context, target, createdBy string) (*domain.StorageMigration, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	if target != domain.StorageTypeDisk && target != domain.StorageTypeMinio {
+		return nil, fmt.Errorf("不支持的存储类型: %s", target)
+	}
+	if target == su.sourceType {
+		return nil, fmt.Errorf("目标存储与当前存储相同: %s", target)
+	}
+	if storageMigrationRunning.Load() {
+		return nil, errors.New("已有存储迁移任务正在运行")
+	}
+
+	// 存在未完成的任务时继续执行，已处理过的对象会被跳过
+	existing, err := su.storageMigrationRepository.GetResumable(c, su.sourceType, target)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := su.storageMigrationRepository.Update(c, existing.ID, map[string]interface{}{
+			"status": domain.StorageMigrationRunning,
+			"error":  "",
+		}); err != nil {
+			return nil, err
+		}
+		existing.Status = domain.StorageMigrationRunning
+		pkg.Log.Printf("继续存储迁移任务 %s (%s -> %s)，从 %q 之后开始", existing.ID, existing.Source, existing.Target, existing.Cursor)
+		return existing, nil
+	}
+
+	migration := &domain.StorageMigration{
+		Source:    su.sourceType,
+		Target:    target,
+		CreatedBy: createdBy,
+	}
+	if err := su.storageMigrationRepository.Create(c, migration); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+func (su *storageMigrationUsecase) Run(ctx context.Context, migration *domain.StorageMigration) error {
+	if !storageMigrationRunning.CompareAndSwap(false, true) {
+		return errors.New("已有存储迁移任务正在运行")
+	}
+	defer storageMigrationRunning.Store(false)
+
+	err := su.run(ctx, migration)
+	if err != nil {
+		status := domain.StorageMigrationInterrupted
+		pkg.Log.Errorf("存储迁移任务 %s 中断: %v", migration.ID, err)
+		su.update(migration.ID, map[string]interface{}{
+			"status": status,
+			"error":  err.Error(),
+		})
+		migration.Status, migration.Error = status, err.Error()
+	}
+	return err
+}
+
+func (su *storageMigrationUsecase) run(ctx context.Context, migration *domain.StorageMigration) error {
+	target, err := su.openStorage(migration.Target)
+	if err != nil {
+		return fmt.Errorf("打开目标存储失败: %w", err)
+	}
+
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	objects, err := su.releaseRepository.ListStorageObjects(c)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("获取存储对象失败: %w", err)
+	}
+
+	// 每次执行重新统计，上次已完成的对象计入 skipped；上次失败的对象重新校验
+	resumeCursor := migration.Cursor
+	failedBefore := make(map[string]bool, len(migration.Failures))
+	for _, failure := range migration.Failures {
+		if path, _, found := strings.Cut(failure, ": "); found {
+			failedBefore[path] = true
+		}
+	}
+	migration.Total, migration.Processed, migration.Copied, migration.Skipped, migration.Failed = len(objects), 0, 0, 0, 0
+	migration.Bytes, migration.Failures = 0, nil
+	su.update(migration.ID, map[string]interface{}{"total": migration.Total})
+	pkg.Log.Printf("存储迁移任务 %s 开始: %s -> %s，共 %d 个对象", migration.ID, migration.Source, migration.Target, migration.Total)
+
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		verified := resumeCursor != "" && object.Path <= resumeCursor && !failedBefore[object.Path]
+		copied, err := su.migrateObject(ctx, target, object, verified)
+		switch {
+		case err != nil:
+			migration.Failed++
+			if len(migration.Failures) < maxMigrationFailures {
+				migration.Failures = append(migration.Failures, object.Path+": "+err.Error())
+			}
+			pkg.Log.Errorf("迁移对象失败 %s: %v", object.Path, err)
+		case copied:
+			migration.Copied++
+			migration.Bytes += object.Size
+		default:
+			migration.Skipped++
+		}
+		migration.Processed++
+		if object.Path > migration.Cursor {
+			migration.Cursor = object.Path
+		}
+
+		su.update(migration.ID, map[string]interface{}{
+			"processed": migration.Processed,
+			"copied":    migration.Copied,
+			"skipped":   migration.Skipped,
+			"failed":    migration.Failed,
+			"bytes":     migration.Bytes,
+			"cursor":    migration.Cursor,
+			"failures":  migration.Failures,
+		})
+		pkg.Log.Printf("存储迁移进度 %d/%d (复制 %d，跳过 %d，失败 %d): %s",
+			migration.Processed, migration.Total, migration.Copied, migration.Skipped, migration.Failed, object.Path)
+	}
+
+	migration.Status = domain.StorageMigrationCompleted
+	if migration.Failed > 0 {
+		migration.Status = domain.StorageMigrationFailed
+	}
+	finishedAt := time.Now()
+	migration.FinishedAt = &finishedAt
+	su.update(migration.ID, map[string]interface{}{
+		"status":      migration.Status,
+		"finished_at": finishedAt,
+	})
+	pkg.Log.Printf("存储迁移任务 %s 结束: %s，复制 %d，跳过 %d，失败 %d", migration.ID, migration.Status, migration.Copied, migration.Skipped, migration.Failed)
+	return nil
+}
+
+// migrateObject 复制单个对象并校验大小与 SHA-256，目标中已存在且一致时跳过，返回是否发生了复制
+// verified 为 true 表示对象在上次执行中已经校验过，只比较大小
+func (su *storageMigrationUsecase) migrateObject(ctx context.Context, target domain.FileRepository, object domain.StorageObject, verified bool) (bool, error) {
+	expectedHash := object.SHA256
+	if stat, err := target.GetObjectStat(ctx, su.bucket, object.Path); err == nil && stat.Size == object.Size {
+		if verified {
+			return false, nil
+		}
+		if expectedHash == "" {
+			if expectedHash, _, err = hashObject(ctx, su.source, su.bucket, object.Path); err != nil {
+				return false, fmt.Errorf("读取源对象失败: %w", err)
+			}
+		}
+		if targetHash, _, err := hashObject(ctx, target, su.bucket, object.Path); err == nil && targetHash == expectedHash {
+			return false, nil
+		}
+	}
+
+	size := object.Size
+	if size <= 0 {
+		stat, err := su.source.GetObjectStat(ctx, su.bucket, object.Path)
+		if err != nil {
+			return false, fmt.Errorf("读取源对象失败: %w", err)
+		}
+		size = stat.Size
+	}
+
+	reader, err := su.source.Download(ctx, &domain.DownloadRequest{Bucket: su.bucket, ObjectName: object.Path})
+	if err != nil {
+		return false, fmt.Errorf("读取源对象失败: %w", err)
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	if _, err := target.Upload(ctx, &domain.UploadRequest{
+		Bucket:      su.bucket,
+		ObjectName:  object.Path,
+		Reader:      io.TeeReader(reader, hasher),
+		Size:        size,
+		ContentType: "application/octet-stream",
+	}); err != nil {
+		return false, fmt.Errorf("写入目标存储失败: %w", err)
+	}
+	sourceHash := hex.EncodeToString(hasher.Sum(nil))
+	if object.SHA256 != "" && sourceHash != object.SHA256 {
+		return true, fmt.Errorf("源对象摘要与记录不一致: %s != %s", sourceHash, object.SHA256)
+	}
+
+	// 回读目标对象校验
+	targetHash, targetSize, err := hashObject(ctx, target, su.bucket, object.Path)
+	if err != nil {
+		return true, fmt.Errorf("回读目标对象失败: %w", err)
+	}
+	if targetSize != size {
+		return true, fmt.Errorf("目标对象大小不一致: %d != %d", targetSize, size)
+	}
+	if targetHash != sourceHash {
+		return true, fmt.Errorf("目标对象摘要不一致: %s != %s", targetHash, sourceHash)
+	}
+	return true, nil
+}
+
+// hashObject 读取整个对象计算 SHA-256，同时返回读取的字节数
+func hashObject(ctx context.Context, storage domain.FileRepository, bucket, objectName string) (string, int64, error) {
+	reader, err := storage.Download(ctx, &domain.DownloadRequest{Bucket: bucket, ObjectName: objectName})
+	if err != nil {
+		return "", 0, err
+	}
+	defer reader.Close()
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, reader)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), n, nil
+}
+
+// update 更新任务进度，复制过程可能很长，不受调用方 context 取消的影响
+func (su *storageMigrationUsecase) update(id string, updates map[string]interface{}) {
+	c, cancel := context.WithTimeout(context.Background(), su.contextTimeout)
+	defer cancel()
+	if err := su.storageMigrationRepository.Update(c, id, updates); err != nil {
+		pkg.Log.Errorf("更新存储迁移进度失败 %s: %v", id, err)
+	}
+}
+
+func (su *storageMigrationUsecase) GetMigration(ctx context.Context, id string) (*domain.StorageMigration, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+	return su.storageMigrationRepository.GetByID(c, id)
+}
+
+func (su *storageMigrationUsecase) ListMigrations(ctx context.Context) ([]*domain.StorageMigration, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+	return su.storageMigrationRepository.List(c, 20)
+}
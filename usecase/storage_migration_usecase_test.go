@@ -0,0 +1,201 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"pkms/domain"
+	"pkms/repository"
+	"pkms/usecase"
+)
+
+const migrationBucket = "pkms"
+
+// memoryMigrationRepository 保存在内存中的迁移任务记录
+type memoryMigrationRepository struct {
+	migrations map[string]*domain.StorageMigration
+}
+
+func (r *memoryMigrationRepository) Create(_ context.Context, migration *domain.StorageMigration) error {
+	migration.ID = "m" + string(rune('0'+len(r.migrations)+1))
+	migration.Status = domain.StorageMigrationRunning
+	copied := *migration
+	r.migrations[migration.ID] = &copied
+	return nil
+}
+
+func (r *memoryMigrationRepository) GetByID(_ context.Context, id string) (*domain.StorageMigration, error) {
+	copied := *r.migrations[id]
+	return &copied, nil
+}
+
+func (r *memoryMigrationRepository) List(context.Context, int) ([]*domain.StorageMigration, error) {
+	return nil, nil
+}
+
+func (r *memoryMigrationRepository) Update(_ context.Context, id string, updates map[string]interface{}) error {
+	m := r.migrations[id]
+	for key, value := range updates {
+		switch key {
+		case "status":
+			m.Status = value.(string)
+		case "cursor":
+			m.Cursor = value.(string)
+		case "failures":
+			m.Failures = value.([]string)
+		case "error":
+			m.Error = value.(string)
+		}
+	}
+	return nil
+}
+
+func (r *memoryMigrationRepository) GetResumable(_ context.Context, source, target string) (*domain.StorageMigration, error) {
+	for _, m := range r.migrations {
+		if m.Source == source && m.Target == target && m.Status != domain.StorageMigrationCompleted {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryMigrationRepository) MarkRunningInterrupted(context.Context) error {
+	return nil
+}
+
+// objectListRepository 只实现 ListStorageObjects 的版本仓库
+type objectListRepository struct {
+	domain.ReleaseRepository
+	objects []domain.StorageObject
+}
+
+func (r *objectListRepository) ListStorageObjects(context.Context) ([]domain.StorageObject, error) {
+	return r.objects, nil
+}
+
+type migrationFixture struct {
+	source, target domain.FileRepository
+	migrations     *memoryMigrationRepository
+	releases       *objectListRepository
+	usecase        domain.StorageMigrationUsecase
+}
+
+func newMigrationFixture(t *testing.T) *migrationFixture {
+	t.Helper()
+	f := &migrationFixture{
+		source:     repository.NewDiskFileRepository(t.TempDir(), "secret"),
+		target:     repository.NewDiskFileRepository(t.TempDir(), "secret"),
+		migrations: &memoryMigrationRepository{migrations: make(map[string]*domain.StorageMigration)},
+		releases:   &objectListRepository{},
+	}
+	f.usecase = usecase.NewStorageMigrationUsecase(f.migrations, f.releases, f.source, domain.StorageTypeDisk, migrationBucket,
+		func(string) (domain.FileRepository, error) { return f.target, nil }, time.Minute)
+	return f
+}
+
+// put 写入对象并登记到版本记录中，hash 为空时使用内容的实际摘要
+func (f *migrationFixture) put(t *testing.T, storage domain.FileRepository, path, content, hash string) {
+	t.Helper()
+	if _, err := storage.Upload(context.Background(), &domain.UploadRequest{
+		Bucket:     migrationBucket,
+		ObjectName: path,
+		Reader:     strings.NewReader(content),
+		Size:       int64(len(content)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if storage != f.source {
+		return
+	}
+	if hash == "" {
+		sum := sha256.Sum256([]byte(content))
+		hash = hex.EncodeToString(sum[:])
+	}
+	f.releases.objects = append(f.releases.objects, domain.StorageObject{Path: path, Size: int64(len(content)), SHA256: hash})
+}
+
+func (f *migrationFixture) run(t *testing.T) *domain.StorageMigration {
+	t.Helper()
+	migration, err := f.usecase.Prepare(context.Background(), domain.StorageTypeMinio, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.usecase.Run(context.Background(), migration); err != nil {
+		t.Fatal(err)
+	}
+	return migration
+}
+
+func readObject(t *testing.T, storage domain.FileRepository, path string) string {
+	t.Helper()
+	reader, err := storage.Download(context.Background(), &domain.DownloadRequest{Bucket: migrationBucket, ObjectName: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestStorageMigrationResumesAfterCursor(t *testing.T) {
+	f := newMigrationFixture(t)
+	f.put(t, f.source, "p/a.bin", "aaa", "")
+	f.put(t, f.source, "p/b.bin", "bbb", "")
+	f.put(t, f.source, "p/c.bin", "ccc", "")
+
+	// 上次执行在 p/b.bin 之后中断：a、b 已复制到目标存储
+	f.put(t, f.target, "p/a.bin", "aaa", "")
+	f.put(t, f.target, "p/b.bin", "bbb", "")
+	interrupted := &domain.StorageMigration{Source: domain.StorageTypeDisk, Target: domain.StorageTypeMinio}
+	_ = f.migrations.Create(context.Background(), interrupted)
+	_ = f.migrations.Update(context.Background(), interrupted.ID, map[string]interface{}{
+		"status": domain.StorageMigrationInterrupted,
+		"cursor": "p/b.bin",
+	})
+
+	migration := f.run(t)
+	if migration.ID != interrupted.ID {
+		t.Fatalf("expected to resume %s, got %s", interrupted.ID, migration.ID)
+	}
+	if migration.Status != domain.StorageMigrationCompleted || migration.Copied != 1 || migration.Skipped != 2 || migration.Failed != 0 {
+		t.Fatalf("unexpected result: %+v", migration)
+	}
+	if got := readObject(t, f.target, "p/c.bin"); got != "ccc" {
+		t.Fatalf("p/c.bin = %q", got)
+	}
+}
+
+func TestStorageMigrationReportsHashMismatch(t *testing.T) {
+	f := newMigrationFixture(t)
+	f.put(t, f.source, "p/a.bin", "aaa", "")
+	f.put(t, f.source, "p/b.bin", "bbb", strings.Repeat("0", 64))
+
+	migration := f.run(t)
+	if migration.Status != domain.StorageMigrationFailed || migration.Copied != 1 || migration.Failed != 1 {
+		t.Fatalf("unexpected result: %+v", migration)
+	}
+	if len(migration.Failures) != 1 || !strings.HasPrefix(migration.Failures[0], "p/b.bin: ") {
+		t.Fatalf("unexpected failures: %v", migration.Failures)
+	}
+
+	// 修正记录后重新执行：失败过的对象即使在游标之前也要重新校验，已完成的对象跳过
+	sum := sha256.Sum256([]byte("bbb"))
+	f.releases.objects[1].SHA256 = hex.EncodeToString(sum[:])
+	retried := f.run(t)
+	if retried.ID != migration.ID {
+		t.Fatalf("expected to resume %s, got %s", migration.ID, retried.ID)
+	}
+	if retried.Status != domain.StorageMigrationCompleted || retried.Failed != 0 || retried.Skipped != 2 {
+		t.Fatalf("unexpected result after retry: %+v", retried)
+	}
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"pkms/domain"
+	"pkms/pkg"
+)
+
+// storageScanRunning 同一进程内同一时间只运行一个巡检
+var storageScanRunning atomic.Bool
+
+// maxStorageIssues 巡检记录中保留的问题明细数量上限，计数不受影响
+const maxStorageIssues = 1000
+
+type storageScanUsecase struct {
+	storageScanRepository domain.StorageScanRepository
+	releaseRepository     domain.ReleaseRepository
+	fileRepository        domain.FileRepository
+	bucket                string
+	orphanGracePeriod     time.Duration
+	contextTimeout        time.Duration
+}
+
+// NewStorageScanUsecase orphanGracePeriod 内创建的孤立对象不会被删除（可能是尚未写入数据库的上传）
+func NewStorageScanUsecase(storageScanRepository domain.StorageScanRepository, releaseRepository domain.ReleaseRepository, fileRepository domain.FileRepository, bucket string, orphanGracePeriod, timeout time.Duration) domain.StorageScanUsecase {
+	return &storageScanUsecase{
+		storageScanRepository: storageScanRepository,
+		releaseRepository:     releaseRepository,
+		fileRepository:        fileRepository,
+		bucket:                bucket,
+		orphanGracePeriod:     orphanGracePeriod,
+		contextTimeout:        timeout,
+	}
+}
+
+func (su *storageScanUsecase) StartScan(ctx context.Context, request *domain.StartStorageScanRequest, trigger, createdBy string) (*domain.StorageScan, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+
+	if storageScanRunning.Load() {
+		return nil, errors.New("已有存储巡检正在运行")
+	}
+
+	scan := &domain.StorageScan{
+		Trigger:       trigger,
+		DeleteOrphans: request.DeleteOrphans,
+		VerifyHashes:  request.VerifyHashes,
+		CreatedBy:     createdBy,
+	}
+	if err := su.storageScanRepository.Create(c, scan); err != nil {
+		return nil, err
+	}
+
+	// 巡检耗时与存储规模相关，在后台执行
+	go func(scan domain.StorageScan) {
+		_ = su.RunScan(context.Background(), &scan)
+	}(*scan)
+
+	return scan, nil
+}
+
+func (su *storageScanUsecase) RunScan(ctx context.Context, scan *domain.StorageScan) error {
+	if !storageScanRunning.CompareAndSwap(false, true) {
+		return errors.New("已有存储巡检正在运行")
+	}
+	defer storageScanRunning.Store(false)
+
+	scan.Status = domain.StorageScanCompleted
+	if err := su.scan(ctx, scan); err != nil {
+		pkg.Log.Errorf("存储巡检 %s 失败: %v", scan.ID, err)
+		scan.Status, scan.Error = domain.StorageScanFailed, err.Error()
+	}
+	finishedAt := time.Now()
+	scan.FinishedAt = &finishedAt
+
+	c, cancel := context.WithTimeout(context.Background(), su.contextTimeout)
+	defer cancel()
+	if err := su.storageScanRepository.Save(c, scan); err != nil {
+		pkg.Log.Errorf("保存存储巡检结果失败 %s: %v", scan.ID, err)
+		return err
+	}
+
+	pkg.Log.Printf("存储巡检 %s 结束: 对象 %d，引用 %d，孤立 %d（删除 %d），缺失 %d，损坏 %d",
+		scan.ID, scan.TotalObjects, scan.ReferencedObjects, scan.OrphanCount, scan.DeletedCount, scan.MissingCount, scan.CorruptCount)
+	if scan.Error != "" {
+		return errors.New(scan.Error)
+	}
+	return nil
+}
+
+func (su *storageScanUsecase) scan(ctx context.Context, scan *domain.StorageScan) error {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	references, err := su.releaseRepository.ListStorageObjects(c)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("获取引用的存储对象失败: %w", err)
+	}
+
+	files, err := su.fileRepository.List(ctx, su.bucket, "")
+	if err != nil {
+		return fmt.Errorf("列出存储对象失败: %w", err)
+	}
+
+	stored := make(map[string]domain.FileInfo, len(files))
+	for _, file := range files {
+		if !file.IsFolder {
+			stored[file.Key] = file
+		}
+	}
+	referenced := make(map[string]bool, len(references))
+	for _, ref := range references {
+		referenced[ref.Path] = true
+	}
+	scan.TotalObjects, scan.ReferencedObjects = len(stored), len(references)
+
+	// 孤立对象：超过宽限期的才删除；没有任何引用时多半是连错了数据库，不做删除
+	graceDeadline := time.Now().Add(-su.orphanGracePeriod)
+	for _, file := range files {
+		if file.IsFolder || referenced[file.Key] {
+			continue
+		}
+		lastModified := file.LastModified
+		issue := domain.StorageIssue{
+			Type:         domain.StorageIssueOrphan,
+			Path:         file.Key,
+			Size:         file.Size,
+			LastModified: &lastModified,
+		}
+		if scan.DeleteOrphans && len(references) > 0 && file.LastModified.Before(graceDeadline) {
+			if deleted, err := su.deleteOrphan(ctx, file.Key, graceDeadline); err != nil {
+				pkg.Log.Errorf("删除孤立对象失败 %s: %v", file.Key, err)
+			} else if deleted {
+				issue.Deleted = true
+				scan.DeletedCount++
+			}
+		}
+		scan.OrphanCount++
+		scan.OrphanBytes += file.Size
+		su.addIssue(scan, issue)
+	}
+
+	// 完整性：被引用的对象必须存在，大小（以及可选的 SHA-256）与记录一致
+	integrity := make(map[string]string)
+	flag := func(ref domain.StorageObject, status string) {
+		// 补丁问题只报告，不影响版本状态；缺失优先于损坏
+		if ref.PatchID != "" || ref.ReleaseID == "" || integrity[ref.ReleaseID] == domain.IntegrityStatusMissing {
+			return
+		}
+		integrity[ref.ReleaseID] = status
+	}
+	for _, ref := range references {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		issue := domain.StorageIssue{
+			Path:      ref.Path,
+			Size:      ref.Size,
+			ReleaseID: ref.ReleaseID,
+			AssetID:   ref.AssetID,
+			PatchID:   ref.PatchID,
+		}
+		file, exists := stored[ref.Path]
+		switch {
+		case !exists:
+			issue.Type = domain.StorageIssueMissing
+			scan.MissingCount++
+			flag(ref, domain.IntegrityStatusMissing)
+		case ref.Size > 0 && file.Size != ref.Size:
+			issue.Type = domain.StorageIssueSizeMismatch
+			issue.Expected, issue.Actual = fmt.Sprint(ref.Size), fmt.Sprint(file.Size)
+			scan.CorruptCount++
+			flag(ref, domain.IntegrityStatusCorrupt)
+		case scan.VerifyHashes && ref.SHA256 != "":
+			actual, _, err := hashObject(ctx, su.fileRepository, su.bucket, ref.Path)
+			if err != nil {
+				pkg.Log.Errorf("读取对象失败 %s: %v", ref.Path, err)
+				continue
+			}
+			if actual == ref.SHA256 {
+				continue
+			}
+			issue.Type = domain.StorageIssueHashMismatch
+			issue.Expected, issue.Actual = ref.SHA256, actual
+			scan.CorruptCount++
+			flag(ref, domain.IntegrityStatusCorrupt)
+		default:
+			continue
+		}
+		su.addIssue(scan, issue)
+	}
+
+	c, cancel = context.WithTimeout(context.WithoutCancel(ctx), su.contextTimeout)
+	defer cancel()
+	if err := su.releaseRepository.UpdateIntegrity(c, integrity, time.Now()); err != nil {
+		return fmt.Errorf("更新版本完整性状态失败: %w", err)
+	}
+	return nil
+}
+
+// deleteOrphan 删除孤立对象，返回对象是否真正被删除
+// 内容寻址对象经过去重存储删除时只会减少引用计数，没有引用的对象需要绕过引用计数回收
+func (su *storageScanUsecase) deleteOrphan(ctx context.Context, objectName string, graceDeadline time.Time) (bool, error) {
+	if _, ok := domain.BlobHashFromPath(objectName); ok {
+		if collector, ok := su.fileRepository.(domain.BlobCollector); ok {
+			return collector.CollectBlob(ctx, su.bucket, objectName, graceDeadline)
+		}
+	}
+	if err := su.fileRepository.Delete(ctx, su.bucket, objectName); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (su *storageScanUsecase) addIssue(scan *domain.StorageScan, issue domain.StorageIssue) {
+	if len(scan.Issues) < maxStorageIssues {
+		scan.Issues = append(scan.Issues, issue)
+	}
+}
+
+func (su *storageScanUsecase) GetScan(ctx context.Context, id string) (*domain.StorageScan, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+	return su.storageScanRepository.GetByID(c, id)
+}
+
+func (su *storageScanUsecase) ListScans(ctx context.Context) ([]*domain.StorageScan, error) {
+	c, cancel := context.WithTimeout(ctx, su.contextTimeout)
+	defer cancel()
+	return su.storageScanRepository.List(c, 20)
+}
+
+// StartStorageScanScheduler 按固定间隔执行巡检，上一次巡检未结束时跳过本次
+func StartStorageScanScheduler(storageScanUsecase domain.StorageScanUsecase, interval time.Duration, request domain.StartStorageScanRequest) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := storageScanUsecase.StartScan(context.Background(), &request, domain.StorageScanTriggerScheduled, "system"); err != nil {
+				pkg.Log.Warnf("定时存储巡检未执行: %v", err)
+			}
+		}
+	}()
+}
@@ -27,6 +27,10 @@ func (tu *tenantUsecase) Create(c context.Context, tenant *domain.Tenant) error
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
 
+	if tenant.Timezone != "" && !domain.IsValidTimezone(tenant.Timezone) {
+		return fmt.Errorf("无效的时区: %s", tenant.Timezone)
+	}
+
 	// 首先创建租户
 	err := tu.tenantRepository.Create(ctx, tenant)
 	if err != nil {
@@ -56,6 +60,10 @@ func (tu *tenantUsecase) GetByID(c context.Context, id string) (*domain.Tenant,
 func (tu *tenantUsecase) Update(c context.Context, tenant *domain.Tenant) error {
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
+
+	if tenant.Timezone != "" && !domain.IsValidTimezone(tenant.Timezone) {
+		return fmt.Errorf("无效的时区: %s", tenant.Timezone)
+	}
 	return tu.tenantRepository.Update(ctx, tenant)
 }
 
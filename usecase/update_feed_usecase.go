@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"pkms/domain"
+	"pkms/internal/versioning"
+	"pkms/pkg"
+
+	"gopkg.in/yaml.v3"
+)
+
+// electron-updater 各平台使用的安装文件类型
+var electronExtensions = map[string][]string{
+	"windows": {".exe"},
+	"darwin":  {".zip", ".dmg"},
+	"linux":   {".appimage", ".deb", ".rpm"},
+}
+
+type updateFeedUsecase struct {
+	upgradeRepository domain.UpgradeRepository
+	packageRepository domain.PackageRepository
+	releaseRepository domain.ReleaseRepository
+	fileRepository    domain.FileRepository
+	bucket            string
+	contextTimeout    time.Duration
+	// Squirrel.Windows 使用 SHA-1 校验 .nupkg，版本文件不会被修改，按文件路径缓存计算结果
+	sha1Sums sync.Map
+}
+
+func NewUpdateFeedUsecase(upgradeRepository domain.UpgradeRepository, packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, fileRepository domain.FileRepository, bucket string, timeout time.Duration) domain.UpdateFeedUsecase {
+	return &updateFeedUsecase{
+		upgradeRepository: upgradeRepository,
+		packageRepository: packageRepository,
+		releaseRepository: releaseRepository,
+		fileRepository:    fileRepository,
+		bucket:            bucket,
+		contextTimeout:    timeout,
+	}
+}
+
+// electronUpdateInfo electron-builder 生成的 latest.yml 格式
+type electronUpdateInfo struct {
+	Version           string             `yaml:"version"`
+	Files             []electronFileInfo `yaml:"files"`
+	Path              string             `yaml:"path"`
+	SHA512            string             `yaml:"sha512"`
+	ReleaseDate       string             `yaml:"releaseDate"`
+	ReleaseNotes      string             `yaml:"releaseNotes,omitempty"`
+	StagingPercentage *int               `yaml:"stagingPercentage,omitempty"`
+}
+
+type electronFileInfo struct {
+	URL    string `yaml:"url"`
+	SHA512 string `yaml:"sha512"`
+	Size   int64  `yaml:"size"`
+}
+
+func (fu *updateFeedUsecase) ElectronFeed(ctx context.Context, access *domain.ClientAccess, name string) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, fu.contextTimeout)
+	defer cancel()
+
+	channel, osName, arch, ok := parseElectronFeedName(name)
+	if !ok {
+		return nil, domain.ErrUpdateFeedNotFound
+	}
+	target, release, err := fu.activeRelease(c, access, channel, osName)
+	if err != nil {
+		return nil, err
+	}
+
+	version, ok := electronVersion(target, release)
+	if !ok {
+		return nil, fmt.Errorf("%w: 版本号 %s 不是语义化版本", domain.ErrUpdateFeedNotFound, target.Version)
+	}
+	info := &electronUpdateInfo{
+		Version:      version,
+		ReleaseDate:  release.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		ReleaseNotes: target.Description,
+	}
+	if info.ReleaseNotes == "" {
+		info.ReleaseNotes = release.ChangeLog
+	}
+	if release.PublishedAt != nil {
+		info.ReleaseDate = release.PublishedAt.UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	for _, file := range feedFiles(release) {
+		if !isElectronFile(file, osName, arch) {
+			continue
+		}
+		sum, err := hex.DecodeString(file.SHA512)
+		if err != nil {
+			continue
+		}
+		info.Files = append(info.Files, electronFileInfo{
+			URL:    release.ID + "/" + url.PathEscape(file.FileName),
+			SHA512: base64.StdEncoding.EncodeToString(sum),
+			Size:   file.FileSize,
+		})
+	}
+	if len(info.Files) == 0 {
+		return nil, domain.ErrUpdateFeedNotFound
+	}
+	// 兼容旧版 electron-updater 读取的顶层字段
+	info.Path, info.SHA512 = info.Files[0].URL, info.Files[0].SHA512
+	// 灰度：electron-updater 按客户端本地生成的随机标识决定是否更新
+	if percentage := target.EffectiveRolloutPercentage(time.Now()); percentage < 100 {
+		info.StagingPercentage = &percentage
+	}
+	return yaml.Marshal(info)
+}
+
+func (fu *updateFeedUsecase) GetElectronFile(ctx context.Context, access *domain.ClientAccess, releaseID, fileName string) (*domain.ReleaseAsset, error) {
+	c, cancel := context.WithTimeout(ctx, fu.contextTimeout)
+	defer cancel()
+
+	release, err := fu.releaseRepository.GetByID(c, releaseID)
+	if err != nil || release.IsDraft || release.PackageID != access.PackageID {
+		return nil, domain.ErrUpdateFeedNotFound
+	}
+	for _, file := range feedFiles(release) {
+		if file.FileName == fileName {
+			return file, nil
+		}
+	}
+	return nil, domain.ErrUpdateFeedNotFound
+}
+
+func (fu *updateFeedUsecase) SquirrelReleases(ctx context.Context, access *domain.ClientAccess) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, fu.contextTimeout)
+	defer cancel()
+
+	target, release, err := fu.activeRelease(c, access, "", "windows")
+	if err != nil {
+		return nil, err
+	}
+	// Squirrel.Windows 不支持灰度，灰度期间不提供更新，全量后所有客户端再更新
+	if target.EffectiveRolloutPercentage(time.Now()) < 100 {
+		return nil, domain.ErrUpdateFeedNotFound
+	}
+
+	var b strings.Builder
+	for _, file := range feedFiles(release) {
+		if !isSquirrelFile(file) {
+			continue
+		}
+		sum, err := fu.sha1Sum(c, file)
+		if err != nil {
+			return nil, fmt.Errorf("计算 %s 的 SHA-1 失败: %w", file.FileName, err)
+		}
+		fmt.Fprintf(&b, "%s %s %d\n", sum, file.FileName, file.FileSize)
+	}
+	if b.Len() == 0 {
+		return nil, domain.ErrUpdateFeedNotFound
+	}
+	return []byte(b.String()), nil
+}
+
+func (fu *updateFeedUsecase) GetSquirrelFile(ctx context.Context, access *domain.ClientAccess, fileName string) (*domain.ReleaseAsset, error) {
+	c, cancel := context.WithTimeout(ctx, fu.contextTimeout)
+	defer cancel()
+
+	// 在包的所有已发布版本中查找，升级目标切换时正在进行的下载不受影响
+	releases, err := fu.releaseRepository.GetByPackageID(c, access.PackageID)
+	if err != nil {
+		return nil, err
+	}
+	for _, release := range releases {
+		if release.IsDraft {
+			continue
+		}
+		for _, file := range feedFiles(release) {
+			if file.FileName == fileName && isSquirrelFile(file) {
+				return file, nil
+			}
+		}
+	}
+	return nil, domain.ErrUpdateFeedNotFound
+}
+
+// activeRelease 查找 desktop 包在渠道中当前生效的升级目标及其版本
+// 渠道为空（或 electron 默认的 latest）时使用凭证绑定的渠道，未绑定时为 stable
+func (fu *updateFeedUsecase) activeRelease(c context.Context, access *domain.ClientAccess, channel, osName string) (*domain.UpgradeTarget, *domain.Release, error) {
+	p, err := fu.packageRepository.GetByID(c, access.PackageID)
+	if err != nil || p.Type != domain.PackageTypeDesktop {
+		return nil, nil, domain.ErrUpdateFeedNotFound
+	}
+
+	if channel == domain.ElectronDefaultChannel {
+		channel = ""
+	}
+	if access.Channel != "" {
+		if channel != "" && channel != access.Channel {
+			return nil, nil, fmt.Errorf("%w: 客户端接入凭证已绑定 %s 渠道", domain.ErrUpdateFeedNotFound, access.Channel)
+		}
+		channel = access.Channel
+	}
+	channel = domain.NormalizeChannel(channel)
+
+	target, err := fu.upgradeRepository.GetActiveUpgradeTargetByPackageID(c, access.PackageID, channel, &domain.TargetingAttributes{OS: osName})
+	if err != nil {
+		return nil, nil, domain.ErrUpdateFeedNotFound
+	}
+	// 不在生效时间或维护窗口内时不提供更新
+	now := time.Now()
+	if next := target.NextAvailableAt(now); next == nil || next.After(now) {
+		return nil, nil, domain.ErrUpdateFeedNotFound
+	}
+
+	release, err := fu.releaseRepository.GetByID(c, target.ReleaseID)
+	if err != nil || release.IsDraft {
+		return nil, nil, domain.ErrUpdateFeedNotFound
+	}
+	return target, release, nil
+}
+
+// sha1Sum 读取文件计算 SHA-1（大写十六进制，与 Squirrel 生成的 RELEASES 一致）
+func (fu *updateFeedUsecase) sha1Sum(c context.Context, file *domain.ReleaseAsset) (string, error) {
+	if sum, ok := fu.sha1Sums.Load(file.FilePath); ok {
+		return sum.(string), nil
+	}
+
+	reader, err := fu.fileRepository.Download(c, &domain.DownloadRequest{Bucket: fu.bucket, ObjectName: file.FilePath})
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", err
+	}
+	sum := strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+	fu.sha1Sums.Store(file.FilePath, sum)
+	return sum, nil
+}
+
+// electronVersion electron-updater 要求完整的语义化版本号（x.y.z），整数版本号（如 42）时改用版本的 tag 或名称
+func electronVersion(target *domain.UpgradeTarget, release *domain.Release) (string, bool) {
+	for _, v := range []string{target.Version, release.VersionCode, release.TagName, release.VersionName} {
+		semver, err := versioning.ParseSemVer(v)
+		if err != nil {
+			continue
+		}
+		// ParseSemVer 会补全缺省的 minor/patch，只接受本身就是完整形式的版本号
+		if version := semver.String(); version == strings.TrimLeft(strings.TrimSpace(v), "vV") {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// parseElectronFeedName 解析 electron-updater 请求的文件名：{channel}.yml（Windows）、{channel}-mac.yml、{channel}-linux.yml（x64）或 {channel}-linux-{arch}.yml
+func parseElectronFeedName(name string) (channel, osName, arch string, ok bool) {
+	base, found := strings.CutSuffix(name, ".yml")
+	if !found || base == "" {
+		return "", "", "", false
+	}
+	if channel, found := strings.CutSuffix(base, "-mac"); found {
+		return channel, "darwin", "", channel != ""
+	}
+	if i := strings.LastIndex(base, "-linux"); i > 0 {
+		switch suffix := base[i+len("-linux"):]; {
+		case suffix == "":
+			return base[:i], "linux", "amd64", true
+		case strings.HasPrefix(suffix, "-") && !strings.Contains(suffix[1:], "-"):
+			return base[:i], "linux", pkg.NormalizeArch(suffix[1:]), true
+		}
+	}
+	return base, "windows", "", true
+}
+
+// feedFiles 版本主文件和构件，主文件以不限平台的构件表示
+func feedFiles(release *domain.Release) []*domain.ReleaseAsset {
+	files := make([]*domain.ReleaseAsset, 0, len(release.Assets)+1)
+	if release.FilePath != "" {
+		files = append(files, &domain.ReleaseAsset{
+			ReleaseID: release.ID,
+			Kind:      pkg.GetArtifactKind(release.FileName),
+			FilePath:  release.FilePath,
+			FileName:  release.FileName,
+			FileSize:  release.FileSize,
+			FileHash:  release.FileHash,
+			SHA256:    release.SHA256,
+			SHA512:    release.SHA512,
+			CreatedAt: release.CreatedAt,
+		})
+	}
+	seen := map[string]bool{release.FilePath + "/" + release.FileName: true}
+	for _, asset := range release.Assets {
+		if key := asset.FilePath + "/" + asset.FileName; !seen[key] {
+			seen[key] = true
+			files = append(files, asset)
+		}
+	}
+	return files
+}
+
+// isElectronFile 构件的扩展名属于该平台且未标记为其他平台；linux 还需架构匹配
+func isElectronFile(file *domain.ReleaseAsset, osName, arch string) bool {
+	if file.Kind == "checksum" || file.SHA512 == "" {
+		return false
+	}
+	if file.OS != "" && pkg.NormalizeOS(file.OS) != osName {
+		return false
+	}
+	if arch != "" && file.Arch != "" {
+		if fileArch := pkg.NormalizeArch(file.Arch); fileArch != arch && !pkg.IsUniversalArch(fileArch) {
+			return false
+		}
+	}
+	ext := strings.ToLower(path.Ext(file.FileName))
+	for _, candidate := range electronExtensions[osName] {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func isSquirrelFile(file *domain.ReleaseAsset) bool {
+	if file.OS != "" && pkg.NormalizeOS(file.OS) != "windows" {
+		return false
+	}
+	return strings.EqualFold(path.Ext(file.FileName), ".nupkg")
+}
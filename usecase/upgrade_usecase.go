@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"pkms/domain"
+	"pkms/internal/versioning"
+	"pkms/pkg"
 )
 
 type upgradeUsecase struct {
@@ -15,12 +20,24 @@ type upgradeUsecase struct {
 	packageRepository      domain.PackageRepository
 	releaseRepository      domain.ReleaseRepository
 	clientAccessRepository domain.ClientAccessRepository
+	patchUsecase           domain.PatchUsecase
+	reportRepository       domain.UpgradeReportRepository
+	deviceRepository       domain.DeviceRepository
+	signingUsecase         domain.SigningUsecase
+	failureThreshold       float64
+	failureMinReports      int
 	contextTimeout         time.Duration
 }
 
 // UpgradeUsecaseConfig 升级用例配置选项
 type UpgradeUsecaseConfig struct {
 	ClientAccessRepository domain.ClientAccessRepository
+	PatchUsecase           domain.PatchUsecase
+	ReportRepository       domain.UpgradeReportRepository
+	DeviceRepository       domain.DeviceRepository
+	SigningUsecase         domain.SigningUsecase
+	FailureThreshold       float64
+	FailureMinReports      int
 }
 
 // UpgradeUsecaseOption 升级用例配置选项函数类型
@@ -33,6 +50,42 @@ func WithClientAccessRepository(repo domain.ClientAccessRepository) UpgradeUseca
 	}
 }
 
+// WithPatchUsecase 配置差分补丁用例，创建升级目标后在后台生成补丁，检查更新时下发补丁信息
+func WithPatchUsecase(patchUsecase domain.PatchUsecase) UpgradeUsecaseOption {
+	return func(config *UpgradeUsecaseConfig) {
+		config.PatchUsecase = patchUsecase
+	}
+}
+
+// WithUpgradeReportRepository 配置升级结果上报仓库
+func WithUpgradeReportRepository(repo domain.UpgradeReportRepository) UpgradeUsecaseOption {
+	return func(config *UpgradeUsecaseConfig) {
+		config.ReportRepository = repo
+	}
+}
+
+// WithDeviceRepository 配置设备登记仓库，检查更新时写入设备记录
+func WithDeviceRepository(repo domain.DeviceRepository) UpgradeUsecaseOption {
+	return func(config *UpgradeUsecaseConfig) {
+		config.DeviceRepository = repo
+	}
+}
+
+// WithSigningUsecase 配置签名用例，检查更新的响应使用租户密钥签名
+func WithSigningUsecase(signingUsecase domain.SigningUsecase) UpgradeUsecaseOption {
+	return func(config *UpgradeUsecaseConfig) {
+		config.SigningUsecase = signingUsecase
+	}
+}
+
+// WithAutoPause 配置自动暂停：上报数达到 minReports 且失败率超过 threshold 时暂停升级目标
+func WithAutoPause(threshold float64, minReports int) UpgradeUsecaseOption {
+	return func(config *UpgradeUsecaseConfig) {
+		config.FailureThreshold = threshold
+		config.FailureMinReports = minReports
+	}
+}
+
 // NewUpgradeUsecase 创建升级用例（支持可选配置）
 func NewUpgradeUsecase(
 	upgradeRepository domain.UpgradeRepository,
@@ -54,6 +107,12 @@ func NewUpgradeUsecase(
 		packageRepository:      packageRepository,
 		releaseRepository:      releaseRepository,
 		clientAccessRepository: config.ClientAccessRepository,
+		patchUsecase:           config.PatchUsecase,
+		reportRepository:       config.ReportRepository,
+		deviceRepository:       config.DeviceRepository,
+		signingUsecase:         config.SigningUsecase,
+		failureThreshold:       config.FailureThreshold,
+		failureMinReports:      config.FailureMinReports,
 		contextTimeout:         timeout,
 	}
 }
@@ -74,16 +133,52 @@ func (u *upgradeUsecase) CreateUpgradeTarget(ctx context.Context, request *domai
 		return nil, fmt.Errorf("软件包不存在: %w", err)
 	}
 
-	// 验证版本是否存在
-	_, err = u.releaseRepository.GetByID(c, request.ReleaseID)
+	// 验证版本是否存在，草稿版本发布前不能下发
+	release, err := u.releaseRepository.GetByID(c, request.ReleaseID)
 	if err != nil {
 		return nil, fmt.Errorf("版本不存在: %w", err)
 	}
+	if release.IsDraft {
+		return nil, errors.New("草稿版本不能设置为升级目标，请先发布")
+	}
+
+	// 同一渠道内可以有多个激活的升级目标，但定向规则相同的目标生效时间段不能重叠
+	channel := domain.NormalizeChannel(request.Channel)
+	if !domain.IsValidChannel(channel) {
+		return nil, fmt.Errorf("无效的渠道名称: %s", request.Channel)
+	}
+	rules, err := normalizeTargetingRules(request.TargetingRules)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateSchedule(request.StartsAt, request.EndsAt, request.MaintenanceWindows); err != nil {
+		return nil, err
+	}
+	existing, err := u.findConflictingTarget(c, request.PackageID, channel, &domain.UpgradeTarget{
+		TargetingRules: rules,
+		StartsAt:       request.StartsAt,
+		EndsAt:         request.EndsAt,
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("该软件包的 %s 渠道已有定向规则相同且生效时间重叠的激活升级目标，请先禁用现有目标", channel)
+	}
 
-	// 检查是否已有该包的激活升级目标
-	existing, err := u.upgradeRepository.GetActiveUpgradeTargetByPackageID(c, request.PackageID)
-	if err == nil && existing != nil {
-		return nil, errors.New("该软件包已有激活的升级目标，请先禁用现有目标")
+	// 灰度参数：未指定比例时，有放量计划则从 0 开始，否则直接全量
+	if err := validateRollout(request.RolloutPercentage, request.RolloutSchedule); err != nil {
+		return nil, err
+	}
+	rolloutPercentage := 100
+	if request.RolloutPercentage != nil {
+		rolloutPercentage = *request.RolloutPercentage
+	} else if len(request.RolloutSchedule) > 0 {
+		rolloutPercentage = 0
+	}
+	rolloutStatus := domain.RolloutStatusCompleted
+	if rolloutPercentage < 100 {
+		rolloutStatus = domain.RolloutStatusInProgress
 	}
 
 	// 创建升级目标
@@ -92,18 +187,31 @@ func (u *upgradeUsecase) CreateUpgradeTarget(ctx context.Context, request *domai
 		ProjectID:   request.ProjectID,
 		PackageID:   request.PackageID,
 		ReleaseID:   request.ReleaseID,
+		Channel:     channel,
 		Name:        request.Name,
 		Description: request.Description,
 		IsActive:    true,
+		IsRollback:  request.IsRollback,
+		IsMandatory: request.IsMandatory,
 		CreatedBy:   userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		MinSupportedVersion: strings.TrimSpace(request.MinSupportedVersion),
+		RolloutPercentage:   rolloutPercentage,
+		RolloutSchedule:     sortRolloutSchedule(request.RolloutSchedule),
+		RolloutStatus:       rolloutStatus,
+		TargetingRules:      rules,
+		StartsAt:            request.StartsAt,
+		EndsAt:              request.EndsAt,
+		MaintenanceWindows:  request.MaintenanceWindows,
 	}
 
 	err = u.upgradeRepository.CreateUpgradeTarget(c, upgradeTarget)
 	if err != nil {
 		return nil, fmt.Errorf("创建升级目标失败: %w", err)
 	}
+	u.schedulePatchGeneration(upgradeTarget.ReleaseID)
 
 	// 返回完整信息
 	return u.upgradeRepository.GetUpgradeTargetByID(c, upgradeTarget.ID)
@@ -135,13 +243,75 @@ func (u *upgradeUsecase) UpdateUpgradeTarget(ctx context.Context, id string, req
 	defer cancel()
 
 	// 检查升级目标是否存在
-	_, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
+	target, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
 	if err != nil {
 		return fmt.Errorf("升级目标不存在: %w", err)
 	}
 
+	if err := validateRollout(request.RolloutPercentage, request.RolloutSchedule); err != nil {
+		return err
+	}
+	// 放量计划只在灰度进行中生效，已全量或已暂停的目标修改计划不会重新开始放量
+	if len(request.RolloutSchedule) > 0 {
+		switch {
+		case target.RolloutStatus == domain.RolloutStatusPaused:
+			return errors.New("灰度已暂停，请先恢复灰度再修改放量计划")
+		case target.RolloutStatus == domain.RolloutStatusCompleted && (request.RolloutPercentage == nil || *request.RolloutPercentage >= 100):
+			return errors.New("灰度已全量完成，修改放量计划时需同时将灰度比例调整到 100 以下")
+		}
+	}
+
+	// 修改后的定向规则与生效时间段，零值时间表示清除
+	updated := &domain.UpgradeTarget{
+		TargetingRules: target.TargetingRules,
+		StartsAt:       target.StartsAt,
+		EndsAt:         target.EndsAt,
+	}
+	if request.TargetingRules != nil {
+		if updated.TargetingRules, err = normalizeTargetingRules(request.TargetingRules); err != nil {
+			return err
+		}
+	}
+	if request.StartsAt != nil {
+		updated.StartsAt = clearableTime(request.StartsAt)
+	}
+	if request.EndsAt != nil {
+		updated.EndsAt = clearableTime(request.EndsAt)
+	}
+	if err := domain.ValidateSchedule(updated.StartsAt, updated.EndsAt, request.MaintenanceWindows); err != nil {
+		return err
+	}
+
+	// 修改定向规则、生效时间或重新激活时，不能与同渠道规则相同的激活目标时间重叠
+	willBeActive := target.IsActive
+	if request.IsActive != nil {
+		willBeActive = *request.IsActive
+	}
+	changed := request.TargetingRules != nil || request.StartsAt != nil || request.EndsAt != nil
+	if willBeActive && (changed || !target.IsActive) {
+		conflict, err := u.findConflictingTarget(c, target.PackageID, target.Channel, updated, target.ID)
+		if err != nil {
+			return err
+		}
+		if conflict != nil {
+			return fmt.Errorf("该软件包的 %s 渠道已有定向规则相同且生效时间重叠的激活升级目标: %s", target.Channel, conflict.Name)
+		}
+	}
+
 	// 构建更新映射
 	updates := make(map[string]interface{})
+	if request.TargetingRules != nil {
+		updates["targeting_rules"] = updated.TargetingRules
+	}
+	if request.StartsAt != nil {
+		updates["starts_at"] = updated.StartsAt
+	}
+	if request.EndsAt != nil {
+		updates["ends_at"] = updated.EndsAt
+	}
+	if request.MaintenanceWindows != nil {
+		updates["maintenance_windows"] = request.MaintenanceWindows
+	}
 	if request.Name != "" {
 		updates["name"] = request.Name
 	}
@@ -151,10 +321,241 @@ func (u *upgradeUsecase) UpdateUpgradeTarget(ctx context.Context, id string, req
 	if request.IsActive != nil {
 		updates["is_active"] = *request.IsActive
 	}
+	if request.IsRollback != nil {
+		updates["is_rollback"] = *request.IsRollback
+	}
+	if request.IsMandatory != nil {
+		updates["is_mandatory"] = *request.IsMandatory
+	}
+	if request.MinSupportedVersion != nil {
+		updates["min_supported_version"] = strings.TrimSpace(*request.MinSupportedVersion)
+	}
+	if request.RolloutSchedule != nil {
+		updates["rollout_schedule"] = sortRolloutSchedule(request.RolloutSchedule)
+	}
+	if request.RolloutPercentage != nil {
+		updates["rollout_percentage"] = *request.RolloutPercentage
+		// 已暂停的灰度保持暂停，需显式恢复
+		if target.RolloutStatus != domain.RolloutStatusPaused {
+			if *request.RolloutPercentage >= 100 {
+				updates["rollout_status"] = domain.RolloutStatusCompleted
+			} else {
+				updates["rollout_status"] = domain.RolloutStatusInProgress
+			}
+		}
+	}
 
 	return u.upgradeRepository.UpdateUpgradeTarget(c, id, updates)
 }
 
+func (u *upgradeUsecase) PauseRollout(ctx context.Context, id string) (*domain.UpgradeTarget, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	target, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
+	if err != nil {
+		return nil, fmt.Errorf("升级目标不存在: %w", err)
+	}
+	if target.RolloutStatus == domain.RolloutStatusPaused {
+		return nil, errors.New("灰度发布已处于暂停状态")
+	}
+
+	if err := u.upgradeRepository.UpdateUpgradeTarget(c, id, map[string]interface{}{
+		"rollout_status": domain.RolloutStatusPaused,
+		"pause_reason":   "manual",
+	}); err != nil {
+		return nil, fmt.Errorf("暂停灰度发布失败: %w", err)
+	}
+	return u.upgradeRepository.GetUpgradeTargetByID(c, id)
+}
+
+func (u *upgradeUsecase) ResumeRollout(ctx context.Context, id string) (*domain.UpgradeTarget, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	target, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
+	if err != nil {
+		return nil, fmt.Errorf("升级目标不存在: %w", err)
+	}
+	if target.RolloutStatus != domain.RolloutStatusPaused {
+		return nil, errors.New("灰度发布未处于暂停状态")
+	}
+
+	status := domain.RolloutStatusInProgress
+	if target.RolloutPercentage >= 100 {
+		status = domain.RolloutStatusCompleted
+	}
+	if err := u.upgradeRepository.UpdateUpgradeTarget(c, id, map[string]interface{}{
+		"rollout_status": status,
+		"pause_reason":   "",
+	}); err != nil {
+		return nil, fmt.Errorf("恢复灰度发布失败: %w", err)
+	}
+	return u.upgradeRepository.GetUpgradeTargetByID(c, id)
+}
+
+func (u *upgradeUsecase) CompleteRollout(ctx context.Context, id string) (*domain.UpgradeTarget, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if _, err := u.upgradeRepository.GetUpgradeTargetByID(c, id); err != nil {
+		return nil, fmt.Errorf("升级目标不存在: %w", err)
+	}
+
+	if err := u.upgradeRepository.UpdateUpgradeTarget(c, id, map[string]interface{}{
+		"rollout_percentage": 100,
+		"rollout_status":     domain.RolloutStatusCompleted,
+		"pause_reason":       "",
+	}); err != nil {
+		return nil, fmt.Errorf("全量发布失败: %w", err)
+	}
+	return u.upgradeRepository.GetUpgradeTargetByID(c, id)
+}
+
+func (u *upgradeUsecase) PromoteUpgradeTarget(ctx context.Context, id string, request *domain.PromoteUpgradeTargetRequest, userID string) (*domain.UpgradeTarget, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	source, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
+	if err != nil {
+		return nil, fmt.Errorf("升级目标不存在: %w", err)
+	}
+	channel := domain.NormalizeChannel(request.Channel)
+	if !domain.IsValidChannel(channel) {
+		return nil, fmt.Errorf("无效的渠道名称: %s", request.Channel)
+	}
+	if channel == source.Channel {
+		return nil, errors.New("目标渠道与当前渠道相同")
+	}
+	release, err := u.releaseRepository.GetByID(c, source.ReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("版本不存在: %w", err)
+	}
+	if release.IsDraft {
+		return nil, errors.New("草稿版本不能晋级，请先发布")
+	}
+
+	now := time.Now()
+
+	// 目标渠道已有定向规则相同的激活目标时，直接将其切换到新版本；原目标的灰度进度属于旧版本，与新建时一样直接全量
+	existing, err := u.findConflictingTarget(c, source.PackageID, channel, &domain.UpgradeTarget{
+		TargetingRules: source.TargetingRules,
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := u.upgradeRepository.UpdateUpgradeTarget(c, existing.ID, map[string]interface{}{
+			"release_id":         source.ReleaseID,
+			"is_rollback":        false,
+			"promoted_by":        userID,
+			"promoted_at":        now,
+			"promoted_from":      source.Channel,
+			"rollout_percentage": 100,
+			"rollout_schedule":   []domain.RolloutStep{},
+			"rollout_status":     domain.RolloutStatusCompleted,
+			"pause_reason":       "",
+		}); err != nil {
+			return nil, fmt.Errorf("渠道晋级失败: %w", err)
+		}
+		u.schedulePatchGeneration(source.ReleaseID)
+		return u.upgradeRepository.GetUpgradeTargetByID(c, existing.ID)
+	}
+
+	// 否则在目标渠道创建新的升级目标
+	promoted := &domain.UpgradeTarget{
+		TenantID:          source.TenantID,
+		ProjectID:         source.ProjectID,
+		PackageID:         source.PackageID,
+		ReleaseID:         source.ReleaseID,
+		Channel:           channel,
+		Name:              source.Name,
+		Description:       source.Description,
+		IsActive:          true,
+		CreatedBy:         userID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		RolloutPercentage: 100,
+		RolloutStatus:     domain.RolloutStatusCompleted,
+		TargetingRules:    source.TargetingRules,
+		PromotedBy:        userID,
+		PromotedAt:        &now,
+		PromotedFrom:      source.Channel,
+	}
+	if err := u.upgradeRepository.CreateUpgradeTarget(c, promoted); err != nil {
+		return nil, fmt.Errorf("渠道晋级失败: %w", err)
+	}
+	u.schedulePatchGeneration(promoted.ReleaseID)
+	return u.upgradeRepository.GetUpgradeTargetByID(c, promoted.ID)
+}
+
+// findConflictingTarget 查找渠道内与 candidate 定向规则相同且生效时间段重叠的激活目标（排除 excludeID），不存在时返回 nil
+func (u *upgradeUsecase) findConflictingTarget(ctx context.Context, packageID, channel string, candidate *domain.UpgradeTarget, excludeID string) (*domain.UpgradeTarget, error) {
+	targets, err := u.upgradeRepository.GetActiveUpgradeTargetsByPackageID(ctx, packageID, channel)
+	if err != nil {
+		return nil, fmt.Errorf("获取激活的升级目标失败: %w", err)
+	}
+	for _, target := range targets {
+		if target.ID != excludeID && target.TargetingRules.Equal(candidate.TargetingRules) && target.ScheduleOverlaps(candidate) {
+			return target, nil
+		}
+	}
+	return nil, nil
+}
+
+// clearableTime 零值时间表示清除
+func clearableTime(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// normalizeTargetingRules 规范化并校验定向规则，空规则返回 nil
+func normalizeTargetingRules(rules *domain.TargetingRules) (*domain.TargetingRules, error) {
+	if rules == nil {
+		return nil, nil
+	}
+	normalized := *rules
+	normalized.Normalize()
+	for i, osName := range normalized.OS {
+		normalized.OS[i] = pkg.NormalizeOS(osName)
+	}
+	if err := normalized.Validate(); err != nil {
+		return nil, err
+	}
+	if normalized.IsEmpty() {
+		return nil, nil
+	}
+	return &normalized, nil
+}
+
+// validateRollout 校验灰度比例与放量计划
+func validateRollout(percentage *int, schedule []domain.RolloutStep) error {
+	if percentage != nil && (*percentage < 0 || *percentage > 100) {
+		return errors.New("灰度比例必须在 0-100 之间")
+	}
+	for _, step := range schedule {
+		if step.Percentage < 0 || step.Percentage > 100 {
+			return errors.New("放量计划中的比例必须在 0-100 之间")
+		}
+		if step.At.IsZero() {
+			return errors.New("放量计划中的时间不能为空")
+		}
+	}
+	return nil
+}
+
+// sortRolloutSchedule 按时间排序放量计划
+func sortRolloutSchedule(schedule []domain.RolloutStep) []domain.RolloutStep {
+	sorted := make([]domain.RolloutStep, len(schedule))
+	copy(sorted, schedule)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].At.Before(sorted[j].At)
+	})
+	return sorted
+}
+
 func (u *upgradeUsecase) DeleteUpgradeTarget(ctx context.Context, id string) error {
 	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
@@ -172,57 +573,129 @@ func (u *upgradeUsecase) CheckUpdateByToken(ctx context.Context, request *domain
 	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
 
-	// 如果没有注入clientAccessRepository，返回错误
-	if u.clientAccessRepository == nil {
-		return nil, errors.New("客户端接入功能未启用")
-	}
-
-	// 1. 根据access_token查找ClientAccess记录
-	clientAccess, err := u.clientAccessRepository.GetByAccessToken(c, accessToken)
+	// 1-2. 根据access_token查找ClientAccess记录并验证有效性
+	clientAccess, err := u.validateClientAccess(c, accessToken)
 	if err != nil {
-		return nil, fmt.Errorf("无效的访问令牌: %w", err)
+		return nil, err
 	}
 
-	// 2. 验证token有效性（未过期、已启用）
-	if !clientAccess.IsActive {
-		return nil, errors.New("客户端接入凭证已被禁用")
+	response, err := u.checkUpdate(c, clientAccess, request, clientIP, accessToken)
+	if err != nil {
+		return nil, err
 	}
 
-	if clientAccess.ExpiresAt != nil && clientAccess.ExpiresAt.Before(time.Now()) {
-		return nil, errors.New("客户端接入凭证已过期")
+	// 对整个响应签名（包括暂无更新的响应），客户端可离线校验
+	if u.signingUsecase != nil {
+		signature, err := u.signingUsecase.SignManifest(c, clientAccess.TenantID, response)
+		if err != nil {
+			return nil, fmt.Errorf("签名更新响应失败: %w", err)
+		}
+		response.Signature = signature
 	}
+	return response, nil
+}
 
+// checkUpdate 为已验证的客户端计算检查更新结果
+func (u *upgradeUsecase) checkUpdate(c context.Context, clientAccess *domain.ClientAccess, request *domain.CheckUpdateRequest, clientIP, accessToken string) (*domain.CheckUpdateResponse, error) {
 	// 3. 更新使用统计
 	if err := u.clientAccessRepository.UpdateUsage(c, accessToken, clientIP); err != nil {
 		// 记录错误但不影响主流程
 		fmt.Printf("更新客户端使用统计失败: %v\n", err)
 	}
 
-	// 4. 根据绑定的package_id获取升级目标
-	upgradeTarget, err := u.upgradeRepository.GetActiveUpgradeTargetByPackageID(c, clientAccess.PackageID)
+	// 4. 确定渠道：凭证绑定的渠道优先，否则使用客户端请求的渠道（默认 stable）
+	channel := domain.NormalizeChannel(request.Channel)
+	if clientAccess.Channel != "" {
+		if request.Channel != "" && request.Channel != clientAccess.Channel {
+			return nil, fmt.Errorf("客户端接入凭证已绑定 %s 渠道", clientAccess.Channel)
+		}
+		channel = clientAccess.Channel
+	}
+
+	// 登记设备（失败不影响检查更新）
+	u.registerDevice(c, clientAccess, request, channel, clientIP)
+
+	// 5. 根据绑定的package_id和渠道评估定向规则，选出最具体的升级目标
+	attrs := request.TargetingAttributes()
+	if attrs.OS != "" {
+		attrs.OS = pkg.NormalizeOS(attrs.OS)
+	}
+	now := time.Now()
+	upgradeTarget, err := u.upgradeRepository.GetActiveUpgradeTargetByPackageID(c, clientAccess.PackageID, channel, attrs)
 	if err != nil {
-		// 没有找到升级目标，表示当前没有可用更新
+		// 没有找到生效中的升级目标，表示当前没有可用更新；有提前创建的目标时告知其生效时间
 		return &domain.CheckUpdateResponse{
 			HasUpdate:      false,
 			CurrentVersion: request.CurrentVersion,
 			LatestVersion:  request.CurrentVersion,
+			Channel:        channel,
+			NextWindowAt:   u.nextScheduledUpdateAt(c, clientAccess.PackageID, channel, attrs, request.CurrentVersion, now),
 		}, nil
 	}
 
-	// 比较版本，简单的字符串比较（实际项目中可能需要更复杂的版本比较逻辑）
-	hasUpdate := upgradeTarget.Version != request.CurrentVersion
+	// 按包配置的版本方案比较版本，只有回滚目标才会向更高版本的客户端下发降级
+	cmp := versioning.Compare(upgradeTarget.VersionScheme, upgradeTarget.Version, request.CurrentVersion)
+	isRollback := cmp < 0 && upgradeTarget.IsRollback
+	hasUpdate := cmp > 0 || isRollback
+
+	// 强制更新：目标标记为强制，或客户端版本低于最低支持版本
+	belowMinVersion := upgradeTarget.MinSupportedVersion != "" &&
+		versioning.Compare(upgradeTarget.VersionScheme, request.CurrentVersion, upgradeTarget.MinSupportedVersion) < 0
+	forceUpdate := hasUpdate && (upgradeTarget.IsMandatory || belowMinVersion)
+
+	// 维护窗口：窗口外视为暂无更新，并返回下一个窗口的开始时间
+	if hasUpdate {
+		if next := upgradeTarget.NextAvailableAt(now); next == nil || next.After(now) {
+			return &domain.CheckUpdateResponse{
+				HasUpdate:      false,
+				CurrentVersion: request.CurrentVersion,
+				LatestVersion:  request.CurrentVersion,
+				Channel:        channel,
+				NextWindowAt:   next,
+			}, nil
+		}
+	}
+
+	// 灰度发布：未命中灰度的客户端视为暂无更新；低于最低支持版本的客户端不受灰度比例限制（暂停时除外）
+	skipRollout := belowMinVersion && upgradeTarget.RolloutStatus != domain.RolloutStatusPaused
+	if hasUpdate && !skipRollout && !upgradeTarget.InRollout(request.ClientID, now) {
+		return &domain.CheckUpdateResponse{
+			HasUpdate:      false,
+			CurrentVersion: request.CurrentVersion,
+			LatestVersion:  request.CurrentVersion,
+			Channel:        channel,
+		}, nil
+	}
 
 	response := &domain.CheckUpdateResponse{
 		HasUpdate:      hasUpdate,
 		CurrentVersion: request.CurrentVersion,
 		LatestVersion:  upgradeTarget.Version,
+		Channel:        channel,
+		IsRollback:     isRollback,
+		ForceUpdate:    forceUpdate,
+		MinVersion:     upgradeTarget.MinSupportedVersion,
 	}
 
 	// 如果有更新，填充下载信息
 	if hasUpdate {
 		response.DownloadURL = upgradeTarget.DownloadURL
+		response.FileName = upgradeTarget.FileName
 		response.FileSize = upgradeTarget.FileSize
 		response.FileHash = upgradeTarget.FileHash
+		response.SHA256 = upgradeTarget.SHA256
+		response.SHA512 = upgradeTarget.SHA512
+		response.FileSignature = upgradeTarget.Signature
+		response.SignatureKeyID = upgradeTarget.SignatureKeyID
+		// 多平台版本：根据客户端上报的 os/arch 选择对应构件
+		toAssetID := ""
+		if request.OS != "" {
+			if asset := u.applyPlatformAsset(c, response, upgradeTarget.ReleaseID, request.OS, request.Arch); asset != nil {
+				toAssetID = asset.ID
+			}
+		}
+		// 客户端当前版本存在可用的差分补丁时一并下发
+		u.applyPatch(c, response, upgradeTarget, request.CurrentVersion, toAssetID)
 		// 可以添加变更日志等信息
 		if upgradeTarget.Description != "" {
 			response.Changelog = upgradeTarget.Description
@@ -232,6 +705,288 @@ func (u *upgradeUsecase) CheckUpdateByToken(ctx context.Context, request *domain
 	return response, nil
 }
 
+// nextScheduledUpdateAt 在尚未生效的激活目标中，找到命中客户端且版本高于当前版本的目标最早可下发更新的时间
+func (u *upgradeUsecase) nextScheduledUpdateAt(ctx context.Context, packageID, channel string, attrs *domain.TargetingAttributes, currentVersion string, now time.Time) *time.Time {
+	targets, err := u.upgradeRepository.GetActiveUpgradeTargetsByPackageID(ctx, packageID, channel)
+	if err != nil {
+		return nil
+	}
+
+	var earliest *time.Time
+	for _, target := range targets {
+		if target.StartsAt == nil || !now.Before(*target.StartsAt) || !target.TargetingRules.Matches(attrs) {
+			continue
+		}
+		if versioning.Compare(target.VersionScheme, target.Version, currentVersion) <= 0 {
+			continue
+		}
+		if next := target.NextAvailableAt(now); next != nil && (earliest == nil || next.Before(*earliest)) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// registerDevice 根据检查更新请求写入或刷新设备记录，未上报 client_id 的客户端不登记
+func (u *upgradeUsecase) registerDevice(ctx context.Context, clientAccess *domain.ClientAccess, request *domain.CheckUpdateRequest, channel, clientIP string) {
+	if u.deviceRepository == nil || request.ClientID == "" {
+		return
+	}
+
+	device := &domain.Device{
+		TenantID:       clientAccess.TenantID,
+		ProjectID:      clientAccess.ProjectID,
+		PackageID:      clientAccess.PackageID,
+		ClientAccessID: clientAccess.ID,
+		DeviceID:       request.ClientID,
+		CurrentVersion: request.CurrentVersion,
+		Channel:        channel,
+		ClientInfo:     request.ClientInfo,
+		LastIP:         clientIP,
+	}
+	if request.OS != "" {
+		device.OS = pkg.NormalizeOS(request.OS)
+	}
+	if request.Arch != "" {
+		device.Arch = pkg.NormalizeArch(request.Arch)
+	}
+	if request.Device != nil {
+		device.OSVersion = request.Device.OSVersion
+		device.Model = request.Device.Model
+		device.Region = request.Device.Region
+		device.Hostname = request.Device.Hostname
+		device.Labels = request.Device.Labels
+	}
+
+	if err := u.deviceRepository.Upsert(ctx, device); err != nil {
+		pkg.Log.Errorf("登记设备失败 %s: %v", request.ClientID, err)
+	}
+}
+
+// validateClientAccess 根据access_token查找客户端接入凭证，并验证未过期、已启用
+func (u *upgradeUsecase) validateClientAccess(c context.Context, accessToken string) (*domain.ClientAccess, error) {
+	// 如果没有注入clientAccessRepository，返回错误
+	if u.clientAccessRepository == nil {
+		return nil, errors.New("客户端接入功能未启用")
+	}
+
+	clientAccess, err := u.clientAccessRepository.GetByAccessToken(c, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("无效的访问令牌: %w", err)
+	}
+	if !clientAccess.IsActive {
+		return nil, errors.New("客户端接入凭证已被禁用")
+	}
+	if clientAccess.ExpiresAt != nil && clientAccess.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("客户端接入凭证已过期")
+	}
+	return clientAccess, nil
+}
+
+func (u *upgradeUsecase) ReportUpgradeResult(ctx context.Context, request *domain.ReportUpgradeRequest, clientIP, accessToken string) (*domain.UpgradeReport, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if u.reportRepository == nil {
+		return nil, errors.New("升级结果上报功能未启用")
+	}
+
+	clientAccess, err := u.validateClientAccess(c, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 定位上报的版本：优先使用 release_id，否则按版本号查找
+	release, err := u.resolveReportRelease(c, clientAccess.PackageID, request)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.UpgradeReport{
+		TenantID:       clientAccess.TenantID,
+		PackageID:      clientAccess.PackageID,
+		ReleaseID:      release.ID,
+		ClientAccessID: clientAccess.ID,
+		ClientID:       request.ClientID,
+		FromVersion:    request.FromVersion,
+		Stage:          request.Stage,
+		Status:         request.Status,
+		Message:        request.Message,
+		ClientIP:       clientIP,
+	}
+
+	// 关联到指向该版本的激活升级目标
+	var target *domain.UpgradeTarget
+	targets, err := u.upgradeRepository.GetUpgradeTargets(c, clientAccess.TenantID, map[string]interface{}{
+		"package_id": clientAccess.PackageID,
+		"is_active":  true,
+	})
+	if err == nil {
+		for _, t := range targets {
+			if t.ReleaseID == release.ID && (clientAccess.Channel == "" || t.Channel == clientAccess.Channel) {
+				target = t
+				break
+			}
+		}
+	}
+	if target != nil {
+		report.UpgradeID = target.ID
+	}
+
+	if err := u.reportRepository.Upsert(c, report); err != nil {
+		return nil, fmt.Errorf("保存升级结果失败: %w", err)
+	}
+
+	if target != nil && request.Status == domain.ReportStatusFailure {
+		u.checkAutoPause(c, target)
+	}
+
+	return report, nil
+}
+
+func (u *upgradeUsecase) GetUpgradeTargetStats(ctx context.Context, id string) (*domain.UpgradeTargetStats, error) {
+	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
+	defer cancel()
+
+	if u.reportRepository == nil {
+		return nil, errors.New("升级结果上报功能未启用")
+	}
+
+	target, err := u.upgradeRepository.GetUpgradeTargetByID(c, id)
+	if err != nil {
+		return nil, fmt.Errorf("升级目标不存在: %w", err)
+	}
+
+	stats, err := u.reportRepository.GetStatsByUpgradeID(c, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取升级统计失败: %w", err)
+	}
+	stats.ReleaseID = target.ReleaseID
+	return stats, nil
+}
+
+// resolveReportRelease 根据上报请求定位版本，版本必须属于凭证绑定的包
+func (u *upgradeUsecase) resolveReportRelease(c context.Context, packageID string, request *domain.ReportUpgradeRequest) (*domain.Release, error) {
+	if request.ReleaseID != "" {
+		release, err := u.releaseRepository.GetByID(c, request.ReleaseID)
+		if err != nil || release.PackageID != packageID {
+			return nil, errors.New("版本不存在")
+		}
+		return release, nil
+	}
+
+	if request.Version == "" {
+		return nil, errors.New("release_id 和 version 不能同时为空")
+	}
+	releases, err := u.releaseRepository.GetByPackageID(c, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("获取版本列表失败: %w", err)
+	}
+	for _, r := range releases {
+		if r.Version() == request.Version || (r.VersionName != "" && r.VersionName == request.Version) {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("版本 %s 不存在", request.Version)
+}
+
+// checkAutoPause 失败率超过阈值时自动暂停升级目标
+func (u *upgradeUsecase) checkAutoPause(c context.Context, target *domain.UpgradeTarget) {
+	if u.failureThreshold <= 0 || target.RolloutStatus == domain.RolloutStatusPaused {
+		return
+	}
+
+	stats, err := u.reportRepository.GetStatsByUpgradeID(c, target.ID)
+	if err != nil {
+		pkg.Log.Errorf("统计升级结果失败 (upgrade %s): %v", target.ID, err)
+		return
+	}
+	if stats.Reports < u.failureMinReports || stats.FailureRatio <= u.failureThreshold {
+		return
+	}
+
+	reason := fmt.Sprintf("auto: failure ratio %.2f exceeds %.2f (%d reports)", stats.FailureRatio, u.failureThreshold, stats.Reports)
+	if err := u.upgradeRepository.UpdateUpgradeTarget(c, target.ID, map[string]interface{}{
+		"rollout_status": domain.RolloutStatusPaused,
+		"pause_reason":   reason,
+	}); err != nil {
+		pkg.Log.Errorf("自动暂停升级目标失败 (upgrade %s): %v", target.ID, err)
+		return
+	}
+	pkg.Log.Warnf("升级目标 %s 已自动暂停: %s", target.ID, reason)
+}
+
+// applyPlatformAsset 用匹配客户端平台的构件信息覆盖响应中的下载信息，返回选中的构件
+func (u *upgradeUsecase) applyPlatformAsset(c context.Context, response *domain.CheckUpdateResponse, releaseID, osName, arch string) *domain.ReleaseAsset {
+	release, err := u.releaseRepository.GetByID(c, releaseID)
+	if err != nil {
+		return nil
+	}
+	asset := release.MatchAsset(osName, arch, "")
+	if asset == nil {
+		return nil
+	}
+
+	query := url.Values{}
+	query.Set("os", asset.OS)
+	if asset.Arch != "" {
+		query.Set("arch", asset.Arch)
+	}
+	query.Set("kind", asset.Kind)
+	response.DownloadURL = fmt.Sprintf("/client-access/download/%s?%s", releaseID, query.Encode())
+	response.FileName = asset.FileName
+	response.FileSize = asset.FileSize
+	response.FileHash = asset.FileHash
+	response.SHA256 = asset.SHA256
+	response.SHA512 = asset.SHA512
+	response.FileSignature = asset.Signature
+	response.SignatureKeyID = asset.SignatureKeyID
+	return asset
+}
+
+// applyPatch 查找从客户端当前版本到目标版本的差分补丁并写入响应
+func (u *upgradeUsecase) applyPatch(c context.Context, response *domain.CheckUpdateResponse, target *domain.UpgradeTarget, currentVersion, toAssetID string) {
+	if u.patchUsecase == nil {
+		return
+	}
+
+	releases, err := u.releaseRepository.GetByPackageID(c, target.PackageID)
+	if err != nil {
+		return
+	}
+	var current *domain.Release
+	for _, r := range releases {
+		if r.Version() == currentVersion || (r.VersionName != "" && r.VersionName == currentVersion) {
+			current = r
+			break
+		}
+	}
+	if current == nil || current.ID == target.ReleaseID {
+		return
+	}
+
+	patch, err := u.patchUsecase.FindPatch(c, current.ID, target.ReleaseID, toAssetID)
+	if err != nil {
+		return
+	}
+	response.PatchURL = "/client-access/patch/" + patch.ID
+	response.PatchSize = patch.FileSize
+	response.PatchHash = patch.TargetHash
+	response.PatchFormat = patch.Format
+}
+
+// schedulePatchGeneration 在后台为目标版本生成差分补丁
+func (u *upgradeUsecase) schedulePatchGeneration(releaseID string) {
+	if u.patchUsecase == nil {
+		return
+	}
+	go func() {
+		if err := u.patchUsecase.GeneratePatches(context.Background(), releaseID); err != nil {
+			pkg.Log.Errorf("生成差分补丁失败 (release %s): %v", releaseID, err)
+		}
+	}()
+}
+
 func (u *upgradeUsecase) GetProjectUpgradeTargets(ctx context.Context, projectID string) ([]*domain.UpgradeTarget, error) {
 	c, cancel := context.WithTimeout(ctx, u.contextTimeout)
 	defer cancel()
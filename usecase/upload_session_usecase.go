@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"pkms/domain"
+	"pkms/pkg"
+)
+
+// uploadLocks 同一会话的分片串行写入
+var uploadLocks sync.Map
+
+type uploadSessionUsecase struct {
+	uploadSessionRepository domain.UploadSessionRepository
+	stagingDir              string
+	maxSize                 int64 // 字节，<=0 表示不限制
+	expiry                  time.Duration
+	contextTimeout          time.Duration
+}
+
+func NewUploadSessionUsecase(uploadSessionRepository domain.UploadSessionRepository, stagingDir string, maxSize int64, expiry, timeout time.Duration) domain.UploadSessionUsecase {
+	return &uploadSessionUsecase{
+		uploadSessionRepository: uploadSessionRepository,
+		stagingDir:              stagingDir,
+		maxSize:                 maxSize,
+		expiry:                  expiry,
+		contextTimeout:          timeout,
+	}
+}
+
+func (uu *uploadSessionUsecase) CreateSession(ctx context.Context, session *domain.UploadSession) error {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	if session.Size <= 0 {
+		return errors.New("文件大小必须大于 0")
+	}
+	if uu.maxSize > 0 && session.Size > uu.maxSize {
+		return domain.ErrUploadTooLarge
+	}
+	if session.FileName == "" || filepath.Base(session.FileName) != session.FileName {
+		return fmt.Errorf("无效的文件名: %s", session.FileName)
+	}
+
+	// 顺带清理过期会话，避免暂存目录无限增长
+	if n, err := uu.CleanupExpired(c); err != nil {
+		pkg.Log.Errorf("清理过期上传会话失败: %v", err)
+	} else if n > 0 {
+		pkg.Log.Printf("已清理 %d 个过期上传会话", n)
+	}
+
+	if err := os.MkdirAll(uu.stagingDir, 0755); err != nil {
+		return fmt.Errorf("创建暂存目录失败: %w", err)
+	}
+
+	session.ExpiresAt = time.Now().Add(uu.expiry)
+	if err := uu.uploadSessionRepository.Create(c, session); err != nil {
+		return err
+	}
+
+	// 预先创建空的暂存文件
+	f, err := os.OpenFile(uu.stagingPath(session.ID), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		_ = uu.uploadSessionRepository.Delete(c, session.ID)
+		return fmt.Errorf("创建暂存文件失败: %w", err)
+	}
+	return f.Close()
+}
+
+func (uu *uploadSessionUsecase) GetSession(ctx context.Context, id string) (*domain.UploadSession, error) {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	session, err := uu.uploadSessionRepository.GetByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != domain.UploadSessionStatusFinalized && time.Now().After(session.ExpiresAt) {
+		return nil, domain.ErrUploadSessionExpired
+	}
+	return session, nil
+}
+
+func (uu *uploadSessionUsecase) WriteChunk(ctx context.Context, id string, offset int64, reader io.Reader) (*domain.UploadSession, error) {
+	lock, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	session, err := uu.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != domain.UploadSessionStatusUploading {
+		return nil, domain.ErrUploadSessionClosed
+	}
+	if offset != session.Offset {
+		return nil, domain.ErrUploadOffsetMismatch
+	}
+
+	f, err := os.OpenFile(uu.stagingPath(id), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开暂存文件失败: %w", err)
+	}
+	// 丢弃上次中断时写入但未记录的数据
+	if err := f.Truncate(session.Offset); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("截断暂存文件失败: %w", err)
+	}
+	if _, err := f.Seek(session.Offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("定位暂存文件失败: %w", err)
+	}
+
+	// 分片的传输时间不受 contextTimeout 限制，只限制写入的字节数不超过文件大小
+	written, copyErr := io.Copy(f, io.LimitReader(reader, session.Size-session.Offset))
+	if err := f.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	f.Close()
+
+	// 即使连接中断，已写入的部分也会记录下来，客户端可从新的偏移继续
+	session.Offset += written
+	updates := map[string]interface{}{
+		"offset":     session.Offset,
+		"expires_at": time.Now().Add(uu.expiry),
+	}
+	if session.Offset == session.Size {
+		session.Status = domain.UploadSessionStatusCompleted
+		updates["status"] = session.Status
+	}
+
+	c, cancel := context.WithTimeout(context.WithoutCancel(ctx), uu.contextTimeout)
+	defer cancel()
+	if err := uu.uploadSessionRepository.Update(c, id, updates); err != nil {
+		return nil, fmt.Errorf("更新上传进度失败: %w", err)
+	}
+	if copyErr != nil {
+		return session, fmt.Errorf("接收分片中断: %w", copyErr)
+	}
+	return session, nil
+}
+
+func (uu *uploadSessionUsecase) BeginFinalize(ctx context.Context, id string) (*domain.UploadSession, error) {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	ok, err := uu.uploadSessionRepository.UpdateStatus(c, id, domain.UploadSessionStatusCompleted, domain.UploadSessionStatusFinalizing)
+	if err != nil {
+		return nil, err
+	}
+	session, err := uu.GetSession(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return session, nil
+	}
+
+	// 并发或重试的请求：按会话当前状态返回
+	switch session.Status {
+	case domain.UploadSessionStatusFinalized:
+		return session, nil
+	case domain.UploadSessionStatusFinalizing:
+		return nil, domain.ErrUploadFinalizing
+	default:
+		return nil, domain.ErrUploadIncomplete
+	}
+}
+
+func (uu *uploadSessionUsecase) AbortFinalize(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(context.WithoutCancel(ctx), uu.contextTimeout)
+	defer cancel()
+
+	_, err := uu.uploadSessionRepository.UpdateStatus(c, id, domain.UploadSessionStatusFinalizing, domain.UploadSessionStatusCompleted)
+	return err
+}
+
+func (uu *uploadSessionUsecase) OpenUpload(ctx context.Context, id string) (io.ReadCloser, error) {
+	session, err := uu.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != domain.UploadSessionStatusFinalizing {
+		return nil, domain.ErrUploadIncomplete
+	}
+	return os.Open(uu.stagingPath(id))
+}
+
+func (uu *uploadSessionUsecase) MarkFinalized(ctx context.Context, id, releaseID string) error {
+	// 版本已经创建，即使请求被取消也要记录，否则会话会停留在 finalizing
+	c, cancel := context.WithTimeout(context.WithoutCancel(ctx), uu.contextTimeout)
+	defer cancel()
+
+	if err := uu.uploadSessionRepository.Update(c, id, map[string]interface{}{
+		"status":     domain.UploadSessionStatusFinalized,
+		"release_id": releaseID,
+	}); err != nil {
+		return err
+	}
+	uu.removeStaging(id)
+	return nil
+}
+
+func (uu *uploadSessionUsecase) DeleteSession(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	if err := uu.uploadSessionRepository.Delete(c, id); err != nil {
+		return err
+	}
+	uu.removeStaging(id)
+	return nil
+}
+
+func (uu *uploadSessionUsecase) CleanupExpired(ctx context.Context) (int, error) {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+
+	sessions, err := uu.uploadSessionRepository.GetExpired(c, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		if err := uu.uploadSessionRepository.Delete(c, session.ID); err != nil {
+			return 0, err
+		}
+		uu.removeStaging(session.ID)
+	}
+	return len(sessions), nil
+}
+
+func (uu *uploadSessionUsecase) stagingPath(id string) string {
+	return filepath.Join(uu.stagingDir, id+".part")
+}
+
+func (uu *uploadSessionUsecase) removeStaging(id string) {
+	if err := os.Remove(uu.stagingPath(id)); err != nil && !os.IsNotExist(err) {
+		pkg.Log.Errorf("删除暂存文件失败 %s: %v", id, err)
+	}
+	uploadLocks.Delete(id)
+}
@@ -0,0 +1,445 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"pkms/domain"
+	"pkms/internal/pgpsign"
+	"pkms/internal/rpm"
+)
+
+const (
+	yumNamespaceCommon    = "http://linux.duke.edu/metadata/common"
+	yumNamespaceRPM       = "http://linux.duke.edu/metadata/rpm"
+	yumNamespaceFilelists = "http://linux.duke.edu/metadata/filelists"
+	yumNamespaceOther     = "http://linux.duke.edu/metadata/other"
+	yumNamespaceRepo      = "http://linux.duke.edu/metadata/repo"
+)
+
+type yumRepositoryUsecase struct {
+	indexer        *linuxPackageIndexer
+	signer         *pgpsign.Signer
+	contextTimeout time.Duration
+	// 软件包列表不变时沿用已生成的 repodata，repomd.xml 的 revision 和文件名因此保持不变
+	mu       sync.Mutex
+	repodata map[string]*yumRepodata
+}
+
+type yumRepodata struct {
+	digest string
+	files  map[string][]byte // repodata/ 下的文件名 -> 内容
+	// 上一次生成的文件，客户端读取旧 repomd.xml 后仍可下载其引用的文件
+	previous map[string][]byte
+}
+
+// NewYumRepositoryUsecase signer 为空时不提供 repomd.xml.asc，客户端需关闭 repo_gpgcheck
+func NewYumRepositoryUsecase(packageRepository domain.PackageRepository, releaseRepository domain.ReleaseRepository, linuxPackageRepository domain.LinuxPackageRepository, fileRepository domain.FileRepository, signer *pgpsign.Signer, bucket string, timeout time.Duration) domain.YumRepositoryUsecase {
+	return &yumRepositoryUsecase{
+		indexer:        newLinuxPackageIndexer(domain.LinuxPackageFormatRPM, packageRepository, releaseRepository, linuxPackageRepository, fileRepository, bucket),
+		signer:         signer,
+		contextTimeout: timeout,
+		repodata:       make(map[string]*yumRepodata),
+	}
+}
+
+func extractRPMPackage(r io.Reader) (*domain.LinuxPackage, error) {
+	header, err := rpm.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LinuxPackage{
+		Name:         header.Name,
+		Version:      header.EVR(),
+		Architecture: header.Arch,
+		Metadata:     string(metadata),
+	}, nil
+}
+
+func (yu *yumRepositoryUsecase) GetMetadataFile(ctx context.Context, scope domain.RepositoryScope, path string) ([]byte, error) {
+	c, cancel := context.WithTimeout(ctx, yu.contextTimeout)
+	defer cancel()
+
+	// {channel}/repodata/{file}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 || !isRepositoryChannel(parts[0]) || parts[1] != "repodata" {
+		return nil, domain.ErrRepositoryFileNotFound
+	}
+	channel, name := parts[0], parts[2]
+
+	entries, err := yu.indexer.collect(c, scope)
+	if err != nil {
+		return nil, err
+	}
+	repodata, err := yu.getRepodata(scope.Type+"/"+scope.ID+"/"+channel, filterRepositoryChannel(entries, channel))
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := repodata.files[name]; ok {
+		return data, nil
+	}
+	if data, ok := repodata.previous[name]; ok {
+		return data, nil
+	}
+	return nil, domain.ErrRepositoryFileNotFound
+}
+
+func (yu *yumRepositoryUsecase) GetPackage(ctx context.Context, scope domain.RepositoryScope, id string) (*domain.LinuxPackage, error) {
+	c, cancel := context.WithTimeout(ctx, yu.contextTimeout)
+	defer cancel()
+	return yu.indexer.poolPackage(c, scope, id)
+}
+
+func (yu *yumRepositoryUsecase) PublicKey() ([]byte, error) {
+	if yu.signer == nil {
+		return nil, errors.New("仓库签名密钥未配置")
+	}
+	return yu.signer.PublicKey()
+}
+
+// getRepodata 软件包列表变化（版本新增、删除或发布）时重新生成 repodata
+func (yu *yumRepositoryUsecase) getRepodata(key string, entries []linuxPackageEntry) (*yumRepodata, error) {
+	h := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(h, "%s %s\n", entry.ID, entry.SHA256)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	yu.mu.Lock()
+	defer yu.mu.Unlock()
+	cached := yu.repodata[key]
+	if cached != nil && cached.digest == digest {
+		return cached, nil
+	}
+
+	files, err := yu.buildRepodata(entries)
+	if err != nil {
+		return nil, err
+	}
+	repodata := &yumRepodata{digest: digest, files: files}
+	if cached != nil {
+		repodata.previous = cached.files
+	}
+	yu.repodata[key] = repodata
+	return repodata, nil
+}
+
+// buildRepodata 生成 primary/filelists/other（gzip 压缩，文件名带摘要前缀）、repomd.xml 及其签名
+func (yu *yumRepositoryUsecase) buildRepodata(entries []linuxPackageEntry) (map[string][]byte, error) {
+	primary := yumPrimary{Xmlns: yumNamespaceCommon, XmlnsRPM: yumNamespaceRPM}
+	filelists := yumFilelists{Xmlns: yumNamespaceFilelists}
+	other := yumOther{Xmlns: yumNamespaceOther}
+	for _, entry := range entries {
+		var header rpm.Package
+		if err := json.Unmarshal([]byte(entry.Metadata), &header); err != nil {
+			continue
+		}
+		version := yumVersion{Epoch: header.Epoch, Ver: header.Version, Rel: header.Release}
+		primary.Packages = append(primary.Packages, newYumPrimaryPackage(entry.LinuxPackage, &header))
+		filelists.Packages = append(filelists.Packages, yumFilelistsPackage{
+			PkgID:   entry.SHA256,
+			Name:    header.Name,
+			Arch:    header.Arch,
+			Version: version,
+			Files:   yumFiles(header.Files, nil),
+		})
+		otherPackage := yumOtherPackage{PkgID: entry.SHA256, Name: header.Name, Arch: header.Arch, Version: version}
+		for _, changelog := range header.Changelogs {
+			otherPackage.Changelogs = append(otherPackage.Changelogs, yumChangelog{Author: changelog.Author, Date: changelog.Date, Text: changelog.Text})
+		}
+		other.Packages = append(other.Packages, otherPackage)
+	}
+	primary.Count = len(primary.Packages)
+	filelists.Count = len(filelists.Packages)
+	other.Count = len(other.Packages)
+
+	now := time.Now().Unix()
+	repomd := yumRepomd{Xmlns: yumNamespaceRepo, XmlnsRPM: yumNamespaceRPM, Revision: now}
+	files := make(map[string][]byte)
+	for _, metadata := range []struct {
+		name string
+		v    any
+	}{
+		{"primary", primary},
+		{"filelists", filelists},
+		{"other", other},
+	} {
+		data, err := marshalYumXML(metadata.v)
+		if err != nil {
+			return nil, err
+		}
+		compressed, err := gzipBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		openSum := sha256.Sum256(data)
+		sum := sha256.Sum256(compressed)
+		fileName := hex.EncodeToString(sum[:]) + "-" + metadata.name + ".xml.gz"
+		files[fileName] = compressed
+		repomd.Data = append(repomd.Data, yumRepomdData{
+			Type:         metadata.name,
+			Checksum:     yumChecksum{Type: "sha256", Value: hex.EncodeToString(sum[:])},
+			OpenChecksum: yumChecksum{Type: "sha256", Value: hex.EncodeToString(openSum[:])},
+			Location:     yumLocation{Href: "repodata/" + fileName},
+			Timestamp:    now,
+			Size:         int64(len(compressed)),
+			OpenSize:     int64(len(data)),
+		})
+	}
+
+	data, err := marshalYumXML(repomd)
+	if err != nil {
+		return nil, err
+	}
+	files["repomd.xml"] = data
+	if yu.signer != nil {
+		signature, err := yu.signer.DetachSign(data)
+		if err != nil {
+			return nil, err
+		}
+		files["repomd.xml.asc"] = signature
+	}
+	return files, nil
+}
+
+// yumPackageLocation 软件包相对于通道目录的下载路径
+func yumPackageLocation(lp *domain.LinuxPackage, header *rpm.Package) string {
+	return fmt.Sprintf("packages/%s/%s", lp.ID, header.FileName())
+}
+
+func newYumPrimaryPackage(lp *domain.LinuxPackage, header *rpm.Package) yumPrimaryPackage {
+	return yumPrimaryPackage{
+		Type:        "rpm",
+		Name:        header.Name,
+		Arch:        header.Arch,
+		Version:     yumVersion{Epoch: header.Epoch, Ver: header.Version, Rel: header.Release},
+		Checksum:    yumChecksum{Type: "sha256", PkgID: "YES", Value: lp.SHA256},
+		Summary:     header.Summary,
+		Description: header.Description,
+		Packager:    header.Packager,
+		URL:         header.URL,
+		Time:        yumTime{File: lp.CreatedAt.Unix(), Build: header.BuildTime},
+		Size:        yumSize{Package: lp.FileSize, Installed: header.InstalledSize, Archive: header.ArchiveSize},
+		Location:    yumLocation{Href: yumPackageLocation(lp, header)},
+		Format: yumFormat{
+			License:     header.License,
+			Vendor:      header.Vendor,
+			Group:       header.Group,
+			BuildHost:   header.BuildHost,
+			SourceRPM:   header.SourceRPM,
+			HeaderRange: yumHeaderRange{Start: header.HeaderStart, End: header.HeaderEnd},
+			Provides:    yumDependencies(header.Provides),
+			Requires:    yumDependencies(header.Requires),
+			Conflicts:   yumDependencies(header.Conflicts),
+			Obsoletes:   yumDependencies(header.Obsoletes),
+			Files:       yumFiles(header.Files, isYumPrimaryFile),
+		},
+	}
+}
+
+// isYumPrimaryFile primary.xml 只列出常被依赖的文件（与 createrepo 相同：/etc/ 下、bin/ 目录下和 /usr/lib/sendmail）
+func isYumPrimaryFile(path string) bool {
+	return strings.HasPrefix(path, "/etc/") || strings.Contains(path, "bin/") || path == "/usr/lib/sendmail"
+}
+
+func yumFiles(files []rpm.File, filter func(string) bool) []yumFile {
+	var result []yumFile
+	for _, file := range files {
+		if filter == nil || filter(file.Path) {
+			result = append(result, yumFile{Type: file.Type, Path: file.Path})
+		}
+	}
+	return result
+}
+
+func yumDependencies(dependencies []rpm.Dependency) *yumEntries {
+	if len(dependencies) == 0 {
+		return nil
+	}
+	entries := &yumEntries{}
+	for _, dependency := range dependencies {
+		entry := yumEntry{
+			Name:  dependency.Name,
+			Flags: dependency.Flags,
+			Epoch: dependency.Epoch,
+			Ver:   dependency.Version,
+			Rel:   dependency.Release,
+		}
+		if dependency.Pre {
+			entry.Pre = "1"
+		}
+		entries.Entries = append(entries.Entries, entry)
+	}
+	return entries
+}
+
+func marshalYumXML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// 以下为 repodata 的 XML 结构，字段与 createrepo 生成的元数据一致
+
+type yumVersion struct {
+	Epoch string `xml:"epoch,attr"`
+	Ver   string `xml:"ver,attr"`
+	Rel   string `xml:"rel,attr"`
+}
+
+type yumChecksum struct {
+	Type  string `xml:"type,attr"`
+	PkgID string `xml:"pkgid,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type yumLocation struct {
+	Href string `xml:"href,attr"`
+}
+
+type yumFile struct {
+	Type string `xml:"type,attr,omitempty"`
+	Path string `xml:",chardata"`
+}
+
+type yumPrimary struct {
+	XMLName  xml.Name            `xml:"metadata"`
+	Xmlns    string              `xml:"xmlns,attr"`
+	XmlnsRPM string              `xml:"xmlns:rpm,attr"`
+	Count    int                 `xml:"packages,attr"`
+	Packages []yumPrimaryPackage `xml:"package"`
+}
+
+type yumPrimaryPackage struct {
+	Type        string      `xml:"type,attr"`
+	Name        string      `xml:"name"`
+	Arch        string      `xml:"arch"`
+	Version     yumVersion  `xml:"version"`
+	Checksum    yumChecksum `xml:"checksum"`
+	Summary     string      `xml:"summary"`
+	Description string      `xml:"description"`
+	Packager    string      `xml:"packager"`
+	URL         string      `xml:"url"`
+	Time        yumTime     `xml:"time"`
+	Size        yumSize     `xml:"size"`
+	Location    yumLocation `xml:"location"`
+	Format      yumFormat   `xml:"format"`
+}
+
+type yumTime struct {
+	File  int64 `xml:"file,attr"`
+	Build int64 `xml:"build,attr"`
+}
+
+type yumSize struct {
+	Package   int64 `xml:"package,attr"`
+	Installed int64 `xml:"installed,attr"`
+	Archive   int64 `xml:"archive,attr"`
+}
+
+type yumFormat struct {
+	License     string         `xml:"rpm:license"`
+	Vendor      string         `xml:"rpm:vendor"`
+	Group       string         `xml:"rpm:group"`
+	BuildHost   string         `xml:"rpm:buildhost"`
+	SourceRPM   string         `xml:"rpm:sourcerpm"`
+	HeaderRange yumHeaderRange `xml:"rpm:header-range"`
+	Provides    *yumEntries    `xml:"rpm:provides"`
+	Requires    *yumEntries    `xml:"rpm:requires"`
+	Conflicts   *yumEntries    `xml:"rpm:conflicts"`
+	Obsoletes   *yumEntries    `xml:"rpm:obsoletes"`
+	Files       []yumFile      `xml:"file"`
+}
+
+type yumHeaderRange struct {
+	Start int64 `xml:"start,attr"`
+	End   int64 `xml:"end,attr"`
+}
+
+type yumEntries struct {
+	Entries []yumEntry `xml:"rpm:entry"`
+}
+
+type yumEntry struct {
+	Name  string `xml:"name,attr"`
+	Flags string `xml:"flags,attr,omitempty"`
+	Epoch string `xml:"epoch,attr,omitempty"`
+	Ver   string `xml:"ver,attr,omitempty"`
+	Rel   string `xml:"rel,attr,omitempty"`
+	Pre   string `xml:"pre,attr,omitempty"`
+}
+
+type yumFilelists struct {
+	XMLName  xml.Name              `xml:"filelists"`
+	Xmlns    string                `xml:"xmlns,attr"`
+	Count    int                   `xml:"packages,attr"`
+	Packages []yumFilelistsPackage `xml:"package"`
+}
+
+type yumFilelistsPackage struct {
+	PkgID   string     `xml:"pkgid,attr"`
+	Name    string     `xml:"name,attr"`
+	Arch    string     `xml:"arch,attr"`
+	Version yumVersion `xml:"version"`
+	Files   []yumFile  `xml:"file"`
+}
+
+type yumOther struct {
+	XMLName  xml.Name          `xml:"otherdata"`
+	Xmlns    string            `xml:"xmlns,attr"`
+	Count    int               `xml:"packages,attr"`
+	Packages []yumOtherPackage `xml:"package"`
+}
+
+type yumOtherPackage struct {
+	PkgID      string         `xml:"pkgid,attr"`
+	Name       string         `xml:"name,attr"`
+	Arch       string         `xml:"arch,attr"`
+	Version    yumVersion     `xml:"version"`
+	Changelogs []yumChangelog `xml:"changelog"`
+}
+
+type yumChangelog struct {
+	Author string `xml:"author,attr"`
+	Date   int64  `xml:"date,attr"`
+	Text   string `xml:",chardata"`
+}
+
+type yumRepomd struct {
+	XMLName  xml.Name        `xml:"repomd"`
+	Xmlns    string          `xml:"xmlns,attr"`
+	XmlnsRPM string          `xml:"xmlns:rpm,attr"`
+	Revision int64           `xml:"revision"`
+	Data     []yumRepomdData `xml:"data"`
+}
+
+type yumRepomdData struct {
+	Type         string      `xml:"type,attr"`
+	Checksum     yumChecksum `xml:"checksum"`
+	OpenChecksum yumChecksum `xml:"open-checksum"`
+	Location     yumLocation `xml:"location"`
+	Timestamp    int64       `xml:"timestamp"`
+	Size         int64       `xml:"size"`
+	OpenSize     int64       `xml:"open-size"`
+}